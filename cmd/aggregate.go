@@ -4,11 +4,19 @@ import (
 	"fmt"
 
 	"github.com/intelligrit/twi-map/internal/aggregator"
+	"github.com/intelligrit/twi-map/internal/config"
+	"github.com/intelligrit/twi-map/internal/model"
 	"github.com/intelligrit/twi-map/internal/store"
 	"github.com/spf13/cobra"
 )
 
-var aggregateCoords bool
+var (
+	aggregateCoords         bool
+	aggregateVerifiedQuotes bool
+	aggregateFilterFile     string
+	aggregateGazetteer      string
+	aggregateExplain        string
+)
 
 var aggregateCmd = &cobra.Command{
 	Use:   "aggregate",
@@ -20,11 +28,34 @@ var aggregateCmd = &cobra.Command{
 		}
 		defer s.Close()
 
-		fmt.Println("Aggregating extractions...")
-		data, err := aggregator.Aggregate(s)
+		if !cmd.Flags().Changed("filter-file") {
+			aggregateFilterFile = cfg.Aggregate.FilterFile
+		}
+		filter, err := aggregator.LoadNameFilter(aggregateFilterFile)
+		if err != nil {
+			return fmt.Errorf("loading filter file: %w", err)
+		}
+
+		if !cmd.Flags().Changed("gazetteer") {
+			aggregateGazetteer = cfg.Aggregate.Gazetteer
+		}
+		gaz, err := aggregator.LoadGazetteer(aggregateGazetteer)
+		if err != nil {
+			return fmt.Errorf("loading gazetteer: %w", err)
+		}
+
+		if aggregateExplain == "" {
+			fmt.Println("Aggregating extractions...")
+		}
+		data, err := aggregator.Aggregate(s, aggregator.Options{DropUnverifiedQuotes: aggregateVerifiedQuotes, Filter: filter, Gazetteer: gaz,
+			AutoMerge: cfg.Aggregate.AutoMerge, ReviewMerge: cfg.Aggregate.ReviewMerge, Inclusion: inclusionPolicy(cfg.Aggregate.Inclusion)})
 		if err != nil {
 			return fmt.Errorf("aggregation failed: %w", err)
 		}
+		if aggregateExplain != "" {
+			fmt.Print(aggregator.Explain(data, gaz, aggregateExplain))
+			return nil
+		}
 
 		if err := s.WriteAggregated(data); err != nil {
 			return fmt.Errorf("saving aggregated data: %w", err)
@@ -32,10 +63,37 @@ var aggregateCmd = &cobra.Command{
 
 		fmt.Printf("Aggregated: %d locations, %d relationships, %d containment rules\n",
 			len(data.Locations), len(data.Relationships), len(data.Containment))
+		if len(data.Rejected) > 0 {
+			fmt.Printf("Rejected %d names as non-locations (see 'twi-map review rejected')\n", len(data.Rejected))
+		}
+		if len(data.Merges) > 0 {
+			var merged int
+			for _, m := range data.Merges {
+				if m.Merged {
+					merged++
+					logVerbose("  merged %s and %s into %s: %s", m.A, m.B, m.ID, m.Detail)
+				} else {
+					logVerbose("  kept %s and %s apart (%s): %s", m.A, m.B, m.Reason, m.Detail)
+				}
+			}
+			fmt.Printf("Merged %d duplicate locations, refused %d merges\n", merged, len(data.Merges)-merged)
+		}
+		if len(data.Candidates) > 0 {
+			fmt.Printf("%d possible duplicates await review (see 'twi-map review merges')\n", len(data.Candidates))
+		}
+		var ambiguous int
+		for _, loc := range data.Locations {
+			if loc.TypeShare() < aggregator.AmbiguousTypeShare {
+				ambiguous++
+			}
+		}
+		if ambiguous > 0 {
+			fmt.Printf("%d locations have a disputed type (see 'twi-map review types')\n", ambiguous)
+		}
 
 		if aggregateCoords {
 			fmt.Println("Assigning coordinates...")
-			if err := aggregator.AssignCoordinates(s, data); err != nil {
+			if err := aggregator.AssignCoordinates(s, data, gaz); err != nil {
 				return fmt.Errorf("assigning coordinates: %w", err)
 			}
 			fmt.Println("Coordinates assigned.")
@@ -45,7 +103,28 @@ var aggregateCmd = &cobra.Command{
 	},
 }
 
+// inclusionPolicy converts the [aggregate.inclusion] config section.
+func inclusionPolicy(c config.InclusionConfig) *aggregator.InclusionPolicy {
+	p := &aggregator.InclusionPolicy{
+		MinMentions:  c.MinMentions,
+		Traceability: c.Traceability,
+		Include:      c.Include,
+		Exclude:      c.Exclude,
+	}
+	if len(c.MinMentionsByType) > 0 {
+		p.MinMentionsByType = make(map[model.LocationType]int, len(c.MinMentionsByType))
+		for t, n := range c.MinMentionsByType {
+			p.MinMentionsByType[model.LocationType(t)] = n
+		}
+	}
+	return p
+}
+
 func init() {
 	aggregateCmd.Flags().BoolVar(&aggregateCoords, "coords", true, "Assign estimated coordinates to locations")
+	aggregateCmd.Flags().BoolVar(&aggregateVerifiedQuotes, "verified-quotes", false, "Drop relationship quotes not found in the chapter text (run verify-quotes first)")
+	aggregateCmd.Flags().StringVar(&aggregateFilterFile, "filter-file", "filter.toml", "TOML file of location names to always allow or deny")
+	aggregateCmd.Flags().StringVar(&aggregateGazetteer, "gazetteer", "", "TOML gazetteer of canonical names, seed positions, and Earth names (default: built-in)")
+	aggregateCmd.Flags().StringVar(&aggregateExplain, "explain", "", "Print which inclusion rule keeps a location on or off the map, without saving anything")
 	rootCmd.AddCommand(aggregateCmd)
 }
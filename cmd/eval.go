@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/eval"
+	"github.com/intelligrit/twi-map/internal/extractor"
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	evalModel     string
+	evalReplay    bool
+	evalOut       string
+	evalBaseline  string
+	evalTolerance float64
+)
+
+// evalCmd scores the extractor against hand-annotated chapters. Each file
+// in the golden directory is a chapter extraction in the same JSON form the
+// extractor produces, corrected by hand.
+var evalCmd = &cobra.Command{
+	Use:   "eval <golden-dir>",
+	Short: "Score extraction against a directory of hand-annotated chapters",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("model") {
+			evalModel = cfg.Extract.Model
+		}
+
+		golden, err := eval.LoadGolden(args[0])
+		if err != nil {
+			return err
+		}
+
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		titles := make(map[int]string)
+		if toc, err := s.ReadTOC(); err == nil {
+			for _, ch := range toc.Chapters {
+				titles[ch.Index] = ch.WebTitle
+			}
+		}
+
+		report := &eval.Report{
+			Model:         evalModel,
+			PromptHash:    extractor.PromptHash(),
+			PromptVersion: extractor.PromptVersion,
+			Source:        "live",
+		}
+		var client *extractor.Client
+		var known knownLocationsFunc
+		if evalReplay {
+			report.Source = "replay"
+			report.Model = ""
+		} else {
+			provider, err := extractor.NewProvider(cfg.Extract.Provider, cfg.Extract.BaseURL)
+			if err != nil {
+				return err
+			}
+			client = newExtractClient(provider, evalModel)
+			src, err := knownLocations(s, cfg.Extract.KnownLocations)
+			if err != nil {
+				return err
+			}
+			known = src.before
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		for _, g := range golden {
+			if g.ChapterTitle == "" {
+				g.ChapterTitle = titles[g.ChapterIndex]
+			}
+			text, err := s.ReadChapterText(g.ChapterIndex)
+			if err != nil {
+				return fmt.Errorf("chapter %d is annotated but not scraped: %w", g.ChapterIndex, err)
+			}
+
+			var raw *model.RawExtraction
+			if evalReplay {
+				if raw, err = s.ReadRawExtraction(g.ChapterIndex); err != nil {
+					return fmt.Errorf("no stored response for chapter %d: %w", g.ChapterIndex, err)
+				}
+				// A replay is only comparable when every response came from
+				// the same model and prompt.
+				report.Model = mergeLabel(report.Model, raw.Model)
+				report.PromptHash = mergeLabel(report.PromptHash, raw.PromptHash)
+				report.PromptVersion = raw.PromptVersion
+			} else {
+				res, err := client.ExtractWindows(ctx, g.ChapterTitle, text, known(g.ChapterIndex))
+				if err != nil {
+					return fmt.Errorf("extracting chapter %d: %w", g.ChapterIndex, err)
+				}
+				raw = extractor.NewRawExtraction(g.ChapterIndex, client.Model, res.Texts, res.StopReasons, res.Usage)
+			}
+
+			pred, err := extractor.BuildConsensus(raw, g.ChapterTitle, text, cfg.Extract.Agreement)
+			if err != nil {
+				// An unparseable reply predicts nothing.
+				fmt.Fprintf(os.Stderr, "  chapter %d: PARSE ERROR: %v\n", g.ChapterIndex, err)
+				pred = &model.ChapterExtraction{ChapterIndex: g.ChapterIndex}
+			}
+			report.Add(g, pred)
+			logVerbose("  chapter %d: %d predicted, %d annotated locations", g.ChapterIndex, len(pred.Locations), len(g.Locations))
+		}
+
+		printEvalReport(report)
+
+		if evalOut != "" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(evalOut, append(data, '\n'), 0o644); err != nil {
+				return err
+			}
+		}
+
+		if evalBaseline != "" {
+			data, err := os.ReadFile(evalBaseline)
+			if err != nil {
+				return err
+			}
+			var baseline eval.Report
+			if err := json.Unmarshal(data, &baseline); err != nil {
+				return fmt.Errorf("reading baseline: %w", err)
+			}
+			if !slices.Equal(baseline.Chapters, report.Chapters) {
+				return fmt.Errorf("baseline covers chapters %v, this run %v; reports are not comparable", baseline.Chapters, report.Chapters)
+			}
+			if regressions := report.Regressions(&baseline, evalTolerance); len(regressions) > 0 {
+				return fmt.Errorf("regressed against %s: %s", evalBaseline, strings.Join(regressions, "; "))
+			}
+			fmt.Printf("\nNo regressions against %s.\n", evalBaseline)
+		}
+		return nil
+	},
+}
+
+// mergeLabel returns label, or "mixed" if it differs from a non-empty prev.
+func mergeLabel(prev, label string) string {
+	if prev != "" && prev != label {
+		return "mixed"
+	}
+	return label
+}
+
+func printEvalReport(r *eval.Report) {
+	fmt.Printf("Evaluation (%s, %s, prompt v%d %s, %d chapters)\n", r.Source, r.Model, r.PromptVersion, r.PromptHash, len(r.Chapters))
+	fmt.Printf("==========\n")
+	printCountsTable("Locations", r.Locations, r.LocationTypes)
+	fmt.Printf("  name accuracy  %.3f (%d / %d matched)\n", r.NameAccuracy(), r.NameMatches, r.Locations.Matched)
+	printCountsTable("Relationships", r.Relationships, r.RelationshipTypes)
+	fmt.Printf("\nQuotes verified: %.3f (%d / %d)\n", r.QuoteRate(), r.QuotesVerified, r.Quotes)
+}
+
+func printCountsTable(title string, total eval.Counts, byType map[string]eval.Counts) {
+	fmt.Printf("\n%-16s %9s %6s %6s %6s %6s %6s\n", title, "precision", "recall", "f1", "match", "pred", "gold")
+	line := func(name string, c eval.Counts) {
+		fmt.Printf("  %-14s %9.3f %6.3f %6.3f %6d %6d %6d\n", name, c.Precision(), c.Recall(), c.F1(), c.Matched, c.Predicted, c.Gold)
+	}
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	for _, t := range types {
+		line(t, byType[t])
+	}
+	line("all", total)
+}
+
+func init() {
+	evalCmd.Flags().StringVar(&evalModel, "model", "claude-sonnet-4-20250514", "Model to evaluate")
+	evalCmd.Flags().BoolVar(&evalReplay, "replay", false, "Score stored raw responses instead of calling the API")
+	evalCmd.Flags().StringVar(&evalOut, "out", "", "Write the report as JSON to this file")
+	evalCmd.Flags().StringVar(&evalBaseline, "baseline", "", "Fail if headline scores fall below this JSON report")
+	evalCmd.Flags().Float64Var(&evalTolerance, "tolerance", 0.01, "Allowed drop in each headline score against --baseline")
+	rootCmd.AddCommand(evalCmd)
+}
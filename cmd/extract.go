@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"time"
 
+	"github.com/intelligrit/twi-map/internal/aggregator"
 	"github.com/intelligrit/twi-map/internal/extractor"
 	"github.com/intelligrit/twi-map/internal/model"
 	"github.com/intelligrit/twi-map/internal/store"
@@ -14,18 +14,53 @@ import (
 )
 
 var (
-	extractVolume string
-	extractModel  string
+	extractVolume      string
+	extractModel       string
+	extractBatch       bool
+	extractConcurrency int
+	extractReparse     bool
+	extractChapters    string
+	extractWhereModel  string
+	extractOlderPrompt string
+	extractFailedOnly  bool
+	extractForce       bool
+	extractDryRun      bool
+	extractMaxSpend    float64
+	extractKnown       int
+	extractSamples     int
+	extractSampleModel []string
+	extractAgreement   float64
 )
 
 var extractCmd = &cobra.Command{
 	Use:   "extract",
-	Short: "Extract location data from chapter text using Claude API",
+	Short: "Extract location data from chapter text using an LLM",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if !cmd.Flags().Changed("model") {
 			extractModel = cfg.Extract.Model
 		}
 
+		sel := chapterSelector{
+			volume:     extractVolume,
+			whereModel: extractWhereModel,
+			failedOnly: extractFailedOnly,
+			force:      extractForce,
+		}
+		if extractChapters != "" {
+			inRange, err := parseChapterRanges(extractChapters)
+			if err != nil {
+				return err
+			}
+			sel.inRange = inRange
+		}
+		if extractOlderPrompt != "" {
+			v, err := parsePromptVersion(extractOlderPrompt)
+			if err != nil {
+				return err
+			}
+			sel.olderThanPrompt = v
+		}
+
 		s, err := store.New(dataDir)
 		if err != nil {
 			return err
@@ -37,93 +72,224 @@ var extractCmd = &cobra.Command{
 			return fmt.Errorf("reading TOC (run scrape-toc first): %w", err)
 		}
 
-		client, err := extractor.NewClient(extractModel, cfg.Extract.MaxTokens)
+		if !cmd.Flags().Changed("agreement") {
+			extractAgreement = cfg.Extract.Agreement
+		}
+		if extractAgreement <= 0 || extractAgreement > 1 {
+			return fmt.Errorf("agreement must be a share between 0 and 1, got %g", extractAgreement)
+		}
+
+		if extractReparse {
+			var chapters []model.Chapter
+			for _, ch := range toc.Chapters {
+				if sel.matches(ch) {
+					chapters = append(chapters, ch)
+				}
+			}
+			return reparseExtractions(s, chapters, extractAgreement)
+		}
+
+		// Chapters already submitted in an uncollected batch would be paid for twice.
+		inBatch, err := pendingBatchChapters(s)
 		if err != nil {
 			return err
 		}
 
-		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-		defer cancel()
+		metas, err := s.ReadExtractionMeta()
+		if err != nil {
+			return fmt.Errorf("reading extractions: %w", err)
+		}
 
 		var toExtract []model.Chapter
 		for _, ch := range toc.Chapters {
-			if extractVolume != "" && ch.Volume != extractVolume {
-				continue
-			}
 			if !s.ChapterTextExists(ch.Index) {
 				continue // skip chapters we haven't scraped
 			}
-			if s.ExtractionExists(ch.Index) {
-				continue // skip already extracted
+			if !sel.include(ch, metas[ch.Index], s.RawExtractionExists(ch.Index)) {
+				continue // already extracted, or not selected for re-extraction
+			}
+			if inBatch[ch.Index] {
+				logVerbose("  skipping %s: pending in a batch", ch.WebTitle)
+				continue
 			}
 			toExtract = append(toExtract, ch)
 		}
 
 		if len(toExtract) == 0 {
-			fmt.Println("All matching chapters already extracted.")
+			fmt.Println("No matching chapters to extract.")
 			return nil
 		}
 
-		fmt.Printf("Extracting locations from %d chapters using %s...\n", len(toExtract), extractModel)
+		if !cmd.Flags().Changed("known-locations") {
+			extractKnown = cfg.Extract.KnownLocations
+		}
+		known, err := knownLocations(s, extractKnown)
+		if err != nil {
+			return err
+		}
 
-		var totalInput, totalOutput int
-		for i, ch := range toExtract {
-			select {
-			case <-ctx.Done():
-				fmt.Printf("\nInterrupted after %d/%d chapters\n", i, len(toExtract))
-				return nil
-			default:
+		if !cmd.Flags().Changed("samples") {
+			extractSamples = cfg.Extract.Samples
+		}
+		if !cmd.Flags().Changed("sample-models") {
+			extractSampleModel = cfg.Extract.SampleModels
+		}
+		extractSamples = max(extractSamples, 1)
+		if extractBatch && extractSamples > 1 {
+			return fmt.Errorf("--samples can't be combined with --batch; extract directly instead")
+		}
+
+		// The provider is attached after the dry-run check, so estimates
+		// don't need an API key.
+		samples := newSampleClients(extractModel, extractSampleModel, extractSamples)
+		client := samples[0]
+
+		for _, c := range samples {
+			if _, ok := cfg.Extract.Prices[c.Model]; !ok && extractMaxSpend > 0 {
+				return fmt.Errorf("--max-spend needs a price for %s under [extract.prices] in config.toml", c.Model)
 			}
+		}
 
-			text, err := s.ReadChapterText(ch.Index)
+		// A batch can't be stopped part way, so its budget is checked against
+		// the estimate up front.
+		if extractDryRun || (extractBatch && extractMaxSpend > 0) {
+			estimates, err := estimateExtraction(s, client, toExtract, known.before)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "  WARNING: failed to read chapter %d: %v\n", ch.Index, err)
-				continue
+				return err
+			}
+			var cost float64
+			for _, run := range sampleRuns(samples) {
+				cost += printEstimate(run.model, scaleEstimates(estimates, run.count), extractBatch)
+			}
+			if extractDryRun {
+				return nil
 			}
+			if cost > extractMaxSpend {
+				return fmt.Errorf("estimated cost $%.2f exceeds --max-spend $%.2f; narrow the selection with --volume or --chapters", cost, extractMaxSpend)
+			}
+		}
 
-			fmt.Printf("  [%d/%d] %s (%d chars)...", i+1, len(toExtract), ch.WebTitle, len(text))
+		provider, err := extractor.NewProvider(cfg.Extract.Provider, cfg.Extract.BaseURL)
+		if err != nil {
+			return err
+		}
+		for _, c := range samples {
+			c.Provider = provider
+		}
 
-			rawResp, usage, err := client.Extract(ctx, ch.WebTitle, text)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, " ERROR: %v\n", err)
-				continue
-			}
+		if extractBatch {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+			return submitExtractionBatch(ctx, s, client, toExtract, known.before)
+		}
 
-			totalInput += usage.InputTokens
-			totalOutput += usage.OutputTokens
+		if !cmd.Flags().Changed("concurrency") {
+			extractConcurrency = cfg.Extract.Concurrency
+		}
+		return runExtractionPool(s, toExtract, poolOptions{
+			workers:   max(extractConcurrency, 1),
+			samples:   samples,
+			agreement: extractAgreement,
+			prices:    cfg.Extract.Prices,
+			maxSpend:  extractMaxSpend,
+			known:     known,
+		})
+	},
+}
 
-			parsed, err := extractor.ParseExtraction(rawResp)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, " PARSE ERROR: %v\n", err)
-				continue
-			}
+// newExtractClient creates a client for modelName configured from the
+// [extract] section of the config.
+func newExtractClient(provider extractor.Provider, modelName string) *extractor.Client {
+	client := extractor.NewClient(provider, modelName, cfg.Extract.MaxTokens)
+	client.Retry.MaxAttempts = cfg.Extract.MaxAttempts
+	client.Limiter = extractor.NewTokenLimiter(cfg.Extract.TokensPerMinute)
+	client.ChunkChars = cfg.Extract.ChunkChars
+	client.ChunkOverlap = cfg.Extract.ChunkOverlap
+	client.Structured = cfg.Extract.StructuredOutput
+	return client
+}
 
-			ext := &model.ChapterExtraction{
-				ChapterIndex:  ch.Index,
-				ChapterTitle:  ch.WebTitle,
-				Locations:     parsed.Locations,
-				Relationships: parsed.Relationships,
-				Containment:   parsed.Containment,
-				Model:         extractModel,
-				ExtractedAt:   time.Now().UTC().Format(time.RFC3339),
-			}
+// newSampleClients creates one client per run of each chapter, cycling
+// through models (or using modelName alone if none are given). The clients
+// share one token budget.
+func newSampleClients(modelName string, models []string, samples int) []*extractor.Client {
+	if len(models) == 0 {
+		models = []string{modelName}
+	}
+	clients := make([]*extractor.Client, samples)
+	for i := range clients {
+		clients[i] = newExtractClient(nil, models[i%len(models)])
+		clients[i].Limiter = clients[0].Limiter
+	}
+	return clients
+}
 
-			if err := s.WriteExtraction(ext); err != nil {
-				return fmt.Errorf("saving extraction: %w", err)
-			}
+// knownLocationsFunc lists, for a chapter, the locations from earlier
+// chapters to name in its extraction prompt.
+type knownLocationsFunc func(chapterIdx int) []extractor.KnownLocation
 
-			fmt.Printf(" %d locations, %d relationships (%d+%d tokens)\n",
-				len(parsed.Locations), len(parsed.Relationships),
-				usage.InputTokens, usage.OutputTokens)
-		}
+// knownSource lists, for a chapter, the locations from earlier chapters to
+// name in its extraction prompt, drawn from the extractions stored when it
+// was created and those it has learned since.
+type knownSource struct {
+	idx   *aggregator.KnownIndex // nil when no locations are listed
+	limit int
+}
 
-		fmt.Printf("\nDone. Total tokens: %d input, %d output\n", totalInput, totalOutput)
+// before lists up to limit locations from chapters before chapterIdx.
+func (k *knownSource) before(chapterIdx int) []extractor.KnownLocation {
+	if k.idx == nil {
 		return nil
-	},
+	}
+	return extractor.KnownLocationsBefore(k.idx.Before(chapterIdx), chapterIdx, k.limit)
+}
+
+// learn adds an extraction saved during the run, so the chapters after it
+// that haven't been sent yet see its locations.
+func (k *knownSource) learn(ext *model.ChapterExtraction) {
+	if k.idx != nil {
+		k.idx.Add(ext)
+	}
+}
+
+// knownLocations returns a knownSource drawing up to limit names from the
+// extractions of earlier chapters, keyed and filtered as aggregation would.
+// It lists none when limit is 0.
+func knownLocations(s *store.Store, limit int) (*knownSource, error) {
+	if limit <= 0 {
+		return &knownSource{}, nil
+	}
+	filter, err := aggregator.LoadNameFilter(cfg.Aggregate.FilterFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading filter file: %w", err)
+	}
+	gaz, err := aggregator.LoadGazetteer(cfg.Aggregate.Gazetteer)
+	if err != nil {
+		return nil, fmt.Errorf("loading gazetteer: %w", err)
+	}
+	idx, err := aggregator.NewKnownIndex(s, aggregator.Options{Filter: filter, Gazetteer: gaz})
+	if err != nil {
+		return nil, fmt.Errorf("reading earlier extractions: %w", err)
+	}
+	return &knownSource{idx: idx, limit: limit}, nil
 }
 
 func init() {
 	extractCmd.Flags().StringVar(&extractVolume, "volume", "", "Only extract from this volume (e.g. vol-1)")
-	extractCmd.Flags().StringVar(&extractModel, "model", "claude-sonnet-4-20250514", "Anthropic model to use")
+	extractCmd.Flags().StringVar(&extractModel, "model", "claude-sonnet-4-20250514", "Model to use")
+	extractCmd.Flags().IntVar(&extractConcurrency, "concurrency", 1, "Number of chapters to extract in parallel")
+	extractCmd.Flags().BoolVar(&extractBatch, "batch", false, "Submit chapters as a Message Batch; collect later with 'extract collect'")
+	extractCmd.Flags().StringVar(&extractChapters, "chapters", "", "Only consider these chapter indices (e.g. 120-180 or 3,7,10-12)")
+	extractCmd.Flags().StringVar(&extractWhereModel, "where-model", "", "Re-extract chapters last extracted with this model")
+	extractCmd.Flags().StringVar(&extractOlderPrompt, "older-than-prompt", "", "Re-extract chapters extracted with a prompt older than this version (e.g. v3)")
+	extractCmd.Flags().BoolVar(&extractFailedOnly, "failed-only", false, "Re-extract only chapters whose last reply was truncated or didn't parse")
+	extractCmd.Flags().BoolVar(&extractForce, "force", false, "Re-extract matching chapters even if already extracted")
+	extractCmd.Flags().BoolVar(&extractDryRun, "dry-run", false, "Estimate tokens and cost per volume without calling the API")
+	extractCmd.Flags().Float64Var(&extractMaxSpend, "max-spend", 0, "Stop dispatching chapters once the run has spent this many US dollars; chapters in flight still finish, so it can be overshot by up to --concurrency chapters (0 = no limit)")
+	extractCmd.Flags().IntVar(&extractKnown, "known-locations", 0, "Name up to this many locations from earlier chapters in each prompt (0 = none)")
+	extractCmd.Flags().IntVar(&extractSamples, "samples", 1, "Extract each chapter this many times and merge the runs by vote")
+	extractCmd.Flags().StringSliceVar(&extractSampleModel, "sample-models", nil, "Models to cycle through the samples (default: --model)")
+	extractCmd.Flags().Float64Var(&extractAgreement, "agreement", 0.5, "Share of samples that must find a location or relationship to keep it")
+	extractCmd.Flags().BoolVar(&extractReparse, "reparse", false, "Rebuild extractions from stored raw responses without calling the API")
 	rootCmd.AddCommand(extractCmd)
 }
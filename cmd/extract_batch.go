@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/intelligrit/twi-map/internal/extractor"
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var extractPollCmd = &cobra.Command{
+	Use:   "poll",
+	Short: "Show the status of submitted extraction batches",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		pending, err := s.ReadPendingBatches()
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("No pending batches.")
+			return nil
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		for _, pb := range pending {
+			client, err := batchClient(pb.Model)
+			if err != nil {
+				return err
+			}
+			b, err := client.GetBatch(ctx, pb.ID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  %s: ERROR: %v\n", pb.ID, err)
+				continue
+			}
+			c := b.RequestCounts
+			fmt.Printf("  %s  %-11s  %d chapters  processing: %d  succeeded: %d  errored: %d  (submitted %s)\n",
+				b.ID, b.ProcessingStatus, len(pb.Chapters), c.Processing, c.Succeeded, c.Errored+c.Canceled+c.Expired, pb.SubmittedAt)
+		}
+		return nil
+	},
+}
+
+var extractCollectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Store the results of finished extraction batches",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		toc, err := s.ReadTOC()
+		if err != nil {
+			return fmt.Errorf("reading TOC (run scrape-toc first): %w", err)
+		}
+		titles := make(map[int]string)
+		for _, ch := range toc.Chapters {
+			titles[ch.Index] = ch.WebTitle
+		}
+
+		pending, err := s.ReadPendingBatches()
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("No pending batches.")
+			return nil
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		var totalInput, totalOutput, stored, failed int
+		for _, pb := range pending {
+			client, err := batchClient(pb.Model)
+			if err != nil {
+				return err
+			}
+			b, err := client.GetBatch(ctx, pb.ID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  %s: ERROR: %v\n", pb.ID, err)
+				continue
+			}
+			if !b.Ended() {
+				fmt.Printf("  %s: still %s, skipping\n", pb.ID, b.ProcessingStatus)
+				continue
+			}
+
+			b.Prefills = pb.Prefills
+			outcomes, err := client.CollectBatch(ctx, b)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  %s: ERROR: %v\n", pb.ID, err)
+				continue
+			}
+
+			fmt.Printf("Collecting %d results from %s...\n", len(outcomes), pb.ID)
+			for _, out := range outcomes {
+				title := titles[out.ChapterIndex]
+				if out.Err != nil {
+					fmt.Fprintf(os.Stderr, "  %s: ERROR: %v\n", title, out.Err)
+					failed++
+					continue
+				}
+
+				totalInput += out.Usage.InputTokens
+				totalOutput += out.Usage.OutputTokens
+
+				raw := extractor.NewRawExtraction(out.ChapterIndex, pb.Model, out.Texts, out.StopReasons, out.Usage)
+				// Credit the prompt the batch was sent with, which may
+				// have changed since.
+				raw.PromptHash, raw.PromptVersion = pb.PromptHash, pb.PromptVersion
+				text, _ := s.ReadChapterText(out.ChapterIndex)
+				ext, err := extractor.BuildExtraction(raw, title, text)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "  %s: PARSE ERROR: %v\n", title, err)
+					failed++
+					// Keep the reply for --reparse, unless it would replace
+					// the one behind an earlier extraction.
+					if !s.ExtractionExists(out.ChapterIndex) {
+						if err := s.WriteRawExtraction(raw); err != nil {
+							return fmt.Errorf("saving raw response: %w", err)
+						}
+					}
+					continue
+				}
+				if err := s.WriteExtractionWithRaw(ext, raw); err != nil {
+					return fmt.Errorf("saving extraction: %w", err)
+				}
+				stored++
+				if ext.Partial {
+					fmt.Fprintf(os.Stderr, "  %s: response truncated, kept %d locations\n", title, len(ext.Locations))
+				}
+
+				logVerbose("  %s: %d locations, %d relationships, %d warning(s) (%d+%d tokens)", title,
+					len(ext.Locations), len(ext.Relationships), len(ext.Warnings), out.Usage.InputTokens, out.Usage.OutputTokens)
+			}
+
+			// Failed chapters are left unextracted so the next run picks them up.
+			if err := s.DeletePendingBatch(pb.ID); err != nil {
+				return fmt.Errorf("clearing pending batch: %w", err)
+			}
+		}
+
+		fmt.Printf("\nDone. Stored %d chapters, %d failed. Total tokens: %d input, %d output\n",
+			stored, failed, totalInput, totalOutput)
+		return nil
+	},
+}
+
+// submitExtractionBatch sends every chapter in toExtract as one Message Batch
+// and records it in the store so 'extract collect' can resume it later.
+func submitExtractionBatch(ctx context.Context, s *store.Store, client *extractor.Client, toExtract []model.Chapter, known knownLocationsFunc) error {
+	chapters := make([]extractor.BatchChapter, 0, len(toExtract))
+	indices := make([]int, 0, len(toExtract))
+	for _, ch := range toExtract {
+		text, err := s.ReadChapterText(ch.Index)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  WARNING: failed to read chapter %d: %v\n", ch.Index, err)
+			continue
+		}
+		chapters = append(chapters, extractor.BatchChapter{Index: ch.Index, Title: ch.WebTitle, Text: text, Known: known(ch.Index)})
+		indices = append(indices, ch.Index)
+	}
+
+	fmt.Printf("Submitting batch of %d chapters using %s...\n", len(chapters), client.Model)
+	b, err := client.SubmitBatch(ctx, chapters)
+	if err != nil {
+		return fmt.Errorf("submitting batch: %w", err)
+	}
+
+	pb := &store.PendingBatch{
+		ID:            b.ID,
+		Model:         client.Model,
+		SubmittedAt:   time.Now().UTC().Format(time.RFC3339),
+		Chapters:      indices,
+		Prefills:      b.Prefills,
+		PromptHash:    extractor.PromptHash(),
+		PromptVersion: extractor.PromptVersion,
+	}
+	if err := s.WritePendingBatch(pb); err != nil {
+		return fmt.Errorf("recording batch %s: %w", b.ID, err)
+	}
+
+	fmt.Printf("Submitted %s. Check progress with 'twi-map extract poll' and store results with 'twi-map extract collect'.\n", b.ID)
+	return nil
+}
+
+// pendingBatchChapters returns the chapters covered by uncollected batches.
+func pendingBatchChapters(s *store.Store) (map[int]bool, error) {
+	pending, err := s.ReadPendingBatches()
+	if err != nil {
+		return nil, fmt.Errorf("reading pending batches: %w", err)
+	}
+	in := make(map[int]bool)
+	for _, pb := range pending {
+		for _, idx := range pb.Chapters {
+			in[idx] = true
+		}
+	}
+	return in, nil
+}
+
+// batchClient builds a client for polling or collecting a batch submitted with the given model.
+func batchClient(modelName string) (*extractor.Client, error) {
+	provider, err := extractor.NewProvider(cfg.Extract.Provider, cfg.Extract.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	client := extractor.NewClient(provider, modelName, cfg.Extract.MaxTokens)
+	client.ChunkChars = cfg.Extract.ChunkChars
+	client.ChunkOverlap = cfg.Extract.ChunkOverlap
+	client.Structured = cfg.Extract.StructuredOutput
+	return client, nil
+}
+
+func init() {
+	extractCmd.AddCommand(extractPollCmd)
+	extractCmd.AddCommand(extractCollectCmd)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/intelligrit/twi-map/internal/extractor"
+	"github.com/intelligrit/twi-map/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	charactersVolume   string
+	charactersChapters string
+	charactersModel    string
+	charactersForce    bool
+)
+
+// extractCharactersCmd runs the optional second extraction pass, recording
+// where named characters are and which named events happen in each chapter.
+// It is independent of the location extraction and can run before or after it.
+var extractCharactersCmd = &cobra.Command{
+	Use:   "characters",
+	Short: "Extract character sightings and named events from chapter text",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("model") {
+			charactersModel = cfg.Extract.Model
+		}
+
+		sel := chapterSelector{volume: charactersVolume}
+		if charactersChapters != "" {
+			inRange, err := parseChapterRanges(charactersChapters)
+			if err != nil {
+				return err
+			}
+			sel.inRange = inRange
+		}
+
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		toc, err := s.ReadTOC()
+		if err != nil {
+			return fmt.Errorf("reading TOC (run scrape-toc first): %w", err)
+		}
+
+		provider, err := extractor.NewProvider(cfg.Extract.Provider, cfg.Extract.BaseURL)
+		if err != nil {
+			return err
+		}
+		client := newExtractClient(provider, charactersModel)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		var extracted, failed int
+		for _, ch := range toc.Chapters {
+			if !sel.matches(ch) || !s.ChapterTextExists(ch.Index) {
+				continue
+			}
+			if !charactersForce && s.CharactersExtracted(ch.Index) {
+				continue
+			}
+			if ctx.Err() != nil {
+				break
+			}
+
+			text, err := s.ReadChapterText(ch.Index)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  %s WARNING: failed to read chapter %d: %v\n", ch.WebTitle, ch.Index, err)
+				failed++
+				continue
+			}
+			res, err := client.ExtractCharacters(ctx, ch.WebTitle, text)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  %s ERROR after %d attempt(s): %v\n", ch.WebTitle, res.Attempts, err)
+				failed++
+				continue
+			}
+			chars, err := extractor.BuildCharacters(ch.Index, client.Model, ch.WebTitle, res.Texts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  %s PARSE ERROR: %v\n", ch.WebTitle, err)
+				failed++
+				continue
+			}
+			if err := s.WriteCharacters(chars); err != nil {
+				return fmt.Errorf("saving %s: %w", ch.WebTitle, err)
+			}
+			extracted++
+			fmt.Printf("  %s: %d sightings, %d events (%d+%d tokens)\n",
+				ch.WebTitle, len(chars.Sightings), len(chars.Events), res.Usage.InputTokens, res.Usage.OutputTokens)
+		}
+
+		if ctx.Err() != nil {
+			fmt.Println("Interrupted.")
+		}
+		fmt.Printf("Extracted characters from %d chapters (%d failed).\n", extracted, failed)
+		return nil
+	},
+}
+
+func init() {
+	extractCharactersCmd.Flags().StringVar(&charactersVolume, "volume", "", "Only extract from this volume (e.g. vol-1)")
+	extractCharactersCmd.Flags().StringVar(&charactersChapters, "chapters", "", "Only consider these chapter indices (e.g. 120-180 or 3,7,10-12)")
+	extractCharactersCmd.Flags().StringVar(&charactersModel, "model", "claude-sonnet-4-20250514", "Model to use")
+	extractCharactersCmd.Flags().BoolVar(&charactersForce, "force", false, "Re-extract chapters that already have character data")
+	extractCmd.AddCommand(extractCharactersCmd)
+}
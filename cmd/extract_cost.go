@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/intelligrit/twi-map/internal/config"
+	"github.com/intelligrit/twi-map/internal/extractor"
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+// batchDiscount is the price of a Message Batch request relative to a
+// direct one.
+const batchDiscount = 0.5
+
+// volumeEstimate totals the estimated usage of one volume's chapters.
+type volumeEstimate struct {
+	volume   string
+	chapters int
+	usage    extractor.Usage
+}
+
+// estimateExtraction estimates the usage of extracting chapters, grouped by
+// volume in reading order.
+func estimateExtraction(s *store.Store, client *extractor.Client, chapters []model.Chapter, known knownLocationsFunc) ([]volumeEstimate, error) {
+	var estimates []volumeEstimate
+	byVolume := make(map[string]int)
+	for _, ch := range chapters {
+		text, err := s.ReadChapterText(ch.Index)
+		if err != nil {
+			return nil, fmt.Errorf("reading chapter %d: %w", ch.Index, err)
+		}
+		i, ok := byVolume[ch.Volume]
+		if !ok {
+			i = len(estimates)
+			byVolume[ch.Volume] = i
+			estimates = append(estimates, volumeEstimate{volume: ch.Volume})
+		}
+		u := client.EstimateChapter(ch.WebTitle, text, known(ch.Index))
+		estimates[i].chapters++
+		estimates[i].usage.InputTokens += u.InputTokens
+		estimates[i].usage.OutputTokens += u.OutputTokens
+		logVerbose("  %s: ~%d input, ~%d output tokens", ch.WebTitle, u.InputTokens, u.OutputTokens)
+	}
+	return estimates, nil
+}
+
+// sampleRun counts the runs of each chapter made with one model.
+type sampleRun struct {
+	model string
+	count int
+}
+
+// sampleRuns groups sample clients by model, in first-use order.
+func sampleRuns(clients []*extractor.Client) []sampleRun {
+	var runs []sampleRun
+	for _, c := range clients {
+		i := slices.IndexFunc(runs, func(r sampleRun) bool { return r.model == c.Model })
+		if i < 0 {
+			runs = append(runs, sampleRun{model: c.Model})
+			i = len(runs) - 1
+		}
+		runs[i].count++
+	}
+	return runs
+}
+
+// scaleEstimates multiplies estimated usage by n runs per chapter.
+func scaleEstimates(estimates []volumeEstimate, n int) []volumeEstimate {
+	scaled := slices.Clone(estimates)
+	for i := range scaled {
+		scaled[i].usage.InputTokens *= n
+		scaled[i].usage.OutputTokens *= n
+	}
+	return scaled
+}
+
+// printEstimate prints per-volume and total estimates, priced when the
+// model has an entry in [extract.prices]. It returns the total cost, or 0
+// if the model has no price.
+func printEstimate(modelName string, estimates []volumeEstimate, batch bool) float64 {
+	price, priced := cfg.Extract.Prices[modelName]
+	if batch {
+		price.Input *= batchDiscount
+		price.Output *= batchDiscount
+	}
+
+	fmt.Printf("Estimated usage for %s", modelName)
+	if batch {
+		fmt.Print(" (batch pricing)")
+	}
+	fmt.Println(":")
+
+	var total volumeEstimate
+	for _, e := range estimates {
+		printEstimateLine(e, price, priced)
+		total.chapters += e.chapters
+		total.usage.InputTokens += e.usage.InputTokens
+		total.usage.OutputTokens += e.usage.OutputTokens
+	}
+	total.volume = "Total"
+	printEstimateLine(total, price, priced)
+
+	if !priced {
+		fmt.Printf("No price configured for %s; add it under [extract.prices] in config.toml.\n", modelName)
+		return 0
+	}
+	return price.Cost(total.usage.InputTokens, total.usage.OutputTokens)
+}
+
+func printEstimateLine(e volumeEstimate, price config.ModelPrice, priced bool) {
+	cost := ""
+	if priced {
+		cost = fmt.Sprintf("  $%.2f", price.Cost(e.usage.InputTokens, e.usage.OutputTokens))
+	}
+	fmt.Printf("  %-8s %4d chapters  ~%d input, ~%d output tokens%s\n",
+		e.volume, e.chapters, e.usage.InputTokens, e.usage.OutputTokens, cost)
+}
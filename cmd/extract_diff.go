@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/aggregator"
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffChapter int
+	diffA       string
+	diffB       string
+	diffJSON    bool
+)
+
+// extractDiffCmd compares two extractions of one chapter, e.g. the archived
+// run from the previous model against the current one.
+var extractDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what changed between two extractions of a chapter",
+	Long: `Compare two extractions of a chapter. Versions are "current" or the
+number of an archived version; by default the newest archived version is
+compared with the current extraction. Names are matched as the aggregator
+matches them, so spelling-only changes show up as modifications.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		versions, err := s.ExtractionVersions(diffChapter)
+		if err != nil {
+			return fmt.Errorf("listing versions: %w", err)
+		}
+		if diffA == "" {
+			if len(versions) == 0 {
+				return fmt.Errorf("chapter %d has no archived versions to compare; pass --a and --b", diffChapter)
+			}
+			diffA = strconv.Itoa(versions[0].Version)
+		}
+
+		a, err := readExtractionVersion(s, diffChapter, diffA)
+		if err != nil {
+			return err
+		}
+		b, err := readExtractionVersion(s, diffChapter, diffB)
+		if err != nil {
+			return err
+		}
+		gaz, err := aggregator.LoadGazetteer(cfg.Aggregate.Gazetteer)
+		if err != nil {
+			return fmt.Errorf("loading gazetteer: %w", err)
+		}
+		d := aggregator.DiffExtractions(a, b, gaz)
+
+		if diffJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				A versionLabel `json:"a"`
+				B versionLabel `json:"b"`
+				*aggregator.ExtractionDiff
+			}{newVersionLabel(diffA, a), newVersionLabel(diffB, b), d})
+		}
+
+		fmt.Printf("Chapter %d: %s -> %s\n", diffChapter, newVersionLabel(diffA, a), newVersionLabel(diffB, b))
+		if d.Empty() {
+			fmt.Println("No differences.")
+			return nil
+		}
+		printChanges("Locations", d.Locations, func(l *model.ExtractedLocation) string {
+			return fmt.Sprintf("%s (%s)", l.Name, l.Type)
+		})
+		printChanges("Relationships", d.Relationships, func(r *model.ExtractedRelationship) string {
+			return fmt.Sprintf("%s -> %s (%s)", r.From, r.To, r.Type)
+		})
+		printChanges("Containment", d.Containment, func(c *model.Containment) string {
+			return fmt.Sprintf("%s in %s", c.Child, c.Parent)
+		})
+		return nil
+	},
+}
+
+// versionLabel identifies one side of a diff.
+type versionLabel struct {
+	Version     string `json:"version"`
+	Model       string `json:"model"`
+	ExtractedAt string `json:"extracted_at"`
+}
+
+func newVersionLabel(version string, ext *model.ChapterExtraction) versionLabel {
+	return versionLabel{Version: version, Model: ext.Model, ExtractedAt: ext.ExtractedAt}
+}
+
+func (v versionLabel) String() string {
+	name := "version " + v.Version
+	if v.Version == "current" {
+		name = "current"
+	}
+	return fmt.Sprintf("%s (%s, %s)", name, v.Model, v.ExtractedAt)
+}
+
+// readExtractionVersion loads the current extraction or an archived version
+// of a chapter.
+func readExtractionVersion(s *store.Store, chapterIdx int, version string) (*model.ChapterExtraction, error) {
+	if version == "current" {
+		ext, err := s.ReadExtraction(chapterIdx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("chapter %d has not been extracted", chapterIdx)
+		}
+		return ext, err
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q (want \"current\" or an archived version number)", version)
+	}
+	ext, err := s.ReadExtractionVersion(chapterIdx, n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("chapter %d has no archived version %d", chapterIdx, n)
+	}
+	return ext, err
+}
+
+// printChanges prints one section of a diff, one line per change.
+func printChanges[T any](title string, changes []aggregator.Change[T], describe func(*T) string) {
+	if len(changes) == 0 {
+		return
+	}
+	var added, removed, modified int
+	for _, c := range changes {
+		switch c.Kind {
+		case aggregator.ChangeAdded:
+			added++
+		case aggregator.ChangeRemoved:
+			removed++
+		case aggregator.ChangeModified:
+			modified++
+		}
+	}
+	fmt.Printf("\n%s: %d added, %d removed, %d modified\n", title, added, removed, modified)
+	for _, c := range changes {
+		switch c.Kind {
+		case aggregator.ChangeAdded:
+			fmt.Printf("  + %s\n", describe(c.B))
+		case aggregator.ChangeRemoved:
+			fmt.Printf("  - %s\n", describe(c.A))
+		case aggregator.ChangeModified:
+			line := fmt.Sprintf("  ~ %s", describe(c.B))
+			if a := describe(c.A); a != describe(c.B) {
+				line = fmt.Sprintf("  ~ %s => %s", a, describe(c.B))
+			}
+			fmt.Printf("%s [%s]\n", line, strings.Join(c.Fields, ", "))
+		}
+	}
+}
+
+func init() {
+	extractDiffCmd.Flags().IntVar(&diffChapter, "chapter", 0, "Chapter index to compare")
+	extractDiffCmd.Flags().StringVar(&diffA, "a", "", "Older version: \"current\" or an archived version number (default: newest archived)")
+	extractDiffCmd.Flags().StringVar(&diffB, "b", "current", "Newer version: \"current\" or an archived version number")
+	extractDiffCmd.Flags().BoolVar(&diffJSON, "json", false, "Print the diff as JSON")
+	extractDiffCmd.MarkFlagRequired("chapter")
+	extractCmd.AddCommand(extractDiffCmd)
+}
@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/intelligrit/twi-map/internal/config"
+	"github.com/intelligrit/twi-map/internal/extractor"
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+// extractJob is one chapter queued for extraction. seq is its position in the
+// run, used to persist results in chapter order.
+type extractJob struct {
+	seq int
+	ch  model.Chapter
+}
+
+// extractOutcome is a worker's result for one chapter.
+type extractOutcome struct {
+	extractJob
+	chars  int
+	res    extractor.ChapterResult
+	raw    *model.RawExtraction // set once every window has a reply
+	parsed *model.ChapterExtraction
+	cost   float64 // US dollars, for models with a configured price
+	err    error
+	stage  string // "READ", "API", or "PARSE" when err is set
+}
+
+// poolOptions configures runExtractionPool.
+type poolOptions struct {
+	workers int
+	// samples holds one client per run of each chapter. With more than one,
+	// the runs are merged by vote, keeping items found by at least
+	// agreement of them.
+	samples   []*extractor.Client
+	agreement float64
+	prices    map[string]config.ModelPrice // runs of unpriced models cost nothing
+	maxSpend  float64                      // US dollars; 0 for no limit
+	known     *knownSource                 // learns each chapter as it is saved
+}
+
+// cost prices usage by modelName, or returns 0 if it has no price.
+func (o poolOptions) cost(modelName string, u extractor.Usage) float64 {
+	return o.prices[modelName].Cost(u.InputTokens, u.OutputTokens)
+}
+
+// priced reports whether every sampled model has a price.
+func (o poolOptions) priced() bool {
+	for _, c := range o.samples {
+		if _, ok := o.prices[c.Model]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// runExtractionPool extracts toExtract with a bounded pool of workers sharing
+// the sample clients (and so one retry policy and token budget). Results are written
+// through a single goroutine in chapter order, so an interrupted run always
+// leaves a contiguous prefix of chapters extracted.
+//
+// The first Ctrl-C stops dispatching new chapters and waits for in-flight
+// requests to finish and be saved; a second Ctrl-C abandons them. Crossing
+// the spend budget stops dispatch the same way, so the chapters in flight,
+// up to one per worker, are paid for beyond it.
+func runExtractionPool(s *store.Store, toExtract []model.Chapter, opts poolOptions) error {
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	defer stopDispatch()
+	workCtx, abandon := context.WithCancel(context.Background())
+	defer abandon()
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-sigs:
+			fmt.Fprintln(os.Stderr, "\nInterrupted: finishing in-flight chapters (Ctrl-C again to abandon them)")
+			stopDispatch()
+		case <-finished:
+			return
+		}
+		select {
+		case <-sigs:
+			abandon()
+		case <-finished:
+		}
+	}()
+
+	if len(opts.samples) > 1 {
+		fmt.Printf("Extracting locations from %d chapters using %d samples of %s (%d workers)...\n", len(toExtract), len(opts.samples), sampleModels(opts.samples), opts.workers)
+	} else {
+		fmt.Printf("Extracting locations from %d chapters using %s (%d workers)...\n", len(toExtract), opts.samples[0].Model, opts.workers)
+	}
+
+	p := pool{
+		workers:  opts.workers,
+		maxSpend: opts.maxSpend,
+		extract: func(ctx context.Context, job extractJob) extractOutcome {
+			return extractChapter(ctx, s, job, opts)
+		},
+		save: func(o extractOutcome) (bool, error) {
+			saved, err := saveOutcome(s, o)
+			if saved {
+				opts.known.learn(o.parsed)
+			}
+			return saved, err
+		},
+	}
+	res := p.run(dispatchCtx, stopDispatch, workCtx, toExtract)
+
+	switch {
+	case res.overBudget:
+		fmt.Printf("\nStopped at the spend budget after %d/%d chapters\n", res.saved, len(toExtract))
+	case dispatchCtx.Err() != nil && res.err == nil:
+		fmt.Printf("\nInterrupted after %d/%d chapters\n", res.saved, len(toExtract))
+	}
+	cost := ""
+	if opts.priced() {
+		cost = fmt.Sprintf(" ($%.2f)", res.spent)
+	}
+	fmt.Printf("\nDone. Saved %d chapters. Total tokens: %d input, %d output%s\n", res.saved, res.totalInput, res.totalOutput, cost)
+	return res.err
+}
+
+// pool is the scheduling core of runExtractionPool, with the extraction and
+// storage of each chapter left to its extract and save functions.
+type pool struct {
+	workers  int
+	maxSpend float64 // US dollars; 0 for no limit
+	extract  func(ctx context.Context, job extractJob) extractOutcome
+	// save persists an outcome, reporting whether it stored an extraction.
+	save func(o extractOutcome) (bool, error)
+}
+
+// poolResult totals a pool run. err is the first fatal extraction error or
+// save failure.
+type poolResult struct {
+	totalInput, totalOutput int
+	spent                   float64
+	saved                   int
+	overBudget              bool
+	err                     error
+}
+
+// run dispatches toExtract to the workers until every chapter has been sent
+// or dispatchCtx is done, and saves the outcomes in chapter order. Workers
+// extract under workCtx. run calls stopDispatch itself once the spend budget
+// is crossed, an extraction fails fatally, or a save fails.
+func (p pool) run(dispatchCtx context.Context, stopDispatch context.CancelFunc, workCtx context.Context, toExtract []model.Chapter) poolResult {
+	jobs := make(chan extractJob)
+	results := make(chan workerResult)
+	// Each idle worker puts a token in ready. Dispatch waits for one before
+	// checking dispatchCtx, so no job is handed out once it is done.
+	ready := make(chan struct{}, p.workers)
+
+	go func() {
+		defer close(jobs)
+		for i, ch := range toExtract {
+			select {
+			case <-ready:
+			case <-dispatchCtx.Done():
+				return
+			}
+			if dispatchCtx.Err() != nil {
+				return
+			}
+			jobs <- extractJob{seq: i, ch: ch}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range p.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handled := make(chan struct{})
+			for {
+				ready <- struct{}{}
+				job, ok := <-jobs
+				if !ok {
+					return
+				}
+				results <- workerResult{p.extract(workCtx, job), handled}
+				<-handled
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var (
+		res       poolResult
+		completed int
+		next      int
+		held      = make(map[int]extractOutcome)
+	)
+	for r := range results {
+		out := r.out
+		completed++
+		res.totalInput += out.res.Usage.InputTokens
+		res.totalOutput += out.res.Usage.OutputTokens
+		res.spent += out.cost
+		printOutcome(completed, len(toExtract), out)
+
+		if p.maxSpend > 0 && !res.overBudget {
+			if res.spent >= p.maxSpend {
+				res.overBudget = true
+				fmt.Fprintf(os.Stderr, "\nSpend budget reached ($%.2f of $%.2f): finishing in-flight chapters\n", res.spent, p.maxSpend)
+				stopDispatch()
+			}
+		}
+
+		if out.err != nil && extractor.IsFatal(out.err) && res.err == nil {
+			res.err = out.err
+			stopDispatch()
+		}
+
+		// Hold results until every earlier chapter has been resolved.
+		held[out.seq] = out
+		for {
+			o, ok := held[next]
+			if !ok {
+				break
+			}
+			delete(held, next)
+			next++
+			saved, err := p.save(o)
+			if err != nil && res.err == nil {
+				res.err = err
+				stopDispatch()
+			}
+			if saved {
+				res.saved++
+			}
+		}
+		r.handled <- struct{}{}
+	}
+	return res
+}
+
+// workerResult is an outcome sent to the saving loop. The worker waits on
+// handled until it has been saved or held, so with one worker each chapter
+// is saved before the next is extracted.
+type workerResult struct {
+	out     extractOutcome
+	handled chan struct{}
+}
+
+// saveOutcome stores a chapter's extraction together with the raw replies
+// behind it. Failed chapters stay unextracted for the next run; their
+// replies are kept for --reparse unless an earlier extraction is stored,
+// which keeps the replies behind it.
+func saveOutcome(s *store.Store, o extractOutcome) (bool, error) {
+	if o.parsed == nil {
+		if o.raw != nil && !s.ExtractionExists(o.ch.Index) {
+			if err := s.WriteRawExtraction(o.raw); err != nil {
+				return false, fmt.Errorf("saving raw response: %w", err)
+			}
+		}
+		return false, nil
+	}
+	if err := s.WriteExtractionWithRaw(o.parsed, o.raw); err != nil {
+		return false, fmt.Errorf("saving extraction: %w", err)
+	}
+	return true, nil
+}
+
+// extractChapter reads, extracts, and parses a single chapter, once per
+// sample client. out.res holds the first run's windows and the usage and
+// attempts of all runs.
+func extractChapter(ctx context.Context, s *store.Store, job extractJob, opts poolOptions) extractOutcome {
+	out := extractOutcome{extractJob: job}
+
+	text, err := s.ReadChapterText(job.ch.Index)
+	if err != nil {
+		out.err, out.stage = err, "READ"
+		return out
+	}
+	out.chars = len(text)
+
+	known := opts.known.before(job.ch.Index)
+	var raws []model.RawExtraction
+	for i, client := range opts.samples {
+		res, err := client.ExtractWindows(ctx, job.ch.WebTitle, text, known)
+		if i == 0 {
+			out.res = res
+		} else {
+			out.res.Usage.InputTokens += res.Usage.InputTokens
+			out.res.Usage.OutputTokens += res.Usage.OutputTokens
+			out.res.Attempts += res.Attempts
+		}
+		out.cost += opts.cost(client.Model, res.Usage)
+		if err != nil {
+			out.err, out.stage = err, "API"
+			return out
+		}
+		raws = append(raws, *extractor.NewRawExtraction(job.ch.Index, client.Model, res.Texts, res.StopReasons, res.Usage))
+	}
+
+	// Keep the raw replies even if they don't parse, for extract --reparse.
+	out.raw = &raws[0]
+	out.raw.Samples = raws[1:]
+	if len(out.raw.Samples) == 0 {
+		out.raw.Samples = nil
+	}
+	out.parsed, err = extractor.BuildConsensus(out.raw, job.ch.WebTitle, text, opts.agreement)
+	if err != nil {
+		out.err, out.stage = err, "PARSE"
+	}
+	return out
+}
+
+// sampleModels names the models behind clients, e.g. "a x2, b".
+func sampleModels(clients []*extractor.Client) string {
+	var names []string
+	for _, run := range sampleRuns(clients) {
+		if run.count > 1 {
+			names = append(names, fmt.Sprintf("%s x%d", run.model, run.count))
+		} else {
+			names = append(names, run.model)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// printOutcome writes one complete progress line per chapter so that output
+// from concurrent workers never interleaves mid-line.
+func printOutcome(completed, total int, out extractOutcome) {
+	prefix := fmt.Sprintf("  [%d/%d] %s", completed, total, out.ch.WebTitle)
+	switch {
+	case out.stage == "READ":
+		fmt.Fprintf(os.Stderr, "%s WARNING: failed to read chapter %d: %v\n", prefix, out.ch.Index, out.err)
+	case out.stage == "API":
+		fmt.Fprintf(os.Stderr, "%s (%d chars) ERROR after %d attempt(s): %v\n", prefix, out.chars, out.res.Attempts, out.err)
+	case out.stage == "PARSE":
+		fmt.Fprintf(os.Stderr, "%s (%d chars) PARSE ERROR: %v\n", prefix, out.chars, out.err)
+	default:
+		windows := ""
+		if n := len(out.res.Texts); n > 1 {
+			windows = fmt.Sprintf(" in %d windows", n)
+		}
+		notes := ""
+		if n := len(out.parsed.Warnings); n > 0 {
+			notes += fmt.Sprintf(" %d warning(s)", n)
+		}
+		if out.parsed.Partial {
+			notes += " (partial: response truncated)"
+		}
+		if out.parsed.Samples > 1 {
+			notes += fmt.Sprintf(" (consensus of %d)", out.parsed.Samples)
+		}
+		fmt.Printf("%s (%d chars%s) %d locations, %d relationships (%d+%d tokens, %d attempt(s))%s\n",
+			prefix, out.chars, windows, len(out.parsed.Locations), len(out.parsed.Relationships),
+			out.res.Usage.InputTokens, out.res.Usage.OutputTokens, out.res.Attempts, notes)
+		for _, w := range out.parsed.Warnings {
+			logVerbose("      %s: %s", w.Kind, w.Detail)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// testChapters returns n chapters indexed 0 to n-1.
+func testChapters(n int) []model.Chapter {
+	chapters := make([]model.Chapter, n)
+	for i := range chapters {
+		chapters[i] = model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.%02d", i)}
+	}
+	return chapters
+}
+
+// parsedOutcome is a successful extraction of job's chapter.
+func parsedOutcome(job extractJob) extractOutcome {
+	return extractOutcome{extractJob: job, parsed: &model.ChapterExtraction{ChapterIndex: job.ch.Index}}
+}
+
+// savedOrder records the chapters a pool saves, in order.
+type savedOrder struct {
+	mu      sync.Mutex
+	indices []int
+}
+
+func (s *savedOrder) save(o extractOutcome) (bool, error) {
+	if o.parsed == nil {
+		return false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indices = append(s.indices, o.ch.Index)
+	return true, nil
+}
+
+// runTestPool runs p over chapters, reporting whether it stopped dispatch.
+func runTestPool(p pool, chapters []model.Chapter) (res poolResult, stopped bool) {
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	defer stopDispatch()
+	res = p.run(dispatchCtx, stopDispatch, context.Background(), chapters)
+	return res, dispatchCtx.Err() != nil
+}
+
+func TestPoolSavesInOrder(t *testing.T) {
+	// Each chapter waits for the one after it to finish, so they complete
+	// in reverse order.
+	chapters := testChapters(4)
+	done := make([]chan struct{}, len(chapters)+1)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	close(done[len(chapters)])
+
+	var saved savedOrder
+	res, _ := runTestPool(pool{
+		workers: len(chapters),
+		extract: func(ctx context.Context, job extractJob) extractOutcome {
+			<-done[job.seq+1]
+			defer close(done[job.seq])
+			return parsedOutcome(job)
+		},
+		save: saved.save,
+	}, chapters)
+
+	if res.err != nil || res.saved != 4 {
+		t.Fatalf("expected 4 chapters saved, got %+v", res)
+	}
+	if !slices.Equal(saved.indices, []int{0, 1, 2, 3}) {
+		t.Errorf("expected chapters saved in order, got %v", saved.indices)
+	}
+}
+
+func TestPoolSavesBeforeNextChapter(t *testing.T) {
+	var saved savedOrder
+	var seen []int
+	runTestPool(pool{
+		workers: 1,
+		extract: func(ctx context.Context, job extractJob) extractOutcome {
+			saved.mu.Lock()
+			seen = append(seen, len(saved.indices))
+			saved.mu.Unlock()
+			return parsedOutcome(job)
+		},
+		save: saved.save,
+	}, testChapters(4))
+
+	if !slices.Equal(seen, []int{0, 1, 2, 3}) {
+		t.Errorf("expected each chapter extracted after the ones before it were saved, saw %v saved", seen)
+	}
+}
+
+func TestPoolFailedChapterDoesNotBlock(t *testing.T) {
+	var saved savedOrder
+	res, stopped := runTestPool(pool{
+		workers: 2,
+		extract: func(ctx context.Context, job extractJob) extractOutcome {
+			if job.ch.Index == 1 {
+				return extractOutcome{extractJob: job, err: errors.New("bad reply"), stage: "PARSE"}
+			}
+			return parsedOutcome(job)
+		},
+		save: saved.save,
+	}, testChapters(4))
+
+	if res.err != nil || res.saved != 3 {
+		t.Fatalf("expected 3 chapters saved and no run error, got %+v", res)
+	}
+	if !slices.Equal(saved.indices, []int{0, 2, 3}) {
+		t.Errorf("expected the chapters after the failure saved, got %v", saved.indices)
+	}
+	if stopped {
+		t.Error("expected a non-fatal failure not to stop dispatch")
+	}
+}
+
+func TestPoolCancelSavesInFlight(t *testing.T) {
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	defer stopDispatch()
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var extracted []int
+	var saved savedOrder
+	p := pool{
+		workers: 2,
+		extract: func(ctx context.Context, job extractJob) extractOutcome {
+			mu.Lock()
+			extracted = append(extracted, job.ch.Index)
+			mu.Unlock()
+			started <- struct{}{}
+			<-release
+			return parsedOutcome(job)
+		},
+		save: saved.save,
+	}
+
+	resCh := make(chan poolResult)
+	go func() { resCh <- p.run(dispatchCtx, stopDispatch, context.Background(), testChapters(10)) }()
+
+	// Interrupt once both workers are busy, then let them finish.
+	<-started
+	<-started
+	stopDispatch()
+	close(release)
+	res := <-resCh
+
+	if len(extracted) != 2 {
+		t.Errorf("expected no chapters dispatched after the interrupt, got %v", extracted)
+	}
+	if res.saved != 2 || !slices.Equal(saved.indices, []int{0, 1}) {
+		t.Errorf("expected both in-flight chapters saved, got %d: %v", res.saved, saved.indices)
+	}
+}
+
+func TestPoolStopsAtBudget(t *testing.T) {
+	for range 20 { // dispatch used to race the stop
+		var saved savedOrder
+		res, stopped := runTestPool(pool{
+			workers:  1,
+			maxSpend: 2,
+			extract: func(ctx context.Context, job extractJob) extractOutcome {
+				out := parsedOutcome(job)
+				out.cost = 1
+				return out
+			},
+			save: saved.save,
+		}, testChapters(10))
+
+		if !res.overBudget || !stopped || res.saved != 2 || res.spent != 2 {
+			t.Fatalf("expected dispatch stopped after the chapter crossing the budget, got %+v", res)
+		}
+	}
+}
+
+func TestPoolStopsOnSaveError(t *testing.T) {
+	res, stopped := runTestPool(pool{
+		workers: 1,
+		extract: func(ctx context.Context, job extractJob) extractOutcome { return parsedOutcome(job) },
+		save: func(o extractOutcome) (bool, error) {
+			return false, errors.New("disk full")
+		},
+	}, testChapters(5))
+
+	if res.err == nil || res.saved != 0 {
+		t.Errorf("expected the save error reported, got %+v", res)
+	}
+	if !stopped {
+		t.Error("expected a save error to stop dispatch")
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/intelligrit/twi-map/internal/extractor"
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+// reparseExtractions rebuilds extractions from stored raw responses, so
+// parser, validation, and salvage fixes can be applied without paying for
+// the API calls again. Consensus runs are merged again at agreement.
+func reparseExtractions(s *store.Store, chapters []model.Chapter, agreement float64) error {
+	var reparsed, failed, missing int
+	for _, ch := range chapters {
+		if !s.RawExtractionExists(ch.Index) {
+			missing++
+			continue
+		}
+		raw, err := s.ReadRawExtraction(ch.Index)
+		if err != nil {
+			return fmt.Errorf("reading raw response for %s: %w", ch.WebTitle, err)
+		}
+
+		text, _ := s.ReadChapterText(ch.Index)
+		ext, err := extractor.BuildConsensus(raw, ch.WebTitle, text, agreement)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  %s: PARSE ERROR: %v\n", ch.WebTitle, err)
+			failed++
+			continue
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			return fmt.Errorf("saving extraction for %s: %w", ch.WebTitle, err)
+		}
+		reparsed++
+		logVerbose("  %s: %d locations, %d relationships, %d warning(s)", ch.WebTitle,
+			len(ext.Locations), len(ext.Relationships), len(ext.Warnings))
+	}
+
+	fmt.Printf("Re-parsed %d chapters, %d failed, %d without a stored response.\n", reparsed, failed, missing)
+	return nil
+}
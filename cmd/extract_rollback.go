@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/intelligrit/twi-map/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var rollbackChapters string
+
+// extractRollbackCmd undoes a bad re-extraction by restoring each chapter's
+// previous version. The discarded extraction is not archived, so repeated
+// rollbacks walk further back through the history.
+var extractRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Restore the previous extraction of the given chapters",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inRange, err := parseChapterRanges(rollbackChapters)
+		if err != nil {
+			return err
+		}
+
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		toc, err := s.ReadTOC()
+		if err != nil {
+			return fmt.Errorf("reading TOC (run scrape-toc first): %w", err)
+		}
+
+		var restored int
+		for _, ch := range toc.Chapters {
+			if !inRange(ch.Index) {
+				continue
+			}
+			ext, err := s.RollbackExtraction(ch.Index)
+			if errors.Is(err, sql.ErrNoRows) {
+				logVerbose("  %s: no earlier version", ch.WebTitle)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("rolling back %s: %w", ch.WebTitle, err)
+			}
+			restored++
+			fmt.Printf("  %s: restored %s extraction from %s\n", ch.WebTitle, ext.Model, ext.ExtractedAt)
+		}
+
+		fmt.Printf("Rolled back %d chapters.\n", restored)
+		return nil
+	},
+}
+
+func init() {
+	extractRollbackCmd.Flags().StringVar(&rollbackChapters, "chapters", "", "Chapter indices to roll back (e.g. 120-180 or 3,7,10-12)")
+	extractRollbackCmd.MarkFlagRequired("chapters")
+	extractCmd.AddCommand(extractRollbackCmd)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// chapterSelector decides which chapters 'extract' sends to the model. With
+// no re-extraction selectors it picks only chapters that have never been
+// extracted; whereModel, olderThanPrompt, and failedOnly each narrow the
+// already-extracted chapters to re-run, and force re-runs all of them.
+type chapterSelector struct {
+	volume          string
+	inRange         func(int) bool // nil matches every chapter
+	whereModel      string
+	olderThanPrompt int
+	failedOnly      bool
+	force           bool
+}
+
+// matches reports whether ch falls within the volume and chapter range.
+func (sel chapterSelector) matches(ch model.Chapter) bool {
+	if sel.volume != "" && ch.Volume != sel.volume {
+		return false
+	}
+	return sel.inRange == nil || sel.inRange(ch.Index)
+}
+
+// include reports whether ch should be extracted. meta is its current
+// extraction, or nil; hasRaw says whether a raw response is stored for it.
+func (sel chapterSelector) include(ch model.Chapter, meta *model.ChapterExtraction, hasRaw bool) bool {
+	if !sel.matches(ch) {
+		return false
+	}
+	byExisting := sel.whereModel != "" || sel.olderThanPrompt > 0
+
+	if meta == nil {
+		if byExisting {
+			return false
+		}
+		if sel.failedOnly {
+			return hasRaw // attempted, but the reply didn't parse
+		}
+		return true
+	}
+
+	if !sel.force && !byExisting && !sel.failedOnly {
+		return false
+	}
+	if sel.whereModel != "" && meta.Model != sel.whereModel {
+		return false
+	}
+	if sel.olderThanPrompt > 0 && meta.PromptVersion >= sel.olderThanPrompt {
+		return false
+	}
+	if sel.failedOnly && !meta.Partial {
+		return false
+	}
+	return true
+}
+
+// parseChapterRanges parses a comma-separated list of chapter indices and
+// inclusive ranges, such as "3,120-180".
+func parseChapterRanges(spec string) (func(int) bool, error) {
+	type span struct{ lo, hi int }
+	var spans []span
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid chapter range %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil || to < from {
+				return nil, fmt.Errorf("invalid chapter range %q", part)
+			}
+		}
+		spans = append(spans, span{from, to})
+	}
+	return func(idx int) bool {
+		for _, sp := range spans {
+			if idx >= sp.lo && idx <= sp.hi {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// parsePromptVersion accepts a prompt version as "v3" or "3".
+func parsePromptVersion(s string) (int, error) {
+	v, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "v"))
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid prompt version %q (want e.g. v3)", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/aggregator"
+	"github.com/intelligrit/twi-map/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewReason    string
+	reviewTypeShare float64
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review decisions made during aggregation",
+}
+
+// reviewRejectedCmd lists the names the last aggregation left off the map, so
+// mistakes can be corrected through the filter file.
+var reviewRejectedCmd = &cobra.Command{
+	Use:   "rejected",
+	Short: "List extracted names rejected as non-locations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		rejected, err := s.ReadRejected()
+		if err != nil {
+			return fmt.Errorf("reading rejected names: %w", err)
+		}
+
+		var shown int
+		for _, r := range rejected {
+			if reviewReason != "" && r.Reason != reviewReason {
+				continue
+			}
+			shown++
+			line := fmt.Sprintf("%-30s %-14s %4d mentions, from chapter %d", r.Name, r.Reason, r.MentionCount, r.FirstChapterIndex)
+			if r.Detail != "" {
+				line += fmt.Sprintf("  %q", r.Detail)
+			}
+			fmt.Println(line)
+		}
+
+		if shown == 0 {
+			fmt.Println("No rejected names. Run 'twi-map aggregate' first.")
+			return nil
+		}
+		fmt.Printf("\n%d rejected. Add wrongly rejected names to 'allow' in %s and re-run aggregate.\n", shown, cfg.Aggregate.FilterFile)
+		return nil
+	},
+}
+
+// reviewTypesCmd lists the locations whose chapters disagree on what kind
+// of place they are, with how each type was voted for.
+var reviewTypesCmd = &cobra.Command{
+	Use:   "types",
+	Short: "List locations whose extracted type is disputed",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		data, err := s.ReadAggregated()
+		if err != nil {
+			return fmt.Errorf("reading aggregated data: %w", err)
+		}
+
+		var shown int
+		for _, loc := range data.Locations {
+			share := loc.TypeShare()
+			if share >= reviewTypeShare {
+				continue
+			}
+			shown++
+			var votes []string
+			for _, v := range loc.TypeVotes {
+				votes = append(votes, fmt.Sprintf("%s %d (weight %.2f, last chapter %d)", v.Type, v.Mentions, v.Weight, v.LastChapterIndex))
+			}
+			fmt.Printf("%-30s %-14s %3.0f%%  %s\n", loc.Name, loc.Type, share*100, strings.Join(votes, "; "))
+		}
+
+		if shown == 0 {
+			fmt.Println("No disputed location types. Run 'twi-map aggregate' first.")
+			return nil
+		}
+		fmt.Printf("\n%d locations whose winning type has under %.0f%% of the vote.\n", shown, reviewTypeShare*100)
+		return nil
+	},
+}
+
+func init() {
+	reviewTypesCmd.Flags().Float64Var(&reviewTypeShare, "share", aggregator.AmbiguousTypeShare, "List locations whose winning type has less than this share of the vote (0-1)")
+	reviewCmd.AddCommand(reviewTypesCmd)
+	reviewRejectedCmd.Flags().StringVar(&reviewReason, "reason", "", "Only list names rejected for this reason (earth, deny_list, filter_file, uncapitalized, person_verb)")
+	reviewCmd.AddCommand(reviewRejectedCmd)
+	rootCmd.AddCommand(reviewCmd)
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/intelligrit/twi-map/internal/aggregator"
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var reviewMergesReviewed bool
+
+// reviewMergesCmd lists the possible duplicate locations the last
+// aggregation found but wasn't confident enough to merge.
+var reviewMergesCmd = &cobra.Command{
+	Use:   "merges",
+	Short: "List possible duplicate locations awaiting review",
+	Long: `List pairs of locations with similar names that the last aggregation
+queued for review instead of merging. Decide each with 'review merges accept'
+or 'review merges reject'; every later aggregation honors the decision.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		if reviewMergesReviewed {
+			reviews, err := s.ReadMergeReviews()
+			if err != nil {
+				return fmt.Errorf("reading merge reviews: %w", err)
+			}
+			for _, r := range reviews {
+				verdict := "rejected"
+				if r.Accepted {
+					verdict = "accepted"
+				}
+				fmt.Printf("%-8s %s <-> %s  (%s)\n", verdict, r.A, r.B, r.ReviewedAt)
+			}
+			fmt.Printf("\n%d reviewed pairs.\n", len(reviews))
+			return nil
+		}
+
+		candidates, err := s.ReadMergeCandidates()
+		if err != nil {
+			return fmt.Errorf("reading merge candidates: %w", err)
+		}
+		if len(candidates) == 0 {
+			fmt.Println("No merge candidates awaiting review. Run 'twi-map aggregate' first.")
+			return nil
+		}
+		for i, c := range candidates {
+			fmt.Printf("%4d. %s <-> %s  %.2f (name %.2f, context %.2f)\n", i+1, c.A, c.B, c.Score, c.NameScore, c.ContextScore)
+		}
+		fmt.Printf("\n%d awaiting review. Decide with 'review merges accept N' or 'review merges reject N'.\n", len(candidates))
+		return nil
+	},
+}
+
+var reviewMergesAcceptCmd = &cobra.Command{
+	Use:   "accept (N | LOCATION LOCATION)",
+	Short: "Merge a candidate pair on every later aggregation",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return reviewMerge(args, true)
+	},
+}
+
+var reviewMergesRejectCmd = &cobra.Command{
+	Use:   "reject (N | LOCATION LOCATION)",
+	Short: "Keep a candidate pair apart on every later aggregation",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return reviewMerge(args, false)
+	},
+}
+
+// reviewMerge records a verdict on the pair named by args: either a number
+// from the 'review merges' list or the two location names.
+func reviewMerge(args []string, accepted bool) error {
+	s, err := store.New(dataDir)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	var a, b string
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("expected a candidate number or two location names, got %q", args[0])
+		}
+		candidates, err := s.ReadMergeCandidates()
+		if err != nil {
+			return fmt.Errorf("reading merge candidates: %w", err)
+		}
+		if n < 1 || n > len(candidates) {
+			return fmt.Errorf("no merge candidate %d (%d awaiting review)", n, len(candidates))
+		}
+		a, b = candidates[n-1].A, candidates[n-1].B
+	} else {
+		gaz, err := aggregator.LoadGazetteer(cfg.Aggregate.Gazetteer)
+		if err != nil {
+			return fmt.Errorf("loading gazetteer: %w", err)
+		}
+		known, err := reviewableLocations(s)
+		if err != nil {
+			return err
+		}
+		if a, err = resolveReviewName(gaz, known, args[0]); err != nil {
+			return err
+		}
+		if b, err = resolveReviewName(gaz, known, args[1]); err != nil {
+			return err
+		}
+		if a == b {
+			return fmt.Errorf("can't review %q against itself", a)
+		}
+	}
+
+	r := model.MergeReview{A: a, B: b, Accepted: accepted, ReviewedAt: time.Now().UTC().Format(time.RFC3339)}
+	if err := s.WriteMergeReview(r); err != nil {
+		return fmt.Errorf("saving review: %w", err)
+	}
+	verdict := "merge"
+	if !accepted {
+		verdict = "keep apart"
+	}
+	fmt.Printf("Will %s %s and %s. Run 'twi-map aggregate' to apply.\n", verdict, a, b)
+	return nil
+}
+
+// reviewableLocations returns the location IDs a review can name: those the
+// last aggregation kept, merged, or queued, and those already reviewed.
+func reviewableLocations(s *store.Store) (map[string]bool, error) {
+	known := make(map[string]bool)
+	data, err := s.ReadAggregated()
+	if err != nil {
+		return nil, fmt.Errorf("reading aggregated data: %w", err)
+	}
+	for _, loc := range data.Locations {
+		known[loc.ID] = true
+	}
+	merges, err := s.ReadMergeDecisions()
+	if err != nil {
+		return nil, fmt.Errorf("reading merge decisions: %w", err)
+	}
+	for _, m := range merges {
+		known[m.A], known[m.B] = true, true
+	}
+	candidates, err := s.ReadMergeCandidates()
+	if err != nil {
+		return nil, fmt.Errorf("reading merge candidates: %w", err)
+	}
+	for _, c := range candidates {
+		known[c.A], known[c.B] = true, true
+	}
+	reviews, err := s.ReadMergeReviews()
+	if err != nil {
+		return nil, fmt.Errorf("reading merge reviews: %w", err)
+	}
+	for _, r := range reviews {
+		known[r.A], known[r.B] = true, true
+	}
+	return known, nil
+}
+
+// resolveReviewName keys a location name as aggregation does, so that the
+// review matches it, and checks the last aggregation saw it.
+func resolveReviewName(g *aggregator.Gazetteer, known map[string]bool, name string) (string, error) {
+	id := g.Key(name)
+	if !known[id] {
+		return "", fmt.Errorf("no location %q in the last aggregation (run 'twi-map aggregate' first)", id)
+	}
+	return id, nil
+}
+
+func init() {
+	reviewMergesCmd.Flags().BoolVar(&reviewMergesReviewed, "reviewed", false, "List past verdicts instead of pending candidates")
+	reviewMergesCmd.AddCommand(reviewMergesAcceptCmd, reviewMergesRejectCmd)
+	reviewCmd.AddCommand(reviewMergesCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/aggregator"
+)
+
+func TestResolveReviewName(t *testing.T) {
+	g := aggregator.DefaultGazetteer()
+	known := map[string]bool{"the wandering inn": true, "blood fields": true}
+
+	tests := []struct {
+		name, want string
+		wantErr    bool
+	}{
+		{"The Inn", "the wandering inn", false},
+		{" [Bloodfields] ", "blood fields", false},
+		{"Invrisil", "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolveReviewName(g, known, tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolveReviewName(%q) = %q, %v; want %q (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
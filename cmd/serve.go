@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/intelligrit/twi-map/internal/aggregator"
 	"github.com/intelligrit/twi-map/internal/store"
 	"github.com/intelligrit/twi-map/internal/web"
 	"github.com/spf13/cobra"
@@ -30,9 +31,15 @@ var serveCmd = &cobra.Command{
 		}
 		defer s.Close()
 
+		gaz, err := aggregator.LoadGazetteer(cfg.Aggregate.Gazetteer)
+		if err != nil {
+			return fmt.Errorf("loading gazetteer: %w", err)
+		}
+
 		srv := &web.Server{
-			Store: s,
-			Addr:  fmt.Sprintf("%s:%d", serveHost, servePort),
+			Store:     s,
+			Addr:      fmt.Sprintf("%s:%d", serveHost, servePort),
+			Gazetteer: gaz,
 		}
 		return srv.ListenAndServe()
 	},
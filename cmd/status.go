@@ -30,6 +30,28 @@ var statusCmd = &cobra.Command{
 		fmt.Printf("Chapters extracted: %d / %d\n", extCount, chapCount)
 		fmt.Printf("Aggregated locations: %d\n", locCount)
 
+		if extCount > 0 {
+			byKind, warned, err := s.WarningCounts()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\nExtraction Quality\n")
+			fmt.Printf("------------------\n")
+			fmt.Printf("Chapters with warnings: %d / %d\n", warned, extCount)
+			if partial := s.PartialExtractionCount(); partial > 0 {
+				fmt.Printf("Truncated responses:    %d\n", partial)
+			}
+
+			var kinds []string
+			for k := range byKind {
+				kinds = append(kinds, k)
+			}
+			sort.Strings(kinds)
+			for _, k := range kinds {
+				fmt.Printf("  %-20s %d\n", k, byKind[k])
+			}
+		}
+
 		// Per-volume breakdown
 		chapByVol := s.ChapterCountByVolume()
 		scrapedByVol := s.ScrapedCountByVolume()
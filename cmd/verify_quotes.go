@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/intelligrit/twi-map/internal/extractor"
+	"github.com/intelligrit/twi-map/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// verifyQuotesCmd backfills quote verification for extractions saved before
+// it existed. New extractions are verified as they are saved.
+var verifyQuotesCmd = &cobra.Command{
+	Use:   "verify-quotes",
+	Short: "Match extracted quotes against chapter text and record where they were found",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.New(dataDir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		toc, err := s.ReadTOC()
+		if err != nil {
+			return fmt.Errorf("reading TOC (run scrape-toc first): %w", err)
+		}
+
+		var chapters, verified, total int
+		for _, ch := range toc.Chapters {
+			if !s.ExtractionExists(ch.Index) {
+				continue
+			}
+			ext, err := s.ReadExtraction(ch.Index)
+			if err != nil {
+				return fmt.Errorf("reading extraction for %s: %w", ch.WebTitle, err)
+			}
+			text, err := s.ReadChapterText(ch.Index)
+			if err != nil {
+				logVerbose("  skipping %s: no chapter text", ch.WebTitle)
+				continue
+			}
+
+			v, n := extractor.VerifyQuotes(ext, text)
+			if err := s.WriteExtraction(ext); err != nil {
+				return fmt.Errorf("saving extraction for %s: %w", ch.WebTitle, err)
+			}
+			chapters++
+			verified += v
+			total += n
+			if v < n {
+				logVerbose("  %s: %d of %d quotes verified", ch.WebTitle, v, n)
+			}
+		}
+
+		fmt.Printf("Verified %d of %d quotes across %d chapters.\n", verified, total, chapters)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyQuotesCmd)
+}
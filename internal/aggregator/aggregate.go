@@ -2,6 +2,8 @@ package aggregator
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -10,77 +12,90 @@ import (
 	"github.com/intelligrit/twi-map/internal/store"
 )
 
-const (
-	// minMentions is the minimum number of chapter mentions required for a location to be included.
-	minMentions = 3
-	// maxContainmentDepth is how many levels of parent containment to walk when checking traceability.
-	maxContainmentDepth = 10
-)
+// maxContainmentDepth is how many levels of parent containment to walk when checking traceability.
+const maxContainmentDepth = 10
+
+// Options adjusts how extractions are merged.
+type Options struct {
+	// DropUnverifiedQuotes blanks relationship quotes that weren't found in
+	// their chapter's text (see extractor.VerifyQuotes) instead of keeping
+	// them with QuoteVerified unset.
+	DropUnverifiedQuotes bool
+	// Filter adds user allow and deny lists to the built-in non-location
+	// filter. It may be nil.
+	Filter *NameFilter
+	// Gazetteer supplies canonical names, seed places, and Earth names. Nil
+	// means DefaultGazetteer.
+	Gazetteer *Gazetteer
+	// AutoMerge and ReviewMerge are the similarity thresholds at which
+	// similarly named locations merge outright or are queued for review.
+	// Zero means DefaultAutoMerge and DefaultReviewMerge.
+	AutoMerge, ReviewMerge float64
+	// Inclusion decides which locations make it onto the map. Nil means
+	// DefaultInclusionPolicy.
+	Inclusion *InclusionPolicy
+}
+
+// locEntry accumulates one location's mentions across chapters.
+type locEntry struct {
+	loc     model.AggregatedLocation
+	indices map[int]bool
+	// revisions holds the description text extracted in each chapter, so
+	// readers can be served the best description known as of their progress.
+	revisions map[int]*model.DescriptionRevision
+	// spellings holds every name the location was extracted under.
+	spellings []string
+	// agreed counts the consensus mentions averaged into loc.Agreement.
+	agreed int
+	// types holds every mention's vote on loc.Type.
+	types typeVotes
+}
 
 // Aggregate loads all per-chapter extractions and merges them into a unified dataset.
-func Aggregate(s *store.Store) (*model.AggregatedData, error) {
+func Aggregate(s *store.Store, opts Options) (*model.AggregatedData, error) {
 	toc, err := s.ReadTOC()
 	if err != nil {
 		return nil, fmt.Errorf("reading TOC: %w", err)
 	}
 
-	type locEntry struct {
-		loc     model.AggregatedLocation
-		indices map[int]bool
+	g := opts.Gazetteer
+	if g == nil {
+		g = DefaultGazetteer()
 	}
-
-	// Canonical name mapping for well-known locations with many variants
-	canonicalNames := map[string]string{
-		"the inn":                 "the wandering inn",
-		"inn":                     "the wandering inn",
-		"the wandering inn":       "the wandering inn",
-		"bloodfields":             "blood fields",
-		"the blood fields":        "blood fields",
-		"the bloodfields":         "blood fields",
-		"high passes":             "the high passes",
-		"the high passes":         "the high passes",
-		"floodplains":             "floodplains of liscor",
-		"the floodplains":         "floodplains of liscor",
-		"flood plains":            "floodplains of liscor",
-		"antinium hive":           "antinium hive",
-		"the antinium hive":       "antinium hive",
-		"the hive":                "antinium hive",
-		"hive":                    "antinium hive",
-		"drath archipelago":       "drath",
-		"the ruins":               "ruins of albez",
-		"ruins":                   "ruins of albez",
-		"garden of sanctuary":     "garden of sanctuary",
-		"the garden of sanctuary": "garden of sanctuary",
-		"the garden":              "garden of sanctuary",
-		"great plains of izril":   "great plains",
-		"the great plains":        "great plains",
-		"liscor's dungeon":        "liscor's dungeon",
-		"dungeon":                 "liscor's dungeon",
-		"the dungeon":             "liscor's dungeon",
-		"new lands of izril":      "new lands",
+	policy := opts.Inclusion
+	if policy == nil {
+		policy = DefaultInclusionPolicy()
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("inclusion policy: %w", err)
+	}
+	if opts.AutoMerge == 0 {
+		opts.AutoMerge = DefaultAutoMerge
+	}
+	if opts.ReviewMerge == 0 {
+		opts.ReviewMerge = DefaultReviewMerge
+	}
+	reviews, err := s.ReadMergeReviews()
+	if err != nil {
+		return nil, fmt.Errorf("reading merge reviews: %w", err)
 	}
 
-	// Earth locations to exclude — TWI characters are transported from modern Earth
-	// to Innworld, so real-world place names appear in dialogue but aren't map locations.
-	earthLocations := map[string]bool{
-		"earth": true, "new york": true, "michigan": true, "london": true,
-		"california": true, "oakland": true, "america": true, "japan": true,
-		"china": true, "korea": true, "india": true, "france": true,
-		"germany": true, "england": true, "united states": true,
-		"los angeles": true, "san francisco": true, "chicago": true,
-		"tokyo": true, "paris": true, "rome": true, "boston": true,
-		"seattle": true, "texas": true, "florida": true, "ohio": true,
-		"colorado": true, "europe": true, "asia": true, "africa": true,
-		"south america": true, "north america": true, "australia": true,
-		"canada": true, "mexico": true, "russia": true, "brazil": true,
-		"spain": true, "italy": true, "greece": true,
+	cls := newClassifier(opts.Filter, g)
+	rejected := make(rejections)
+	// personHits records relationship endpoints followed by a person verb,
+	// e.g. "Erin said", with the chapter and verb of the first sighting.
+	type personHit struct {
+		chapterIdx, count int
+		detail            string
 	}
+	personHits := make(map[string]*personHit)
 
 	// Simple map: normalized name -> entry. No alias pointer tricks.
 	locMap := make(map[string]*locEntry)
 
 	var allRels []model.AggregatedRelationship
-	relSeen := make(map[string]bool)
+	relIdx := make(map[string]int)
+	relAgreed := make(map[string]int)
 
 	var allContainment []model.Containment
 	contSeen := make(map[string]bool)
@@ -96,17 +111,17 @@ func Aggregate(s *store.Store) (*model.AggregatedData, error) {
 		}
 
 		for _, loc := range ext.Locations {
-			key := normalizeName(loc.Name)
-			if earthLocations[key] {
-				continue // skip Earth locations
-			}
-			if canon, ok := canonicalNames[key]; ok {
-				key = canon
-			}
+			key := g.Key(loc.Name)
+			spelling := strings.TrimSpace(loc.Name)
 
 			if entry, ok := locMap[key]; ok {
-				entry.indices[ch.Index] = true
-				entry.loc.MentionCount++
+				entry.spellings = append(entry.spellings, spelling)
+				if !entry.indices[ch.Index] {
+					entry.indices[ch.Index] = true
+					entry.loc.MentionCount++
+				}
+				addAgreement(&entry.loc.Agreement, &entry.agreed, loc.Agreement)
+				entry.types.add(loc.Type, ch.Index, loc.Agreement)
 				if len(loc.Description) > len(entry.loc.Description) {
 					entry.loc.Description = loc.Description
 				}
@@ -118,200 +133,271 @@ func Aggregate(s *store.Store) (*model.AggregatedData, error) {
 						entry.loc.Aliases = append(entry.loc.Aliases, a)
 					}
 				}
+				addRevision(entry.revisions, key, ch.Index, loc)
 			} else {
 				locMap[key] = &locEntry{
 					loc: model.AggregatedLocation{
 						ID:                key,
 						Name:              toDisplayName(key),
-						Type:              loc.Type,
 						Aliases:           loc.Aliases,
 						Description:       loc.Description,
 						VisualDescription: loc.VisualDescription,
 						FirstChapterIndex: ch.Index,
 						MentionCount:      1,
 					},
-					indices: map[int]bool{ch.Index: true},
+					indices:   map[int]bool{ch.Index: true},
+					revisions: make(map[int]*model.DescriptionRevision),
+					spellings: []string{spelling},
+					types:     typeVotes{},
 				}
+				locMap[key].types.add(loc.Type, ch.Index, loc.Agreement)
+				addRevision(locMap[key].revisions, key, ch.Index, loc)
+				addAgreement(&locMap[key].loc.Agreement, &locMap[key].agreed, loc.Agreement)
 			}
 		}
 
 		for _, rel := range ext.Relationships {
-			fromKey := canonicalize(normalizeName(rel.From), canonicalNames)
-			toKey := canonicalize(normalizeName(rel.To), canonicalNames)
+			fromKey := g.Key(rel.From)
+			toKey := g.Key(rel.To)
+			for _, end := range []struct{ name, key string }{{rel.From, fromKey}, {rel.To, toKey}} {
+				verb := personVerbAfter(rel.Detail+" "+rel.Quote, end.name)
+				if verb == "" {
+					continue
+				}
+				if hit, ok := personHits[end.key]; ok {
+					hit.count++
+				} else {
+					personHits[end.key] = &personHit{ch.Index, 1, fmt.Sprintf("%q %s in chapter %d", end.name, verb, ch.Index)}
+				}
+			}
 			rKey := fmt.Sprintf("%s|%s|%s", fromKey, toKey, rel.Type)
-			if !relSeen[rKey] {
-				relSeen[rKey] = true
-				allRels = append(allRels, model.AggregatedRelationship{
-					From:              toDisplayName(fromKey),
-					To:                toDisplayName(toKey),
-					Type:              rel.Type,
-					Detail:            rel.Detail,
-					Quote:             rel.Quote,
-					FirstChapterIndex: ch.Index,
-				})
+			if i, ok := relIdx[rKey]; ok {
+				n := relAgreed[rKey]
+				addAgreement(&allRels[i].Agreement, &n, rel.Agreement)
+				relAgreed[rKey] = n
+				addQuote(&allRels[i], rel, ch.Index)
+				continue
 			}
+			relIdx[rKey] = len(allRels)
+			agg := model.AggregatedRelationship{
+				From:              toDisplayName(fromKey),
+				To:                toDisplayName(toKey),
+				Type:              rel.Type,
+				Detail:            rel.Detail,
+				FirstChapterIndex: ch.Index,
+			}
+			addQuote(&agg, rel, ch.Index)
+			n := 0
+			addAgreement(&agg.Agreement, &n, rel.Agreement)
+			relAgreed[rKey] = n
+			allRels = append(allRels, agg)
 		}
 
 		for _, c := range ext.Containment {
-			childKey := canonicalize(normalizeName(c.Child), canonicalNames)
-			parentKey := canonicalize(normalizeName(c.Parent), canonicalNames)
+			childKey := g.Key(c.Child)
+			parentKey := g.Key(c.Parent)
 			cKey := childKey + "|" + parentKey
 			if !contSeen[cKey] {
 				contSeen[cKey] = true
 				allContainment = append(allContainment, model.Containment{
-					Child:  toDisplayName(childKey),
-					Parent: toDisplayName(parentKey),
+					Child:             toDisplayName(childKey),
+					Parent:            toDisplayName(parentKey),
+					FirstChapterIndex: ch.Index,
 				})
 			}
 		}
 	}
 
-	// Build containment parent lookup for traceability check
-	parentOf := make(map[string]string)
-	for _, c := range allContainment {
-		parentOf[normalizeName(c.Child)] = normalizeName(c.Parent)
-	}
-
-	// Seed names that count as "traceable" (locations we have known positions for)
-	seededNames := map[string]bool{
-		"izril": true, "baleros": true, "chandrar": true, "terandria": true,
-		"rhir": true, "drath archipelago": true, "drath": true,
-		"liscor": true, "the wandering inn": true, "celum": true,
-		"esthelm": true, "wales": true, "invrisil": true, "pallass": true,
-		"the blood fields": true, "the high passes": true, "high passes": true,
-		"the floodplains": true, "flood plains": true, "floodplains of liscor": true,
-		"first landing": true, "the northern plains": true, "the human lands": true,
-		"the drake lands": true, "great plains of izril": true, "vale forest": true,
-		"blood fields": true, "bloodfields": true, "ruins of liscor": true,
-		"ruins of albez": true, "krakk forest": true,
-		"reim": true, "hellios": true, "germina": true, "nerrhavia": true,
-		"nerrhavia's fallen": true, "belchan": true, "jecrass": true,
-		"medain": true, "khelt": true, "quarass": true,
-		"riverfarm": true, "magnolia's estate": true, "lady magnolia's estate": true,
-		"calanfer": true, "noelictus": true, "ailendamus": true,
-		"oteslia": true, "zeres": true, "manus": true, "reizmelt": true,
-		"hectval": true, "wistram academy": true, "wistram": true,
-		"tiqr": true, "pomle": true, "roshal": true, "savere": true,
-		"talenqual": true, "elvallian": true, "gaiil-drome": true,
-		"blighted kingdom": true, "pheislant": true, "desonis": true,
-		"kaliv": true, "erribathe": true, "dawn concordat": true,
-		"house of minos": true, "new lands": true, "great plains": true,
-		"garden of sanctuary": true, "liscor's dungeon": true,
-		"a'ctelios salash": true, "zeikhal": true, "paeth": true,
-		"claiven earth": true, "az'kerash's castle": true,
-		"remendia": true, "albez": true, "runner's guild": true,
-		"windrest": true, "unseen empire": true, "laken's empire": true,
-		"tails and scales": true, "nombernaught": true,
-		"salazsar": true, "fissival": true, "drake lands": true,
-		"human lands": true, "gnoll plains": true,
-		"kasignel": true, "shifthold": true,
-		"walled cities": true, "market street": true,
-		"adventurer's guild": true, "hivelands": true,
+	// Each location takes the type most of its mentions agree on, so a city
+	// first glimpsed as "other" is corrected by the chapters that follow.
+	for _, entry := range locMap {
+		entry.loc.Type = entry.types.winner()
 	}
 
-	// Core place keywords - if a containment chain mentions one of these, it's traceable
-	seedKeywords := []string{
-		"izril", "baleros", "chandrar", "terandria", "rhir", "drath",
-		"liscor", "celum", "esthelm", "invrisil", "pallass", "wales",
-		"reim", "riverfarm", "magnolia", "calanfer", "ailendamus",
-		"oteslia", "zeres", "manus", "wistram", "talenqual", "khelt",
-		"noelictus", "pheislant", "hectval", "reizmelt",
-		"remendia", "albez", "pomle", "tiqr", "roshal", "savere",
-		"jecrass", "hellios", "germina", "medain", "belchan",
-		"gaiil-drome", "elvallian", "paeth", "claiven",
-		"blighted", "nerrhavia", "desonis", "kaliv", "erribathe",
-		"laken", "unseen empire", "riverfarm",
-		"nombernaught", "dwarven", "salazsar", "fissival", "drake",
-		"human", "gnoll", "antinium", "goblin",
-	}
+	// Merge locations that are the same place, then move relationships and
+	// containment onto the resolved IDs.
+	resolve, merges, candidates := resolveEntities(locMap, allRels, allContainment, g, resolveOptions{
+		autoMerge:   opts.AutoMerge,
+		reviewMerge: opts.ReviewMerge,
+		reviews:     reviews,
+	})
+	allRels, allContainment = rewriteEndpoints(allRels, allContainment, resolve)
 
-	matchesSeed := func(name string) bool {
-		if seededNames[name] {
-			return true
-		}
-		for _, kw := range seedKeywords {
-			if strings.Contains(name, kw) {
-				return true
+	// Reject names that aren't places: known non-locations, the user's deny
+	// list, and names never capitalized. Names seen only as relationship
+	// endpoints are rejected when a person verb follows them.
+	for key, entry := range locMap {
+		if reason := cls.classify(key, entry.spellings); reason != "" {
+			for idx := range entry.indices {
+				rejected.add(key, reason, "", idx)
 			}
+			delete(locMap, key)
 		}
-		return false
 	}
-
-	// Check if a location can trace back to a known position
-	isTraceable := func(id string) bool {
-		if matchesSeed(id) {
-			return true
-		}
-		cur := id
-		for i := 0; i < maxContainmentDepth; i++ {
-			p, ok := parentOf[cur]
-			if !ok {
-				return false
-			}
-			if matchesSeed(p) {
-				return true
-			}
-			cur = p
+	for key, hit := range personHits {
+		if _, isLoc := locMap[resolve(key)]; isLoc || cls.allow[key] || rejected[key] != nil {
+			continue
 		}
-		return false
+		rejected.add(key, RejectPersonVerb, hit.detail, hit.chapterIdx)
+		rejected[key].MentionCount = hit.count
 	}
+	candidates = slices.DeleteFunc(candidates, func(c model.MergeCandidate) bool {
+		a, b := resolve(c.A), resolve(c.B)
+		return a == b || locMap[a] == nil || locMap[b] == nil
+	})
+	allRels = slices.DeleteFunc(allRels, func(r model.AggregatedRelationship) bool {
+		return rejected[normalizeName(r.From)] != nil || rejected[normalizeName(r.To)] != nil
+	})
+	allContainment = slices.DeleteFunc(allContainment, func(c model.Containment) bool {
+		return rejected[normalizeName(c.Child)] != nil || rejected[normalizeName(c.Parent)] != nil
+	})
 
-	// Build set of "established" location IDs — those that meet the full inclusion criteria
-	// (enough mentions AND traceable). Then find low-mention locations that are relationship
-	// endpoints connected to an established location — these provide provenance context.
-	established := map[string]bool{}
-	for _, entry := range locMap {
-		if entry.loc.MentionCount >= minMentions && isTraceable(entry.loc.ID) {
-			established[entry.loc.ID] = true
-		}
-	}
-	provenanceEndpoints := map[string]bool{}
-	for _, rel := range allRels {
-		fromID := normalizeName(rel.From)
-		toID := normalizeName(rel.To)
-		// If one side is established, the other side earns inclusion as provenance
-		if established[fromID] && isTraceable(toID) {
-			provenanceEndpoints[toID] = true
-		}
-		if established[toID] && isTraceable(fromID) {
-			provenanceEndpoints[fromID] = true
+	// Decide which locations make it onto the map.
+	inclusion := policy.decide(locMap, allRels, allContainment, g)
+
+	// A later chapter may back a relationship with a quote that verifies
+	// where the first one didn't; readers who haven't reached it are served
+	// an earlier quote instead (see AggregatedRelationship.SetQuoteAsOf).
+	for i := range allRels {
+		if opts.DropUnverifiedQuotes {
+			allRels[i].Quotes = slices.DeleteFunc(allRels[i].Quotes, func(q model.RelationshipQuote) bool { return !q.Verified })
 		}
+		allRels[i].SetQuoteAsOf(math.MaxInt)
 	}
 
 	var locations []model.AggregatedLocation
+	var descriptions []model.DescriptionRevision
 	for _, entry := range locMap {
-		if !isTraceable(entry.loc.ID) {
-			continue // can't place on map
-		}
-		if entry.loc.MentionCount < minMentions && !provenanceEndpoints[entry.loc.ID] {
-			continue // not referenced enough and not a provenance endpoint
+		if !inclusion[entry.loc.ID].Included {
+			continue
 		}
 		for idx := range entry.indices {
 			entry.loc.ChapterIndices = append(entry.loc.ChapterIndices, idx)
 		}
 		sort.Ints(entry.loc.ChapterIndices)
+		entry.loc.TypeVotes = entry.types.tally()
 		locations = append(locations, entry.loc)
+		for _, rev := range entry.revisions {
+			descriptions = append(descriptions, *rev)
+		}
 	}
 
 	// Sort by first appearance
 	sort.Slice(locations, func(i, j int) bool {
 		return locations[i].FirstChapterIndex < locations[j].FirstChapterIndex
 	})
+	sort.Slice(descriptions, func(i, j int) bool {
+		if descriptions[i].LocationID != descriptions[j].LocationID {
+			return descriptions[i].LocationID < descriptions[j].LocationID
+		}
+		return descriptions[i].ChapterIndex < descriptions[j].ChapterIndex
+	})
+
+	var rejectedList []model.RejectedEntity
+	for _, r := range rejected {
+		rejectedList = append(rejectedList, *r)
+	}
+	sort.Slice(rejectedList, func(i, j int) bool { return rejectedList[i].ID < rejectedList[j].ID })
 
 	return &model.AggregatedData{
 		Locations:     locations,
 		Relationships: allRels,
 		Containment:   allContainment,
+		Descriptions:  descriptions,
+		Rejected:      rejectedList,
+		Merges:        merges,
+		Candidates:    candidates,
+		Inclusion:     sortedDecisions(inclusion),
 		AggregatedAt:  time.Now().UTC().Format(time.RFC3339),
 	}, nil
 }
 
-// canonicalize applies the canonical name map, returning the canonical form or the original.
-func canonicalize(name string, canonicalNames map[string]string) string {
-	if canon, ok := canonicalNames[name]; ok {
-		return canon
+// addAgreement folds one mention's consensus agreement into mean, the running
+// average over n mentions. Mentions from single-run extractions carry no
+// agreement and are skipped.
+func addAgreement(mean *float64, n *int, agreement float64) {
+	if agreement <= 0 {
+		return
+	}
+	*n++
+	*mean += (agreement - *mean) / float64(*n)
+}
+
+// addQuote records rel's quote, and where it was found, as agg's quote for
+// the chapter. Of several quotes within one chapter, the first verified one
+// wins.
+func addQuote(agg *model.AggregatedRelationship, rel model.ExtractedRelationship, chapterIdx int) {
+	if rel.Quote == "" {
+		return
+	}
+	q := model.RelationshipQuote{ChapterIndex: chapterIdx, Quote: rel.Quote, Verified: rel.QuoteMatch.Verified()}
+	if q.Verified {
+		q.Paragraph = rel.QuoteMatch.Paragraph
+	}
+	if n := len(agg.Quotes); n > 0 && agg.Quotes[n-1].ChapterIndex == chapterIdx {
+		if q.Verified && !agg.Quotes[n-1].Verified {
+			agg.Quotes[n-1] = q
+		}
+		return
+	}
+	agg.Quotes = append(agg.Quotes, q)
+}
+
+// rewriteEndpoints renames relationship and containment endpoints through
+// resolve, dropping links that now join a location to itself and, of links
+// that became identical, all but the first.
+func rewriteEndpoints(rels []model.AggregatedRelationship, cont []model.Containment, resolve func(string) string) ([]model.AggregatedRelationship, []model.Containment) {
+	rename := func(name string) string {
+		if id := resolve(normalizeName(name)); id != normalizeName(name) {
+			return toDisplayName(id)
+		}
+		return name
+	}
+
+	seen := make(map[string]bool)
+	outRels := rels[:0]
+	for _, rel := range rels {
+		rel.From, rel.To = rename(rel.From), rename(rel.To)
+		key := fmt.Sprintf("%s|%s|%s", normalizeName(rel.From), normalizeName(rel.To), rel.Type)
+		if normalizeName(rel.From) == normalizeName(rel.To) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		outRels = append(outRels, rel)
+	}
+
+	seen = make(map[string]bool)
+	outCont := cont[:0]
+	for _, c := range cont {
+		c.Child, c.Parent = rename(c.Child), rename(c.Parent)
+		key := normalizeName(c.Child) + "|" + normalizeName(c.Parent)
+		if normalizeName(c.Child) == normalizeName(c.Parent) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		outCont = append(outCont, c)
+	}
+	return outRels, outCont
+}
+
+// addRevision records a chapter's description of a location. When several
+// extracted names collapse onto the same location within one chapter, the
+// longest text for each field wins, matching the cross-chapter rule.
+func addRevision(revs map[int]*model.DescriptionRevision, id string, chapterIdx int, loc model.ExtractedLocation) {
+	if loc.Description == "" && loc.VisualDescription == "" {
+		return
+	}
+	rev, ok := revs[chapterIdx]
+	if !ok {
+		rev = &model.DescriptionRevision{LocationID: id, ChapterIndex: chapterIdx}
+		revs[chapterIdx] = rev
+	}
+	if len(loc.Description) > len(rev.Description) {
+		rev.Description = loc.Description
+	}
+	if len(loc.VisualDescription) > len(rev.VisualDescription) {
+		rev.VisualDescription = loc.VisualDescription
 	}
-	return name
 }
 
 func normalizeName(name string) string {
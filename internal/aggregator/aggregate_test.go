@@ -1,6 +1,7 @@
 package aggregator
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -20,7 +21,7 @@ func TestAggregate(t *testing.T) {
 	}
 	defer s.Close()
 
-	// Insert TOC (need enough chapters so locations meet minMentions=3 filter)
+	// Insert TOC (need enough chapters so locations meet the default 3-mention threshold)
 	toc := &model.TOC{
 		Chapters: []model.Chapter{
 			{Index: 0, WebTitle: "1.00", Slug: "1-00", Volume: "vol-1"},
@@ -83,7 +84,7 @@ func TestAggregate(t *testing.T) {
 		t.Fatalf("writing extraction 3: %v", err)
 	}
 
-	data, err := Aggregate(s)
+	data, err := Aggregate(s, Options{})
 	if err != nil {
 		t.Fatalf("aggregation failed: %v", err)
 	}
@@ -117,6 +118,23 @@ func TestAggregate(t *testing.T) {
 		t.Errorf("expected longer description, got %q", liscor.Description)
 	}
 
+	// Every chapter's description should be kept as a revision
+	var liscorRevs []model.DescriptionRevision
+	for _, rev := range data.Descriptions {
+		if rev.LocationID == "liscor" {
+			liscorRevs = append(liscorRevs, rev)
+		}
+	}
+	if len(liscorRevs) != 3 {
+		t.Fatalf("expected 3 Liscor description revisions, got %d", len(liscorRevs))
+	}
+	if liscorRevs[0].ChapterIndex != 0 || liscorRevs[0].Description != "A walled city" {
+		t.Errorf("expected chapter 0 revision 'A walled city', got %+v", liscorRevs[0])
+	}
+	if liscorRevs[2].ChapterIndex != 2 || liscorRevs[2].Description != "Liscor again" {
+		t.Errorf("expected chapter 2 revision 'Liscor again', got %+v", liscorRevs[2])
+	}
+
 	// The Wandering Inn should get title-cased canonical name
 	var twi *model.AggregatedLocation
 	for i, loc := range data.Locations {
@@ -156,6 +174,29 @@ func TestAggregate(t *testing.T) {
 		if c.Parent != "Izril" {
 			t.Errorf("expected containment parent 'Izril', got %q", c.Parent)
 		}
+		if c.FirstChapterIndex != 0 {
+			t.Errorf("expected containment first chapter 0, got %d", c.FirstChapterIndex)
+		}
+	}
+
+	// Coordinates carry the chapter that reveals their location; seeds for
+	// places no chapter mentions stay unrevealed.
+	if err := AssignCoordinates(s, data, nil); err != nil {
+		t.Fatalf("assigning coordinates: %v", err)
+	}
+	coords, err := s.ReadCoordinates()
+	if err != nil {
+		t.Fatalf("reading coordinates: %v", err)
+	}
+	revealed := make(map[string]int)
+	for _, c := range coords {
+		revealed[c.LocationID] = c.FirstChapterIndex
+	}
+	if revealed["liscor"] != 0 {
+		t.Errorf("expected liscor coordinate revealed at chapter 0, got %d", revealed["liscor"])
+	}
+	if revealed["pallass"] != model.UnrevealedChapter {
+		t.Errorf("expected pallass coordinate unrevealed, got %d", revealed["pallass"])
 	}
 }
 
@@ -196,3 +237,270 @@ func TestToDisplayName(t *testing.T) {
 		}
 	}
 }
+
+func TestAggregateQuotes(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-quotes")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 3 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+
+	unverified := &model.QuoteMatch{Offset: 10, Score: 0.3}
+	verified := &model.QuoteMatch{Offset: 500, Paragraph: 7, Score: 1}
+	rels := [][]model.ExtractedRelationship{
+		{
+			{From: "The Wandering Inn", To: "Liscor", Type: "adjacency", Quote: "made up", QuoteMatch: unverified},
+			{From: "Liscor", To: "Izril", Type: "containment", Quote: "also made up", QuoteMatch: unverified},
+		},
+		{{From: "The Wandering Inn", To: "Liscor", Type: "adjacency", Quote: "the inn near Liscor", QuoteMatch: verified}},
+		{{From: "The Wandering Inn", To: "Liscor", Type: "adjacency", Quote: "later quote", QuoteMatch: verified}},
+	}
+	for i, r := range rels {
+		ext := &model.ChapterExtraction{
+			ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01",
+			Locations: []model.ExtractedLocation{
+				{Name: "Liscor", Type: "city"},
+				{Name: "The Wandering Inn", Type: "building"},
+				{Name: "Izril", Type: "continent"},
+			},
+			Relationships: r,
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	data, err := Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	if len(data.Relationships) != 2 {
+		t.Fatalf("expected 2 relationships, got %+v", data.Relationships)
+	}
+	inn := data.Relationships[0]
+	if inn.Quote != "the inn near Liscor" || !inn.QuoteVerified || inn.QuoteChapterIndex != 1 || inn.QuoteParagraph != 7 {
+		t.Errorf("expected the first verified quote to replace the unverified one, got %+v", inn)
+	}
+	if inn.FirstChapterIndex != 0 {
+		t.Errorf("expected first chapter to stay 0, got %d", inn.FirstChapterIndex)
+	}
+	if len(inn.Quotes) != 3 || inn.Quotes[0].Quote != "made up" || inn.Quotes[0].Verified {
+		t.Errorf("expected every chapter's quote kept for readers not yet at chapter 1, got %+v", inn.Quotes)
+	}
+	if cont := data.Relationships[1]; cont.Quote != "also made up" || cont.QuoteVerified {
+		t.Errorf("expected unverified quote kept but not marked verified, got %+v", cont)
+	}
+
+	data, err = Aggregate(s, Options{DropUnverifiedQuotes: true})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	if data.Relationships[0].Quote != "the inn near Liscor" || data.Relationships[1].Quote != "" {
+		t.Errorf("expected only the unverified quote dropped, got %+v", data.Relationships)
+	}
+}
+
+func TestAggregateRejectsNonLocations(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-filter")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 3 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	for i := range 3 {
+		ext := &model.ChapterExtraction{
+			ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01",
+			Locations: []model.ExtractedLocation{
+				{Name: "Liscor", Type: "city"},
+				{Name: "Runner's Guild", Type: "building"},
+				{Name: "the kitchen", Type: "building"},
+				{Name: "the cellar", Type: "building"},
+				{Name: "London", Type: "city"},
+			},
+			Relationships: []model.ExtractedRelationship{
+				{From: "Erin", To: "Liscor", Type: "direction", Detail: "Erin said the city was north"},
+				{From: "Runner's Guild", To: "Liscor", Type: "containment"},
+			},
+			Containment: []model.Containment{
+				{Child: "the kitchen", Parent: "Liscor"},
+				{Child: "the cellar", Parent: "Liscor"},
+			},
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	data, err := Aggregate(s, Options{Filter: &NameFilter{Allow: []string{"The Cellar"}}})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+
+	var ids []string
+	for _, loc := range data.Locations {
+		ids = append(ids, loc.ID)
+	}
+	if fmt.Sprint(ids) != "[liscor the cellar]" && fmt.Sprint(ids) != "[the cellar liscor]" {
+		t.Errorf("expected only Liscor and the allowed cellar, got %v", ids)
+	}
+
+	reasons := make(map[string]string)
+	for _, r := range data.Rejected {
+		reasons[r.ID] = r.Reason
+	}
+	want := map[string]string{
+		"runner's guild": RejectDenyList,
+		"the kitchen":    RejectUncapitalized,
+		"london":         RejectEarth,
+		"erin":           RejectPersonVerb,
+	}
+	if fmt.Sprint(reasons) != fmt.Sprint(want) {
+		t.Errorf("expected rejections %v, got %v", want, reasons)
+	}
+	for _, r := range data.Rejected {
+		if r.ID == "the kitchen" && r.MentionCount != 3 {
+			t.Errorf("expected the kitchen rejected with 3 mentions, got %d", r.MentionCount)
+		}
+	}
+
+	if len(data.Relationships) != 0 {
+		t.Errorf("expected relationships with rejected endpoints dropped, got %+v", data.Relationships)
+	}
+	if len(data.Containment) != 1 || data.Containment[0].Child != "The Cellar" {
+		t.Errorf("expected only the allowed containment kept, got %+v", data.Containment)
+	}
+}
+
+func TestPersonVerbAfter(t *testing.T) {
+	tests := []struct {
+		text, name, want string
+	}{
+		{"Erin said the inn was near.", "Erin", "said"},
+		{"Liscor stood tall, and Relc nodded.", "Relc", "nodded"},
+		{"Liscor stood tall.", "Liscor", ""},
+		{"Near Liscor, Erin laughed.", "Liscor", ""},
+		{"Ryoka walked, then Ryoka thought.", "ryoka", "walked"},
+	}
+	for _, tt := range tests {
+		if got := personVerbAfter(tt.text, tt.name); got != tt.want {
+			t.Errorf("personVerbAfter(%q, %q) = %q, want %q", tt.text, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAggregateAgreement(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-agreement")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 3 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+
+	// Chapter 2 was extracted in a single run, so it carries no agreement.
+	for i, agreement := range []float64{1, 0.5, 0} {
+		ext := &model.ChapterExtraction{
+			ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01",
+			Locations: []model.ExtractedLocation{{Name: "Liscor", Type: "city", Agreement: agreement}},
+			Relationships: []model.ExtractedRelationship{
+				{From: "Liscor", To: "Izril", Type: "containment", Agreement: agreement},
+			},
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	data, err := Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	if len(data.Locations) != 1 || data.Locations[0].Agreement != 0.75 {
+		t.Errorf("expected Liscor agreement averaged over consensus chapters (0.75), got %+v", data.Locations)
+	}
+	if len(data.Relationships) != 1 || data.Relationships[0].Agreement != 0.75 {
+		t.Errorf("expected relationship agreement 0.75, got %+v", data.Relationships)
+	}
+}
+
+func TestAggregateCountsChapters(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-mentions")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 3 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+
+	// Each chapter names both cities twice: Pallass under one key, Liscor
+	// under two that are merged by alias.
+	for i := range 3 {
+		ext := &model.ChapterExtraction{
+			ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01",
+			Locations: []model.ExtractedLocation{
+				{Name: "Pallass", Type: "city"},
+				{Name: "PALLASS", Type: "city"},
+				{Name: "Liscor", Type: "city"},
+				{Name: "The City of Liscor", Type: "city", Aliases: []string{"Liscor"}},
+			},
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	data, err := Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, loc := range data.Locations {
+		counts[loc.ID] = loc.MentionCount
+	}
+	if len(counts) != 2 || counts["pallass"] != 3 || counts["liscor"] != 3 {
+		t.Errorf("expected both cities counted once per chapter, got %v", counts)
+	}
+}
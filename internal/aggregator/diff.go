@@ -0,0 +1,154 @@
+package aggregator
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// Change kinds recorded in Change.Kind.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// Change is one record that differs between two extractions of a chapter.
+// A is the record in the older extraction and B in the newer; one of them is
+// nil for an addition or removal. Fields lists what differs in a
+// modification, e.g. "name" when only the spelling changed.
+type Change[T any] struct {
+	Kind   string   `json:"kind"`
+	Key    string   `json:"key"`
+	A      *T       `json:"a,omitempty"`
+	B      *T       `json:"b,omitempty"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ExtractionDiff lists what changed between two extractions of a chapter.
+// Records are matched by Gazetteer.Key, so spelling changes that aggregate to
+// the same location show up as modifications rather than a removal and an
+// addition.
+type ExtractionDiff struct {
+	ChapterIndex  int                                   `json:"chapter_index"`
+	Locations     []Change[model.ExtractedLocation]     `json:"locations"`
+	Relationships []Change[model.ExtractedRelationship] `json:"relationships"`
+	Containment   []Change[model.Containment]           `json:"containment"`
+}
+
+// Empty reports whether the two extractions agree.
+func (d *ExtractionDiff) Empty() bool {
+	return len(d.Locations) == 0 && len(d.Relationships) == 0 && len(d.Containment) == 0
+}
+
+// DiffExtractions compares extraction a with b, matching names through g
+// (DefaultGazetteer if nil). Changes are sorted by key.
+func DiffExtractions(a, b *model.ChapterExtraction, g *Gazetteer) *ExtractionDiff {
+	if g == nil {
+		g = DefaultGazetteer()
+	}
+	return &ExtractionDiff{
+		ChapterIndex: b.ChapterIndex,
+		Locations: diffRecords(a.Locations, b.Locations,
+			func(l model.ExtractedLocation) string { return g.Key(l.Name) },
+			locationFields),
+		Relationships: diffRecords(a.Relationships, b.Relationships,
+			func(r model.ExtractedRelationship) string {
+				return g.Key(r.From) + " -> " + g.Key(r.To) + " (" + string(r.Type) + ")"
+			},
+			relationshipFields),
+		Containment: diffRecords(a.Containment, b.Containment,
+			func(c model.Containment) string { return g.Key(c.Child) + " in " + g.Key(c.Parent) },
+			func(x, y model.Containment) []string {
+				if x.Child != y.Child || x.Parent != y.Parent {
+					return []string{"name"}
+				}
+				return nil
+			}),
+	}
+}
+
+// diffRecords matches records of a and b by key and reports each key found
+// on only one side, or whose records differ per fields. When several records
+// share a key within one extraction, the first is compared.
+func diffRecords[T any](a, b []T, key func(T) string, fields func(x, y T) []string) []Change[T] {
+	index := func(recs []T) map[string]*T {
+		m := make(map[string]*T)
+		for i := range recs {
+			if k := key(recs[i]); m[k] == nil {
+				m[k] = &recs[i]
+			}
+		}
+		return m
+	}
+	inA, inB := index(a), index(b)
+
+	changes := []Change[T]{}
+	for k, x := range inA {
+		y, ok := inB[k]
+		if !ok {
+			changes = append(changes, Change[T]{Kind: ChangeRemoved, Key: k, A: x})
+		} else if f := fields(*x, *y); len(f) > 0 {
+			changes = append(changes, Change[T]{Kind: ChangeModified, Key: k, A: x, B: y, Fields: f})
+		}
+	}
+	for k, y := range inB {
+		if _, ok := inA[k]; !ok {
+			changes = append(changes, Change[T]{Kind: ChangeAdded, Key: k, B: y})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// locationFields lists the fields that differ between two extractions of
+// the same location. Aliases are compared as normalized sets.
+func locationFields(x, y model.ExtractedLocation) []string {
+	var fields []string
+	if x.Name != y.Name {
+		fields = append(fields, "name")
+	}
+	if x.Type != y.Type {
+		fields = append(fields, "type")
+	}
+	if !sameNames(x.Aliases, y.Aliases) {
+		fields = append(fields, "aliases")
+	}
+	if x.Description != y.Description {
+		fields = append(fields, "description")
+	}
+	if x.VisualDescription != y.VisualDescription {
+		fields = append(fields, "visual_description")
+	}
+	return fields
+}
+
+// relationshipFields does the same for relationships.
+func relationshipFields(x, y model.ExtractedRelationship) []string {
+	var fields []string
+	if x.From != y.From || x.To != y.To {
+		fields = append(fields, "name")
+	}
+	if x.Detail != y.Detail {
+		fields = append(fields, "detail")
+	}
+	if x.Quote != y.Quote {
+		fields = append(fields, "quote")
+	}
+	return fields
+}
+
+// sameNames reports whether a and b hold the same names once normalized,
+// ignoring order and repeats.
+func sameNames(a, b []string) bool {
+	norm := func(names []string) []string {
+		var out []string
+		for _, n := range names {
+			out = append(out, normalizeName(n))
+		}
+		slices.Sort(out)
+		return slices.Compact(out)
+	}
+	return slices.Equal(norm(a), norm(b))
+}
@@ -0,0 +1,75 @@
+package aggregator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestDiffExtractions(t *testing.T) {
+	a := &model.ChapterExtraction{
+		ChapterIndex: 3,
+		Locations: []model.ExtractedLocation{
+			{Name: "Liscor", Type: model.LocationCity, Description: "A walled city"},
+			{Name: "Bloodfields", Type: model.LocationLandmark},
+			{Name: "Celum", Type: model.LocationTown, Aliases: []string{"celum town"}},
+			{Name: "Pallass", Type: model.LocationCity},
+		},
+		Relationships: []model.ExtractedRelationship{
+			{From: "The Inn", To: "Liscor", Type: model.RelAdjacency, Detail: "near"},
+			{From: "Liscor", To: "Celum", Type: model.RelDirection},
+		},
+		Containment: []model.Containment{{Child: "Liscor", Parent: "Izril"}},
+	}
+	b := &model.ChapterExtraction{
+		ChapterIndex: 3,
+		Locations: []model.ExtractedLocation{
+			{Name: "Liscor", Type: model.LocationCity, Description: "A walled city of Drakes"},
+			{Name: "The Blood Fields", Type: model.LocationLandmark},
+			{Name: "Celum", Type: model.LocationTown, Aliases: []string{"Celum Town"}},
+			{Name: "Esthelm", Type: model.LocationTown},
+		},
+		Relationships: []model.ExtractedRelationship{
+			{From: "The Wandering Inn", To: "Liscor", Type: model.RelAdjacency, Detail: "near"},
+		},
+		Containment: []model.Containment{{Child: "liscor", Parent: "Izril"}, {Child: "Esthelm", Parent: "Izril"}},
+	}
+
+	d := DiffExtractions(a, b, nil)
+	if d.ChapterIndex != 3 || d.Empty() {
+		t.Fatalf("expected a non-empty diff of chapter 3, got %+v", d)
+	}
+
+	got := make(map[string]string)
+	for _, c := range d.Locations {
+		got[c.Key] = fmt.Sprint(c.Kind, c.Fields)
+	}
+	want := map[string]string{
+		"liscor":       "modified[description]",
+		"blood fields": "modified[name]",
+		"pallass":      "removed[]",
+		"esthelm":      "added[]",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected location changes %v, got %v", want, got)
+	}
+
+	if len(d.Relationships) != 2 {
+		t.Fatalf("expected 2 relationship changes, got %+v", d.Relationships)
+	}
+	if c := d.Relationships[1]; c.Kind != ChangeModified || c.Key != "the wandering inn -> liscor (adjacency)" || fmt.Sprint(c.Fields) != "[name]" {
+		t.Errorf("expected the respelled inn grouped as a name change, got %+v", c)
+	}
+	if c := d.Relationships[0]; c.Kind != ChangeRemoved || c.A.To != "Celum" || c.B != nil {
+		t.Errorf("expected the Celum relationship removed, got %+v", c)
+	}
+
+	if len(d.Containment) != 2 || d.Containment[0].Kind != ChangeAdded || d.Containment[1].Fields[0] != "name" {
+		t.Errorf("expected Esthelm containment added and Liscor respelled, got %+v", d.Containment)
+	}
+
+	if !DiffExtractions(a, a, nil).Empty() {
+		t.Error("expected an extraction to have no diff with itself")
+	}
+}
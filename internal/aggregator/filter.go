@@ -0,0 +1,154 @@
+package aggregator
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// Rejection reasons recorded in model.RejectedEntity.Reason.
+const (
+	RejectEarth         = "earth"
+	RejectDenyList      = "deny_list"
+	RejectFilterFile    = "filter_file"
+	RejectUncapitalized = "uncapitalized"
+	RejectPersonVerb    = "person_verb"
+)
+
+// deniedNames are names the extractor often returns that are organizations,
+// peoples, or adventuring teams rather than places.
+var deniedNames = map[string]bool{
+	// Guilds and other organizations
+	"runner's guild": true, "runners' guild": true, "adventurer's guild": true,
+	"adventurers' guild": true, "mage's guild": true, "mages' guild": true,
+	"merchant's guild": true, "merchants' guild": true, "thieves' guild": true,
+	"the watch": true, "watch": true, "city watch": true, "the council": true,
+	"council": true, "wall lords": true, "the five families": true,
+	// Peoples
+	"drakes": true, "gnolls": true, "humans": true, "antinium": true,
+	"goblins": true, "dwarves": true, "elves": true, "half-elves": true,
+	"lizardfolk": true, "garuda": true, "centaurs": true, "minotaurs": true,
+	"selphids": true, "dullahans": true, "beastkin": true,
+	// Adventuring teams
+	"horns of hammerad": true, "halfseekers": true, "griffon hunt": true,
+	"silver swords": true, "flowers of izril": true, "the flowers of izril": true,
+}
+
+// personVerbs are verbs that follow a person's name, not a place's, as in
+// "Erin said". Places can "stand" or "lie", so only speech, thought, and
+// bodily actions are listed.
+var personVerbs = map[string]bool{
+	"said": true, "says": true, "asked": true, "asks": true, "told": true,
+	"replied": true, "shouted": true, "whispered": true, "muttered": true,
+	"laughed": true, "smiled": true, "grinned": true, "nodded": true,
+	"frowned": true, "sighed": true, "thought": true, "thinks": true,
+	"wondered": true, "decided": true, "remembered": true, "walked": true,
+	"ran": true, "glanced": true, "looked": true, "stared": true,
+}
+
+// NameFilter is a user-curated allow and deny list, loaded from a TOML file
+// with "allow" and "deny" arrays of names. Allowed names are never rejected;
+// denied names always are.
+type NameFilter struct {
+	Allow []string `toml:"allow"`
+	Deny  []string `toml:"deny"`
+}
+
+// LoadNameFilter reads a filter file. A missing file yields an empty filter.
+func LoadNameFilter(path string) (*NameFilter, error) {
+	f := &NameFilter{}
+	if path == "" {
+		return f, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return f, nil
+	}
+	if _, err := toml.DecodeFile(path, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// classifier decides which aggregated names are not map locations.
+type classifier struct {
+	allow, deny map[string]bool
+	earth       map[string]bool
+}
+
+func newClassifier(f *NameFilter, g *Gazetteer) *classifier {
+	c := &classifier{allow: make(map[string]bool), deny: make(map[string]bool), earth: g.earth}
+	if f == nil {
+		return c
+	}
+	for _, name := range f.Allow {
+		c.allow[g.Key(name)] = true
+	}
+	for _, name := range f.Deny {
+		c.deny[g.Key(name)] = true
+	}
+	return c
+}
+
+// classify returns why the location id, extracted under the given
+// spellings, should be rejected, or "" to keep it.
+func (c *classifier) classify(id string, spellings []string) string {
+	switch {
+	case c.allow[id]:
+		return ""
+	case c.deny[id]:
+		return RejectFilterFile
+	case c.earth[id]:
+		return RejectEarth
+	case deniedNames[id]:
+		return RejectDenyList
+	}
+	// Proper place names are capitalized. A name never extracted with a
+	// capital letter is a common noun such as "the kitchen".
+	for _, s := range spellings {
+		if strings.IndexFunc(s, unicode.IsUpper) >= 0 {
+			return ""
+		}
+	}
+	return RejectUncapitalized
+}
+
+// personVerbAfter returns the person verb that directly follows name in
+// text, or "" if there is none.
+func personVerbAfter(text, name string) string {
+	text, name = strings.ToLower(text), strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	for i := strings.Index(text, name); i >= 0; {
+		rest := strings.Fields(text[i+len(name):])
+		if len(rest) > 0 {
+			if verb := strings.TrimFunc(rest[0], unicode.IsPunct); personVerbs[verb] {
+				return verb
+			}
+		}
+		next := strings.Index(text[i+1:], name)
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+	return ""
+}
+
+// rejections collects rejected names, keyed by normalized ID.
+type rejections map[string]*model.RejectedEntity
+
+func (r rejections) add(id, reason, detail string, chapterIdx int) {
+	e, ok := r[id]
+	if !ok {
+		e = &model.RejectedEntity{ID: id, Name: toDisplayName(id), Reason: reason, Detail: detail, FirstChapterIndex: chapterIdx}
+		r[id] = e
+	}
+	e.MentionCount++
+	if chapterIdx < e.FirstChapterIndex {
+		e.FirstChapterIndex = chapterIdx
+	}
+}
@@ -0,0 +1,329 @@
+package aggregator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// Default similarity thresholds for fuzzy name matching. Pairs scoring at
+// least DefaultAutoMerge merge outright; pairs scoring at least
+// DefaultReviewMerge are queued as merge candidates for review.
+const (
+	DefaultAutoMerge   = 0.9
+	DefaultReviewMerge = 0.75
+)
+
+// Merge reasons for fuzzy matching and human review, alongside MergeAlias.
+const (
+	MergeFuzzy     = "fuzzy"
+	MergeReviewed  = "reviewed"
+	RefuseReviewed = "rejected_in_review"
+)
+
+// contextWeight scales how much shared context, the overlap of two
+// locations' related places, raises their name similarity.
+const contextWeight = 0.2
+
+// maxBlockSize skips name tokens shared by so many locations ("city",
+// "road") that comparing every pair in them is both slow and pointless.
+const maxBlockSize = 200
+
+// nameStopwords carry no identity: "The Inn of Liscor" and "Liscor Inn" are
+// compared on "inn liscor".
+var nameStopwords = map[string]bool{"the": true, "a": true, "an": true, "of": true}
+
+// coreName reduces a normalized name to what fuzzy matching compares:
+// possessives and stopwords dropped, tokens in their original order.
+func coreName(id string) []string {
+	var out []string
+	for _, tok := range strings.FieldsFunc(id, func(r rune) bool { return r == ' ' || r == '-' || r == ',' }) {
+		tok = strings.TrimSuffix(strings.TrimSuffix(tok, "'s"), "'")
+		if tok != "" && !nameStopwords[tok] {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// minWordRatio is the edit ratio below which two words in the same position
+// are different words ("north", "south") rather than one misspelled.
+const minWordRatio = 0.75
+
+// nameSimilarity scores how alike two location IDs are in [0, 1]: the better
+// of their edit-distance ratio and the overlap of their token sets. Names
+// with different numbers in them score 0, and names that differ by a whole
+// word are scored on their tokens alone.
+func nameSimilarity(a, b string) float64 {
+	ta, tb := coreName(a), coreName(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	// Numbered siblings such as "level 11" and "level 12" are different
+	// places, however alike their names.
+	if !slices.Equal(numberTokens(ta), numberTokens(tb)) {
+		return 0
+	}
+	edit := 0.0
+	if !wordMismatch(ta, tb) {
+		edit = editRatio(strings.Join(ta, " "), strings.Join(tb, " "))
+	}
+	return max(edit, tokenDice(ta, tb))
+}
+
+// numberTokens returns the tokens containing a digit, sorted.
+func numberTokens(toks []string) []string {
+	var out []string
+	for _, t := range toks {
+		if strings.ContainsFunc(t, unicode.IsDigit) {
+			out = append(out, t)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// wordMismatch reports whether a and b have the same number of tokens and
+// some token of a is a different word from the one in its place in b.
+func wordMismatch(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if editRatio(a[i], b[i]) < minWordRatio {
+			return true
+		}
+	}
+	return false
+}
+
+// editRatio is 1 minus the edit distance between a and b over the longer
+// length, counting an adjacent transposition as one edit.
+func editRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	n := max(len(ra), len(rb))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(n)
+}
+
+// editDistance is the optimal string alignment distance between a and b.
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// tokenDice is the Dice coefficient of two token sets.
+func tokenDice(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, t := range a {
+		set[t] = true
+	}
+	shared := 0
+	seen := make(map[string]bool, len(b))
+	for _, t := range b {
+		if set[t] && !seen[t] {
+			shared++
+		}
+		seen[t] = true
+	}
+	return 2 * float64(shared) / float64(len(set)+len(seen))
+}
+
+// jaccard is the Jaccard index of two sets, 0 if both are empty.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// neighbors maps each location ID to the IDs it shares a relationship or
+// containment link with: its context.
+func neighbors(rels []model.AggregatedRelationship, cont []model.Containment) map[string]map[string]bool {
+	out := make(map[string]map[string]bool)
+	link := func(x, y string) {
+		x, y = normalizeName(x), normalizeName(y)
+		if x == y {
+			return
+		}
+		for _, p := range [][2]string{{x, y}, {y, x}} {
+			if out[p[0]] == nil {
+				out[p[0]] = make(map[string]bool)
+			}
+			out[p[0]][p[1]] = true
+		}
+	}
+	for _, r := range rels {
+		link(r.From, r.To)
+	}
+	for _, c := range cont {
+		link(c.Child, c.Parent)
+	}
+	return out
+}
+
+// pairKey identifies an unordered pair of location IDs.
+func pairKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "\x00" + b
+}
+
+// applyReviews merges the pairs a reviewer accepted, whatever their score
+// or consistency checks, and returns the pairs a reviewer rejected.
+func (r *resolver) applyReviews(reviews []model.MergeReview) map[string]bool {
+	rejected := make(map[string]bool)
+	for _, rv := range reviews {
+		if !rv.Accepted {
+			rejected[pairKey(rv.A, rv.B)] = true
+			continue
+		}
+		if r.locs[rv.A] == nil || r.locs[rv.B] == nil {
+			continue // one side no longer extracted
+		}
+		ra, rb := r.uf.find(rv.A), r.uf.find(rv.B)
+		if ra == rb {
+			continue
+		}
+		r.union(ra, rb)
+		r.decisions = append(r.decisions, model.MergeDecision{
+			ID: r.uf.find(ra), A: rv.A, B: rv.B, Reason: MergeReviewed, Merged: true,
+			Detail: "accepted in review " + rv.ReviewedAt,
+		})
+	}
+	return rejected
+}
+
+// scoredPair is a candidate duplicate found by fuzzy matching.
+type scoredPair struct {
+	a, b                 string
+	score, name, context float64
+}
+
+// fuzzyMatch compares locations with similar names, merging pairs that score
+// at least autoMerge and returning those between reviewMerge and autoMerge
+// for review. Pairs in rejected are never merged or queued.
+func (r *resolver) fuzzyMatch(ctx map[string]map[string]bool, autoMerge, reviewMerge float64, rejected map[string]bool) []model.MergeCandidate {
+	// Only locations sharing a core token, or the first or last letters of
+	// one, are compared.
+	blocks := make(map[string][]string)
+	ids := make([]string, 0, len(r.locs))
+	for id := range r.locs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		core := coreName(id)
+		keys := map[string]bool{}
+		for _, tok := range core {
+			keys["t:"+tok] = true
+			if r := []rune(tok); len(r) > 3 {
+				keys["p:"+string(r[:3])] = true
+				keys["s:"+string(r[len(r)-3:])] = true
+			}
+		}
+		for k := range keys {
+			blocks[k] = append(blocks[k], id)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var pairs []scoredPair
+	for _, block := range blocks {
+		if len(block) > maxBlockSize {
+			continue
+		}
+		for i, a := range block {
+			for _, b := range block[i+1:] {
+				if seen[pairKey(a, b)] {
+					continue
+				}
+				seen[pairKey(a, b)] = true
+				p := scoredPair{a: a, b: b, name: nameSimilarity(a, b)}
+				p.context = jaccard(ctx[a], ctx[b])
+				p.score = min(1, p.name+contextWeight*p.context)
+				if p.score >= reviewMerge {
+					pairs = append(pairs, p)
+				}
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].score != pairs[j].score {
+			return pairs[i].score > pairs[j].score
+		}
+		return pairKey(pairs[i].a, pairs[i].b) < pairKey(pairs[j].a, pairs[j].b)
+	})
+
+	var candidates []model.MergeCandidate
+	for _, p := range pairs {
+		if rejected[pairKey(p.a, p.b)] {
+			r.decisions = append(r.decisions, model.MergeDecision{
+				A: p.a, B: p.b, Reason: RefuseReviewed,
+				Detail: fmt.Sprintf("similarity %.2f, rejected in review", p.score),
+			})
+			continue
+		}
+		ra, rb := r.uf.find(p.a), r.uf.find(p.b)
+		if ra == rb || r.rejectedBetween(ra, rb, rejected) {
+			continue
+		}
+		detail := fmt.Sprintf("similarity %.2f (name %.2f, context %.2f)", p.score, p.name, p.context)
+		if p.score >= autoMerge {
+			r.tryMerge(p.a, p.b, "", MergeFuzzy, detail)
+			continue
+		}
+		if r.typeConflict(ra, rb) != "" || r.containmentConflict(ra, rb) != "" {
+			continue
+		}
+		candidates = append(candidates, model.MergeCandidate{
+			A: p.a, B: p.b, Score: p.score, NameScore: p.name, ContextScore: p.context,
+		})
+	}
+	return candidates
+}
+
+// rejectedBetween reports whether a reviewer rejected merging any member of
+// one entity with any member of the other.
+func (r *resolver) rejectedBetween(ra, rb string, rejected map[string]bool) bool {
+	for _, x := range r.members[ra] {
+		for _, y := range r.members[rb] {
+			if rejected[pairKey(x, y)] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package aggregator
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"the high passes", "high passes", 1},
+		{"floodplains", "flood plains", 11.0 / 12},
+		{"invrisil", "invirsil", 7.0 / 8}, // one transposition
+		{"magnolia's estate", "lady magnolia's estate", 0.8},
+		{"liscor", "liscor's dungeon", 2.0 / 3},
+		{"liscor", "pallass", 0},
+		{"the", "liscor", 0},
+		{"level 11 of the dungeon", "level 12 of the dungeon", 0},
+		{"floor 2", "floor 2", 1},
+		{"north gate", "south gate", 0.5},
+		{"north gate", "nroth gate", 0.9},
+	}
+	for _, tt := range tests {
+		if got := nameSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("nameSimilarity(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	locMap := map[string]*locEntry{}
+	for _, e := range []*locEntry{
+		testEntry("Floodplains", model.LocationLandmark, 3),
+		testEntry("Flood Plains", model.LocationLandmark, 1),
+		testEntry("Magnolia's Estate", model.LocationBuilding, 2),
+		testEntry("Lady Magnolia's Estate", model.LocationBuilding, 2),
+		testEntry("Invrisil", model.LocationCity, 2),
+		testEntry("Invirsil", model.LocationCity, 1),
+		testEntry("Reizmelt", model.LocationCity, 2),
+		testEntry("Riezmelt", model.LocationForest, 1),
+		testEntry("Level 11 Stairs", model.LocationDungeon, 2),
+		testEntry("Level 12 Stairs", model.LocationDungeon, 2),
+		testEntry("North Gate", model.LocationLandmark, 2),
+		testEntry("South Gate", model.LocationLandmark, 2),
+	} {
+		locMap[e.loc.ID] = e
+	}
+	reviews := []model.MergeReview{
+		{A: "invirsil", B: "invrisil", Accepted: false, ReviewedAt: "2025-01-01"},
+	}
+
+	_, merges, candidates := resolveEntities(locMap, nil, nil, DefaultGazetteer(), resolveOptions{
+		autoMerge: DefaultAutoMerge, reviewMerge: DefaultReviewMerge, reviews: reviews,
+	})
+
+	if _, ok := locMap["flood plains"]; ok || locMap["floodplains"].loc.MentionCount != 3 {
+		t.Error("expected Flood Plains merged into Floodplains")
+	}
+	if len(candidates) != 1 || candidates[0].A != "lady magnolia's estate" || candidates[0].B != "magnolia's estate" || candidates[0].Score != 0.8 {
+		t.Errorf("expected only the estates queued for review, got %+v", candidates)
+	}
+	if locMap["invirsil"] == nil || locMap["riezmelt"] == nil {
+		t.Error("expected the rejected pair and the forest kept apart")
+	}
+	if locMap["level 11 stairs"] == nil || locMap["level 12 stairs"] == nil || locMap["north gate"] == nil || locMap["south gate"] == nil {
+		t.Error("expected numbered siblings and gates named for different sides kept apart")
+	}
+
+	reasons := make(map[string]string)
+	for _, m := range merges {
+		reasons[m.A+"/"+m.B] = m.Reason
+	}
+	want := map[string]string{
+		"flood plains/floodplains": MergeFuzzy,
+		"invirsil/invrisil":        RefuseReviewed,
+	}
+	if fmt.Sprint(reasons) != fmt.Sprint(want) {
+		t.Errorf("expected decisions %v, got %v", want, reasons)
+	}
+}
+
+func TestAggregateHonorsMergeReviews(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-review")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 3 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	for i := range 3 {
+		ext := &model.ChapterExtraction{
+			ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01",
+			Locations: []model.ExtractedLocation{
+				{Name: "Magnolia's Estate", Type: "building"},
+				{Name: "Lady Magnolia's Estate", Type: "building"},
+			},
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	data, err := Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	if len(data.Locations) != 2 || len(data.Candidates) != 1 {
+		t.Fatalf("expected both estates kept and queued, got %d locations and %+v", len(data.Locations), data.Candidates)
+	}
+	if err := s.WriteAggregated(data); err != nil {
+		t.Fatalf("writing aggregated: %v", err)
+	}
+
+	c := data.Candidates[0]
+	if err := s.WriteMergeReview(model.MergeReview{A: c.B, B: c.A, Accepted: true, ReviewedAt: "2025-01-01T00:00:00Z"}); err != nil {
+		t.Fatalf("writing review: %v", err)
+	}
+	if pending, _ := s.ReadMergeCandidates(); len(pending) != 0 {
+		t.Errorf("expected reviewed candidates off the queue, got %+v", pending)
+	}
+
+	data, err = Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	if len(data.Locations) != 1 || data.Locations[0].MentionCount != 3 || len(data.Candidates) != 0 {
+		t.Errorf("expected the accepted pair merged, got %+v and candidates %+v", data.Locations, data.Candidates)
+	}
+	if len(data.Merges) != 1 || data.Merges[0].Reason != MergeReviewed || data.Merges[0].ID != data.Locations[0].ID {
+		t.Errorf("expected a reviewed merge decision, got %+v", data.Merges)
+	}
+}
@@ -0,0 +1,231 @@
+package aggregator
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// GazetteerVersion is the gazetteer file format this build reads.
+const GazetteerVersion = 1
+
+//go:embed gazetteer.toml
+var builtinGazetteer string
+
+// confidences are the values model.Coordinate.Confidence takes.
+var confidences = []string{"high", "medium", "low", "estimated"}
+
+// Gazetteer is the hand-curated knowledge aggregation relies on: canonical
+// names and their aliases, seed positions, names that are placeable without
+// a position of their own, and real-world places to leave off the map.
+type Gazetteer struct {
+	Version      int      `toml:"version"`
+	Places       []Place  `toml:"place"`
+	Traceable    []string `toml:"traceable"`
+	SeedKeywords []string `toml:"seed_keywords"`
+	Earth        []string `toml:"earth"`
+
+	canonical map[string]string // alias -> canonical name
+	placeable map[string]bool   // seeded or traceable names and their aliases
+	earth     map[string]bool
+}
+
+// Place is a well-known location. Position, if set, is its [x, y] seed
+// position; an empty Confidence means "estimated".
+type Place struct {
+	Name       string    `toml:"name"`
+	Aliases    []string  `toml:"aliases"`
+	Position   []float64 `toml:"position"`
+	Confidence string    `toml:"confidence"`
+}
+
+// DefaultGazetteer returns the gazetteer built into the binary.
+var DefaultGazetteer = sync.OnceValue(func() *Gazetteer {
+	g, err := ParseGazetteer(builtinGazetteer)
+	if err != nil {
+		panic(fmt.Sprintf("built-in gazetteer: %v", err))
+	}
+	return g
+})
+
+// LoadGazetteer reads a gazetteer file, or returns the built-in one if path
+// is empty.
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	if path == "" {
+		return DefaultGazetteer(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	g, err := ParseGazetteer(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return g, nil
+}
+
+// ParseGazetteer decodes and validates a gazetteer in TOML form.
+func ParseGazetteer(data string) (*Gazetteer, error) {
+	g := &Gazetteer{}
+	md, err := toml.Decode(data, g)
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown key %q", undecoded[0].String())
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	g.index()
+	return g, nil
+}
+
+// Validate reports every problem with the gazetteer: an unsupported
+// version, places or aliases listed twice or claimed by two places, malformed
+// positions, and names that are both places and excluded.
+func (g *Gazetteer) Validate() error {
+	var errs []error
+	if g.Version != GazetteerVersion {
+		errs = append(errs, fmt.Errorf("version %d is not supported (want %d)", g.Version, GazetteerVersion))
+	}
+
+	owner := make(map[string]string) // name or alias -> place that claims it
+	claim := func(name, place string) {
+		if prev, ok := owner[name]; ok {
+			switch {
+			case prev == place && name == place:
+				errs = append(errs, fmt.Errorf("place %q is listed twice", place))
+			case prev == place:
+				errs = append(errs, fmt.Errorf("place %q lists %q twice", place, name))
+			default:
+				errs = append(errs, fmt.Errorf("%q is claimed by both %q and %q", name, prev, place))
+			}
+			return
+		}
+		owner[name] = place
+	}
+	for i, p := range g.Places {
+		name := normalizeName(p.Name)
+		if name == "" {
+			errs = append(errs, fmt.Errorf("place %d has no name", i+1))
+			continue
+		}
+		claim(name, name)
+		for _, a := range p.Aliases {
+			claim(normalizeName(a), name)
+		}
+		if p.Position != nil && len(p.Position) != 2 {
+			errs = append(errs, fmt.Errorf("place %q: position must be [x, y], got %v", name, p.Position))
+		}
+		if p.Confidence != "" && !slices.Contains(confidences, p.Confidence) {
+			errs = append(errs, fmt.Errorf("place %q: confidence %q is not one of %s", name, p.Confidence, strings.Join(confidences, ", ")))
+		}
+		if p.Confidence != "" && p.Position == nil {
+			errs = append(errs, fmt.Errorf("place %q: confidence set without a position", name))
+		}
+	}
+
+	lists := []struct {
+		name  string
+		names []string
+	}{{"traceable", g.Traceable}, {"seed_keywords", g.SeedKeywords}, {"earth", g.Earth}}
+	for _, l := range lists {
+		seen := make(map[string]bool)
+		for _, n := range l.names {
+			n = normalizeName(n)
+			if seen[n] {
+				errs = append(errs, fmt.Errorf("%s lists %q twice", l.name, n))
+			}
+			seen[n] = true
+			if place, ok := owner[n]; ok && l.name == "earth" {
+				errs = append(errs, fmt.Errorf("earth name %q is also place %q", n, place))
+			}
+			if place, ok := owner[n]; ok && l.name == "traceable" {
+				errs = append(errs, fmt.Errorf("traceable name %q is already place %q", n, place))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// index builds the lookup maps from the validated entries.
+func (g *Gazetteer) index() {
+	g.canonical = make(map[string]string)
+	g.placeable = make(map[string]bool)
+	g.earth = make(map[string]bool)
+	for _, p := range g.Places {
+		name := normalizeName(p.Name)
+		for _, a := range p.Aliases {
+			g.canonical[normalizeName(a)] = name
+			if p.Position != nil {
+				g.placeable[normalizeName(a)] = true
+			}
+		}
+		if p.Position != nil {
+			g.placeable[name] = true
+		}
+	}
+	for _, n := range g.Traceable {
+		g.placeable[normalizeName(n)] = true
+	}
+	for _, n := range g.Earth {
+		g.earth[normalizeName(n)] = true
+	}
+	for i, kw := range g.SeedKeywords {
+		g.SeedKeywords[i] = normalizeName(kw)
+	}
+}
+
+// Key returns the ID a location name aggregates under: the normalized name,
+// mapped through the aliases of well-known places.
+func (g *Gazetteer) Key(name string) string {
+	name = normalizeName(name)
+	if canon, ok := g.canonical[name]; ok {
+		return canon
+	}
+	return name
+}
+
+// matchesSeed reports whether a location ID can be placed on the map by
+// itself: it has a seed position, is listed as traceable, or mentions a
+// seed keyword.
+func (g *Gazetteer) matchesSeed(id string) bool {
+	if g.placeable[id] {
+		return true
+	}
+	for _, kw := range g.SeedKeywords {
+		if strings.Contains(id, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// seeds returns the seed coordinates keyed by location ID. A place's aliases
+// are seeded at its position too.
+func (g *Gazetteer) seeds() map[string]model.Coordinate {
+	out := make(map[string]model.Coordinate)
+	for _, p := range g.Places {
+		if p.Position == nil {
+			continue
+		}
+		conf := p.Confidence
+		if conf == "" {
+			conf = "estimated"
+		}
+		for _, name := range append([]string{p.Name}, p.Aliases...) {
+			id := normalizeName(name)
+			out[id] = model.Coordinate{LocationID: id, X: p.Position[0], Y: p.Position[1], Confidence: conf}
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package aggregator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultGazetteer(t *testing.T) {
+	g := DefaultGazetteer()
+	if got := g.Key("The Inn"); got != "the wandering inn" {
+		t.Errorf("expected The Inn to key as the wandering inn, got %q", got)
+	}
+	if got := g.Key("[Bloodfields]"); got != "blood fields" {
+		t.Errorf("expected Bloodfields to key as blood fields, got %q", got)
+	}
+	if !g.earth["london"] || g.earth["liscor"] {
+		t.Error("expected london excluded as Earth and liscor kept")
+	}
+	for _, id := range []string{"liscor", "the inn", "market street", "northern antinium hive"} {
+		if !g.matchesSeed(id) {
+			t.Errorf("expected %q to match a seed", id)
+		}
+	}
+	if g.matchesSeed("some cave") {
+		t.Error("expected an unknown place not to match a seed")
+	}
+	seeds := g.seeds()
+	if c := seeds["liscor"]; c.X != 240 || c.Y != -20 || c.Confidence != "estimated" {
+		t.Errorf("expected liscor seeded at (240, -20), got %+v", c)
+	}
+	if inn, canon := seeds["inn"], seeds["the wandering inn"]; inn.X != canon.X || inn.Y != canon.Y {
+		t.Error("expected aliases seeded at their place's position")
+	}
+}
+
+func TestGazetteerValidate(t *testing.T) {
+	_, err := ParseGazetteer(`
+version = 2
+earth = ["london", "liscor"]
+seed_keywords = ["izril", "izril"]
+
+[[place]]
+name = "liscor"
+aliases = ["the walled city", "the walled city"]
+position = [1, 2, 3]
+
+[[place]]
+name = "pallass"
+aliases = ["The Walled City"]
+confidence = "sure"
+
+[[place]]
+name = "Liscor"
+`)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"version 2 is not supported",
+		`earth name "liscor" is also place "liscor"`,
+		`seed_keywords lists "izril" twice`,
+		`place "liscor" lists "the walled city" twice`,
+		`"the walled city" is claimed by both "liscor" and "pallass"`,
+		`position must be [x, y]`,
+		`confidence "sure" is not one of`,
+		`confidence set without a position`,
+		`place "liscor" is listed twice`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q in:\n%v", want, err)
+		}
+	}
+
+	if _, err := ParseGazetteer("version = 1\nplaces = []\n"); err == nil || !strings.Contains(err.Error(), `unknown key "places"`) {
+		t.Errorf("expected an unknown key error, got %v", err)
+	}
+}
+
+func TestLoadGazetteer(t *testing.T) {
+	if g, err := LoadGazetteer(""); err != nil || g != DefaultGazetteer() {
+		t.Fatalf("expected the built-in gazetteer for an empty path, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "gazetteer.toml")
+	os.WriteFile(path, []byte(`
+version = 1
+
+[[place]]
+name = "liscor"
+aliases = ["the city of liscor"]
+position = [10, 20]
+confidence = "high"
+`), 0o644)
+	g, err := LoadGazetteer(path)
+	if err != nil {
+		t.Fatalf("loading gazetteer: %v", err)
+	}
+	if g.Key("The City of Liscor") != "liscor" || g.Key("The Inn") != "the inn" {
+		t.Error("expected only the file's aliases to apply")
+	}
+	if c := g.seeds()["liscor"]; c.X != 10 || c.Confidence != "high" {
+		t.Errorf("expected the file's seed for liscor, got %+v", c)
+	}
+	if _, err := LoadGazetteer(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("expected an error for a missing gazetteer file")
+	}
+}
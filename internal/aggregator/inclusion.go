@@ -0,0 +1,261 @@
+package aggregator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// Traceability modes for InclusionPolicy.Traceability: how a location must
+// connect to a known position to be placed on the map.
+const (
+	// TraceStrict requires a gazetteer place, or a containment chain up to one.
+	TraceStrict = "strict"
+	// TraceKeyword also accepts names containing a seed keyword ("human",
+	// "drake"), directly or up the containment chain.
+	TraceKeyword = "keyword"
+	// TraceOff places every location.
+	TraceOff = "off"
+)
+
+var traceModes = []string{TraceStrict, TraceKeyword, TraceOff}
+
+// Inclusion rules recorded in model.InclusionDecision.Rule.
+const (
+	IncludeListed      = "include_list"
+	IncludeMentions    = "mentions"
+	IncludeProvenance  = "provenance"
+	ExcludeListed      = "exclude_list"
+	ExcludeUntraceable = "untraceable"
+	ExcludeFewMentions = "too_few_mentions"
+)
+
+// InclusionPolicy decides which aggregated locations make it onto the map.
+// A location is included if it is listed in Include, or if it is traceable
+// and either mentioned often enough for its type or related to a location
+// that is. Locations listed in Exclude never are.
+type InclusionPolicy struct {
+	MinMentions       int
+	MinMentionsByType map[model.LocationType]int
+	Traceability      string
+	Include, Exclude  []string
+}
+
+// DefaultInclusionPolicy returns the policy used when none is configured.
+func DefaultInclusionPolicy() *InclusionPolicy {
+	return &InclusionPolicy{MinMentions: 3, Traceability: TraceKeyword}
+}
+
+// Validate reports every problem with the policy.
+func (p *InclusionPolicy) Validate() error {
+	var errs []error
+	if p.MinMentions < 0 {
+		errs = append(errs, fmt.Errorf("min_mentions must not be negative, got %d", p.MinMentions))
+	}
+	types := make([]model.LocationType, 0, len(p.MinMentionsByType))
+	for t := range p.MinMentionsByType {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	for _, t := range types {
+		if !slices.Contains(model.LocationTypes, t) {
+			errs = append(errs, fmt.Errorf("min_mentions_by_type: %q is not a location type", t))
+		}
+		if n := p.MinMentionsByType[t]; n < 0 {
+			errs = append(errs, fmt.Errorf("min_mentions_by_type: %s must not be negative, got %d", t, n))
+		}
+	}
+	if !slices.Contains(traceModes, p.Traceability) {
+		errs = append(errs, fmt.Errorf("traceability %q is not one of %s", p.Traceability, strings.Join(traceModes, ", ")))
+	}
+	for _, name := range p.Include {
+		if containsNorm(p.Exclude, name) {
+			errs = append(errs, fmt.Errorf("%q is both included and excluded", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// minMentions is how many mentions a location of type t needs.
+func (p *InclusionPolicy) minMentions(t model.LocationType) int {
+	if n, ok := p.MinMentionsByType[t]; ok {
+		return n
+	}
+	return p.MinMentions
+}
+
+// trace reports whether id can be placed on the map under the policy's
+// traceability mode, and how.
+func (p *InclusionPolicy) trace(id string, parentOf map[string]string, g *Gazetteer) (bool, string) {
+	if p.Traceability == TraceOff {
+		return true, "traceability is off"
+	}
+	cur := id
+	for range maxContainmentDepth + 1 {
+		if why := p.seedReason(cur, g); why != "" {
+			if cur == id {
+				return true, why
+			}
+			return true, fmt.Sprintf("lies within %q, which %s", cur, why)
+		}
+		parent, ok := parentOf[cur]
+		if !ok {
+			break
+		}
+		cur = parent
+	}
+	if p.Traceability == TraceKeyword {
+		return false, "no gazetteer place or seed keyword in its name or containment chain"
+	}
+	return false, "no gazetteer place in its containment chain"
+}
+
+// seedReason explains why id is placeable by itself, or returns "".
+func (p *InclusionPolicy) seedReason(id string, g *Gazetteer) string {
+	if g.placeable[id] {
+		return "is a gazetteer place"
+	}
+	if p.Traceability == TraceKeyword {
+		for _, kw := range g.SeedKeywords {
+			if strings.Contains(id, kw) {
+				return fmt.Sprintf("contains seed keyword %q", kw)
+			}
+		}
+	}
+	return ""
+}
+
+// decide applies the policy to every location in locMap, returning a
+// decision for each. rels and cont link locations for provenance and
+// containment tracing.
+func (p *InclusionPolicy) decide(locMap map[string]*locEntry, rels []model.AggregatedRelationship, cont []model.Containment, g *Gazetteer) map[string]model.InclusionDecision {
+	parentOf := make(map[string]string)
+	for _, c := range cont {
+		parentOf[normalizeName(c.Child)] = normalizeName(c.Parent)
+	}
+	include, exclude := make(map[string]bool), make(map[string]bool)
+	for _, name := range p.Include {
+		include[g.Key(name)] = true
+	}
+	for _, name := range p.Exclude {
+		exclude[g.Key(name)] = true
+	}
+
+	out := make(map[string]model.InclusionDecision, len(locMap))
+	traced := make(map[string]string)
+	for id, entry := range locMap {
+		d := model.InclusionDecision{ID: id}
+		ok, why := p.trace(id, parentOf, g)
+		traced[id] = why
+		need := p.minMentions(entry.loc.Type)
+		switch {
+		case exclude[id]:
+			d.Rule, d.Detail = ExcludeListed, "listed under exclude"
+		case include[id]:
+			d.Included, d.Rule, d.Detail = true, IncludeListed, "listed under include"
+		case !ok:
+			d.Rule, d.Detail = ExcludeUntraceable, why
+		case entry.loc.MentionCount >= need:
+			d.Included, d.Rule = true, IncludeMentions
+			d.Detail = fmt.Sprintf("%d mentions (a %s needs %d) and it %s", entry.loc.MentionCount, typeName(entry.loc.Type), need, why)
+		default:
+			d.Rule = ExcludeFewMentions
+			d.Detail = fmt.Sprintf("%d mentions, but a %s needs %d", entry.loc.MentionCount, typeName(entry.loc.Type), need)
+		}
+		out[id] = d
+	}
+
+	// Locations short of their mention threshold are still included when
+	// related to one that made it, as provenance for that relationship.
+	for _, rel := range rels {
+		from, to := normalizeName(rel.From), normalizeName(rel.To)
+		for _, end := range [][2]string{{from, to}, {to, from}} {
+			est, other := out[end[0]], end[1]
+			d, ok := out[other]
+			if !est.Included || est.Rule == IncludeProvenance || !ok || d.Rule != ExcludeFewMentions {
+				continue
+			}
+			loc := locMap[other].loc
+			d.Included, d.Rule = true, IncludeProvenance
+			d.Detail = fmt.Sprintf("%d mentions (a %s needs %d), but related to %q (%s) and it %s",
+				loc.MentionCount, typeName(loc.Type), p.minMentions(loc.Type), end[0], rel.Type, traced[other])
+			out[other] = d
+		}
+	}
+	return out
+}
+
+// typeName is how a location type reads in an explanation.
+func typeName(t model.LocationType) string {
+	if t == "" {
+		return "untyped location"
+	}
+	return strings.ReplaceAll(string(t), "_", " ")
+}
+
+// Explain describes which rule put the named location on the map or kept it
+// off, following merges and the non-location filter, for data returned by
+// Aggregate.
+func Explain(data *model.AggregatedData, g *Gazetteer, name string) string {
+	if g == nil {
+		g = DefaultGazetteer()
+	}
+	id := g.Key(name)
+	var b strings.Builder
+	if id != normalizeName(name) {
+		fmt.Fprintf(&b, "%q is a gazetteer alias of %q.\n", name, id)
+	}
+
+	seen := map[string]bool{}
+	for !seen[id] {
+		seen[id] = true
+		for _, d := range data.Inclusion {
+			if d.ID != id {
+				continue
+			}
+			verdict := "excluded"
+			if d.Included {
+				verdict = "included"
+			}
+			fmt.Fprintf(&b, "%q is %s by rule %s: %s.\n", id, verdict, d.Rule, d.Detail)
+			return b.String()
+		}
+		for _, r := range data.Rejected {
+			if r.ID == id {
+				fmt.Fprintf(&b, "%q is excluded as a non-location (%s)", id, r.Reason)
+				if r.Detail != "" {
+					fmt.Fprintf(&b, ": %s", r.Detail)
+				}
+				b.WriteString(".\n")
+				return b.String()
+			}
+		}
+		merged := false
+		for _, m := range data.Merges {
+			if m.Merged && m.ID != id && (m.A == id || m.B == id) {
+				fmt.Fprintf(&b, "%q was merged into %q (%s): %s.\n", id, m.ID, m.Reason, m.Detail)
+				id, merged = m.ID, true
+				break
+			}
+		}
+		if !merged {
+			break
+		}
+	}
+	fmt.Fprintf(&b, "%q was never extracted as a location.\n", id)
+	return b.String()
+}
+
+// sortedDecisions lists decisions by ID.
+func sortedDecisions(m map[string]model.InclusionDecision) []model.InclusionDecision {
+	out := make([]model.InclusionDecision, 0, len(m))
+	for _, d := range m {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
@@ -0,0 +1,166 @@
+package aggregator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+func TestInclusionPolicyDecide(t *testing.T) {
+	locMap := map[string]*locEntry{}
+	for _, e := range []*locEntry{
+		testEntry("Liscor", model.LocationCity, 5),
+		testEntry("Market Street", model.LocationRoad, 1),
+		testEntry("Drake Outpost", model.LocationBuilding, 4),
+		testEntry("Octavia's Shop", model.LocationBuilding, 1),
+		testEntry("Floodplains", model.LocationLandmark, 2),
+		testEntry("Vellmoor Crag", model.LocationLandmark, 6),
+		testEntry("The Hidden Cave", model.LocationDungeon, 9),
+	} {
+		locMap[e.loc.ID] = e
+	}
+	rels := []model.AggregatedRelationship{{From: "Octavia's Shop", To: "Liscor", Type: model.RelContainment}}
+	cont := []model.Containment{
+		{Child: "Octavia's Shop", Parent: "Liscor"},
+		{Child: "Floodplains", Parent: "Liscor"},
+	}
+	g := DefaultGazetteer()
+
+	tests := []struct {
+		name   string
+		policy *InclusionPolicy
+		want   map[string]string
+	}{
+		{"default", DefaultInclusionPolicy(), map[string]string{
+			"liscor":          IncludeMentions,
+			"market street":   ExcludeFewMentions,
+			"drake outpost":   IncludeMentions,
+			"octavia's shop":  IncludeProvenance,
+			"floodplains":     ExcludeFewMentions,
+			"vellmoor crag":   ExcludeUntraceable,
+			"the hidden cave": ExcludeUntraceable,
+		}},
+		{"strict with type thresholds", &InclusionPolicy{
+			MinMentions: 3, Traceability: TraceStrict,
+			MinMentionsByType: map[model.LocationType]int{model.LocationLandmark: 2, model.LocationRoad: 1},
+		}, map[string]string{
+			"liscor":          IncludeMentions,
+			"market street":   IncludeMentions,
+			"drake outpost":   ExcludeUntraceable,
+			"octavia's shop":  IncludeProvenance,
+			"floodplains":     IncludeMentions,
+			"vellmoor crag":   ExcludeUntraceable,
+			"the hidden cave": ExcludeUntraceable,
+		}},
+		{"off with lists", &InclusionPolicy{
+			MinMentions: 3, Traceability: TraceOff,
+			Include: []string{"Market Street"}, Exclude: []string{"the hidden cave", "Liscor"},
+		}, map[string]string{
+			"liscor":          ExcludeListed,
+			"market street":   IncludeListed,
+			"drake outpost":   IncludeMentions,
+			"octavia's shop":  ExcludeFewMentions,
+			"floodplains":     ExcludeFewMentions,
+			"vellmoor crag":   IncludeMentions,
+			"the hidden cave": ExcludeListed,
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := make(map[string]string)
+		for id, d := range tt.policy.decide(locMap, rels, cont, g) {
+			got[id] = d.Rule
+			if wantIn := slices.Contains([]string{IncludeListed, IncludeMentions, IncludeProvenance}, d.Rule); d.Included != wantIn {
+				t.Errorf("%s: %s has rule %s but included=%v", tt.name, id, d.Rule, d.Included)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: expected rules %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestInclusionPolicyValidate(t *testing.T) {
+	p := &InclusionPolicy{
+		MinMentions:       -1,
+		MinMentionsByType: map[model.LocationType]int{"castle": 2, model.LocationCity: -2},
+		Traceability:      "loose",
+		Include:           []string{"Liscor"},
+		Exclude:           []string{"liscor"},
+	}
+	err := p.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"min_mentions must not", `"castle" is not a location type`, "city must not be negative", `traceability "loose"`, `"Liscor" is both`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-explain")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 3 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	for i := range 3 {
+		ext := &model.ChapterExtraction{
+			ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01",
+			Locations: []model.ExtractedLocation{
+				{Name: "Liscor", Type: "city"},
+				{Name: "The City of Liscor", Type: "city", Aliases: []string{"Liscor"}},
+				{Name: "Vellmoor Crag", Type: "landmark"},
+				{Name: "the kitchen", Type: "building"},
+			},
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	data, err := Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	tests := []struct{ name, want string }{
+		{"Liscor", `"liscor" is included by rule mentions: 3 mentions (a city needs 3) and it is a gazetteer place.`},
+		{"The City of Liscor", `"the city of liscor" was merged into "liscor" (alias)`},
+		{"Vellmoor Crag", `"vellmoor crag" is excluded by rule untraceable`},
+		{"the kitchen", `"the kitchen" is excluded as a non-location (uncapitalized)`},
+		{"Zeres", `"zeres" was never extracted`},
+	}
+	for _, tt := range tests {
+		if got := Explain(data, nil, tt.name); !strings.Contains(got, tt.want) {
+			t.Errorf("Explain(%q) = %q, want it to contain %q", tt.name, got, tt.want)
+		}
+	}
+	if got := Explain(data, nil, "The City of Liscor"); !strings.Contains(got, `"liscor" is included`) {
+		t.Errorf("expected the explanation to follow the merge, got %q", got)
+	}
+
+	if _, err := Aggregate(s, Options{Inclusion: &InclusionPolicy{Traceability: "sometimes"}}); err == nil {
+		t.Error("expected an invalid inclusion policy to fail aggregation")
+	}
+}
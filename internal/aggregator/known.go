@@ -0,0 +1,114 @@
+package aggregator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+// KnownIndex answers which locations had been named before a chapter. It is
+// built from the per-chapter extractions rather than the last aggregation,
+// whose names, types, and inclusion decisions draw on every chapter, so
+// nothing it reports was learned later in the story. It is safe for
+// concurrent use.
+type KnownIndex struct {
+	g   *Gazetteer
+	cls *classifier
+
+	mu       sync.RWMutex
+	chapters []*model.ChapterExtraction // in chapter order
+}
+
+// NewKnownIndex loads every stored extraction. Only opts.Filter and
+// opts.Gazetteer are used.
+func NewKnownIndex(s *store.Store, opts Options) (*KnownIndex, error) {
+	toc, err := s.ReadTOC()
+	if err != nil {
+		return nil, fmt.Errorf("reading TOC: %w", err)
+	}
+	g := opts.Gazetteer
+	if g == nil {
+		g = DefaultGazetteer()
+	}
+	k := &KnownIndex{g: g, cls: newClassifier(opts.Filter, g)}
+	for _, ch := range toc.Chapters {
+		if !s.ExtractionExists(ch.Index) {
+			continue
+		}
+		ext, err := s.ReadExtraction(ch.Index)
+		if err != nil {
+			continue
+		}
+		k.chapters = append(k.chapters, ext)
+	}
+	sort.Slice(k.chapters, func(i, j int) bool { return k.chapters[i].ChapterIndex < k.chapters[j].ChapterIndex })
+	return k, nil
+}
+
+// Add records a chapter's extraction, replacing any earlier one of the same
+// chapter, so that it is reported for the chapters after it.
+func (k *KnownIndex) Add(ext *model.ChapterExtraction) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	i := sort.Search(len(k.chapters), func(i int) bool { return k.chapters[i].ChapterIndex >= ext.ChapterIndex })
+	if i < len(k.chapters) && k.chapters[i].ChapterIndex == ext.ChapterIndex {
+		k.chapters[i] = ext
+		return
+	}
+	k.chapters = slices.Insert(k.chapters, i, ext)
+}
+
+// Before returns the locations extracted from chapters before chapterIdx,
+// in order of first appearance. Each is named and typed, and its chapters
+// listed, from those chapters alone; names that aren't places are left out.
+func (k *KnownIndex) Before(chapterIdx int) []model.AggregatedLocation {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	entries := make(map[string]*locEntry)
+	for _, ext := range k.chapters {
+		if ext.ChapterIndex >= chapterIdx {
+			break
+		}
+		for _, loc := range ext.Locations {
+			key := k.g.Key(loc.Name)
+			entry, ok := entries[key]
+			if !ok {
+				entry = &locEntry{
+					loc:     model.AggregatedLocation{ID: key, Name: toDisplayName(key), FirstChapterIndex: ext.ChapterIndex},
+					indices: make(map[int]bool),
+					types:   typeVotes{},
+				}
+				entries[key] = entry
+			}
+			entry.indices[ext.ChapterIndex] = true
+			entry.spellings = append(entry.spellings, strings.TrimSpace(loc.Name))
+			entry.types.add(loc.Type, ext.ChapterIndex, loc.Agreement)
+		}
+	}
+
+	var locs []model.AggregatedLocation
+	for key, entry := range entries {
+		if k.cls.classify(key, entry.spellings) != "" {
+			continue
+		}
+		for idx := range entry.indices {
+			entry.loc.ChapterIndices = append(entry.loc.ChapterIndices, idx)
+		}
+		sort.Ints(entry.loc.ChapterIndices)
+		entry.loc.MentionCount = len(entry.loc.ChapterIndices)
+		entry.loc.Type = entry.types.winner()
+		locs = append(locs, entry.loc)
+	}
+	sort.Slice(locs, func(i, j int) bool {
+		if locs[i].FirstChapterIndex != locs[j].FirstChapterIndex {
+			return locs[i].FirstChapterIndex < locs[j].FirstChapterIndex
+		}
+		return locs[i].ID < locs[j].ID
+	})
+	return locs
+}
@@ -0,0 +1,132 @@
+package aggregator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+func TestKnownIndexBefore(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-known")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 5 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	// The Walled City is only revealed to be Liscor in chapter 3, and Celum
+	// is only typed a city from chapter 2 on.
+	chapters := [][]model.ExtractedLocation{
+		{{Name: "Walled City", Type: "city"}, {Name: "Celum", Type: "other"}, {Name: "Erin", Type: "other"}},
+		{{Name: "Walled City", Type: "city"}, {Name: "Celum", Type: "other"}},
+		{{Name: "Celum", Type: "city"}, {Name: "Celum", Type: "city"}},
+		{{Name: "Liscor", Type: "city", Aliases: []string{"Walled City"}}, {Name: "Celum", Type: "city"}},
+		{{Name: "Liscor", Type: "city"}, {Name: "Celum", Type: "city"}},
+	}
+	for i, locs := range chapters {
+		ext := &model.ChapterExtraction{ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01", Locations: locs}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	// The full aggregation renames the Walled City and retypes Celum; the
+	// index must not.
+	data, err := Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	for _, loc := range data.Locations {
+		if loc.ID == "walled city" {
+			t.Fatalf("expected the aggregation to merge the Walled City into Liscor, got %+v", data.Locations)
+		}
+	}
+
+	idx, err := NewKnownIndex(s, Options{Filter: &NameFilter{Deny: []string{"Erin"}}})
+	if err != nil {
+		t.Fatalf("building index: %v", err)
+	}
+	got := make(map[string]model.AggregatedLocation)
+	for _, loc := range idx.Before(2) {
+		got[loc.ID] = loc
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected the Walled City and Celum before chapter 2, got %+v", got)
+	}
+	if wc := got["walled city"]; wc.Name != "Walled City" || wc.MentionCount != 2 {
+		t.Errorf("expected the Walled City under its early name, got %+v", wc)
+	}
+	if celum := got["celum"]; celum.Type != model.LocationOther || len(celum.ChapterIndices) != 2 || celum.TypeVotes != nil {
+		t.Errorf("expected Celum typed by chapters 0-1 only, got %+v", celum)
+	}
+	if _, ok := got["liscor"]; ok {
+		t.Error("expected Liscor unknown before chapter 3")
+	}
+
+	after := idx.Before(5)
+	if len(after) != 3 || after[0].ID != "celum" || after[0].Type != model.LocationCity || after[0].MentionCount != 5 {
+		t.Errorf("expected later chapters to count once reached, got %+v", after)
+	}
+	if got := idx.Before(0); len(got) != 0 {
+		t.Errorf("expected nothing known before the first chapter, got %+v", got)
+	}
+}
+
+func TestKnownIndexAdd(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-known-add")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 3 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	ext := &model.ChapterExtraction{ChapterIndex: 0, Model: "test", ExtractedAt: "2024-01-01", Locations: []model.ExtractedLocation{{Name: "Liscor", Type: "city"}}}
+	if err := s.WriteExtraction(ext); err != nil {
+		t.Fatalf("writing extraction: %v", err)
+	}
+
+	idx, err := NewKnownIndex(s, Options{})
+	if err != nil {
+		t.Fatalf("building index: %v", err)
+	}
+	// Chapters extracted during a run are added as they are saved, and a
+	// re-extraction replaces the chapter's earlier one.
+	idx.Add(&model.ChapterExtraction{ChapterIndex: 2, Locations: []model.ExtractedLocation{{Name: "Pallass", Type: "city"}}})
+	idx.Add(&model.ChapterExtraction{ChapterIndex: 1, Locations: []model.ExtractedLocation{{Name: "Celum", Type: "town"}}})
+	idx.Add(&model.ChapterExtraction{ChapterIndex: 0, Locations: []model.ExtractedLocation{{Name: "Liscor", Type: "city"}, {Name: "Esthelm", Type: "town"}}})
+
+	var ids []string
+	for _, loc := range idx.Before(2) {
+		ids = append(ids, loc.ID)
+	}
+	if fmt.Sprint(ids) != "[esthelm liscor celum]" {
+		t.Errorf("expected chapters 0-1 as added, got %v", ids)
+	}
+	if got := idx.Before(3); len(got) != 4 || got[3].ID != "pallass" || got[0].MentionCount != 1 {
+		t.Errorf("expected chapter 2 reported after it, got %+v", got)
+	}
+}
@@ -0,0 +1,324 @@
+package aggregator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// Merge reasons recorded in model.MergeDecision.Reason. The first two are
+// why locations were merged; the rest are why a merge was refused.
+const (
+	MergeAlias           = "alias"
+	MergeSharedAlias     = "shared_alias"
+	RefuseType           = "type_conflict"
+	RefuseContainment    = "containment_conflict"
+	RefuseAmbiguousAlias = "ambiguous_alias"
+)
+
+// settlementTypes may be merged with one another: the extractor often
+// disagrees on whether a place is a town or a city.
+var settlementTypes = []model.LocationType{model.LocationCity, model.LocationTown, model.LocationVillage}
+
+// unionFind groups location IDs into resolved entities.
+type unionFind map[string]string
+
+func (u unionFind) find(id string) string {
+	for u[id] != "" && u[id] != id {
+		u[id] = u[u[id]] // path halving
+		id = u[id]
+	}
+	return id
+}
+
+func (u unionFind) union(a, b string) {
+	if ra, rb := u.find(a), u.find(b); ra != rb {
+		u[rb] = ra
+	}
+}
+
+// resolver merges locations whose names and extracted aliases overlap.
+type resolver struct {
+	locs      map[string]*locEntry
+	g         *Gazetteer
+	uf        unionFind
+	members   map[string][]string // root -> IDs in its entity
+	parentsOf map[string][]string // ID -> containment parents
+	decisions []model.MergeDecision
+}
+
+// resolveOptions tunes entity resolution.
+type resolveOptions struct {
+	autoMerge, reviewMerge float64
+	reviews                []model.MergeReview
+}
+
+// resolveEntities merges the entries of locMap that are the same place:
+// those that name each other as aliases or share a capitalized alias no
+// other location claims, pairs a reviewer accepted, and pairs whose names
+// and context are similar enough. Merges are refused when the types are
+// incompatible or containment contradicts them, and pairs a reviewer
+// rejected are never merged. locMap is rewritten to hold one entry per
+// resolved location; the returned function maps any pre-merge ID onto its
+// resolved ID. Similar pairs short of opts.autoMerge are returned for review.
+func resolveEntities(locMap map[string]*locEntry, rels []model.AggregatedRelationship, containment []model.Containment, g *Gazetteer, opts resolveOptions) (func(string) string, []model.MergeDecision, []model.MergeCandidate) {
+	r := &resolver{
+		locs:      locMap,
+		g:         g,
+		uf:        make(unionFind),
+		members:   make(map[string][]string),
+		parentsOf: make(map[string][]string),
+	}
+	for _, c := range containment {
+		child, parent := normalizeName(c.Child), normalizeName(c.Parent)
+		r.parentsOf[child] = append(r.parentsOf[child], parent)
+	}
+
+	ids := make([]string, 0, len(locMap))
+	for id := range locMap {
+		ids = append(ids, id)
+		r.members[id] = []string{id}
+	}
+	sort.Strings(ids)
+
+	// An alias that is another location's ID names that location.
+	claims := make(map[string][]string) // alias key -> IDs listing it
+	for _, id := range ids {
+		for _, alias := range locMap[id].loc.Aliases {
+			key := g.Key(alias)
+			if key == id || slices.Contains(claims[key], id) {
+				continue
+			}
+			if _, ok := locMap[key]; ok {
+				r.tryMerge(key, id, key, MergeAlias, fmt.Sprintf("%q lists %q as an alias", id, alias))
+				continue
+			}
+			// Uncapitalized aliases are descriptions ("the walled city"),
+			// which many places share.
+			if strings.IndexFunc(alias, unicode.IsUpper) >= 0 {
+				claims[key] = append(claims[key], id)
+			}
+		}
+	}
+
+	// Other aliases merge locations only when exactly two claim them.
+	aliases := make([]string, 0, len(claims))
+	for alias := range claims {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		switch claimants := claims[alias]; {
+		case len(claimants) == 2:
+			r.tryMerge(claimants[0], claimants[1], alias, MergeSharedAlias, fmt.Sprintf("both list %q as an alias", alias))
+		case len(claimants) > 2:
+			r.decisions = append(r.decisions, model.MergeDecision{
+				A: claimants[0], B: claimants[1], Via: alias, Reason: RefuseAmbiguousAlias,
+				Detail: fmt.Sprintf("%d locations list %q as an alias: %s", len(claimants), alias, strings.Join(claimants, ", ")),
+			})
+		}
+	}
+
+	rejected := r.applyReviews(opts.reviews)
+	candidates := r.fuzzyMatch(neighbors(rels, containment), opts.autoMerge, opts.reviewMerge, rejected)
+
+	resolved := r.collapse()
+	for i := range r.decisions {
+		if d := &r.decisions[i]; d.Merged {
+			d.ID = resolved(d.A)
+		}
+	}
+	return resolved, r.decisions, candidates
+}
+
+// tryMerge merges the entities of a and b if they are compatible, recording
+// the decision either way.
+func (r *resolver) tryMerge(a, b, via, reason, detail string) {
+	ra, rb := r.uf.find(a), r.uf.find(b)
+	if ra == rb {
+		return
+	}
+	d := model.MergeDecision{A: a, B: b, Via: via, Reason: reason, Detail: detail}
+	if why := r.typeConflict(ra, rb); why != "" {
+		d.Reason, d.Detail = RefuseType, why
+	} else if why := r.containmentConflict(ra, rb); why != "" {
+		d.Reason, d.Detail = RefuseContainment, why
+	} else {
+		d.Merged = true
+		r.union(ra, rb)
+	}
+	r.decisions = append(r.decisions, d)
+}
+
+// union joins the entities rooted at ra and rb.
+func (r *resolver) union(ra, rb string) {
+	r.uf.union(ra, rb)
+	r.members[ra] = append(r.members[ra], r.members[rb]...)
+	delete(r.members, rb)
+}
+
+// typeConflict explains why the entities rooted at ra and rb can't be the
+// same kind of place, or returns "".
+func (r *resolver) typeConflict(ra, rb string) string {
+	for _, x := range r.members[ra] {
+		for _, y := range r.members[rb] {
+			tx, ty := r.locs[x].loc.Type, r.locs[y].loc.Type
+			if !typesCompatible(tx, ty) {
+				return fmt.Sprintf("%q is a %s but %q is a %s", x, tx, y, ty)
+			}
+		}
+	}
+	return ""
+}
+
+func typesCompatible(a, b model.LocationType) bool {
+	if a == b || a == "" || b == "" || a == model.LocationOther || b == model.LocationOther {
+		return true
+	}
+	return slices.Contains(settlementTypes, a) && slices.Contains(settlementTypes, b)
+}
+
+// containmentConflict explains why containment rules out merging the
+// entities rooted at ra and rb, or returns "". One can't contain the other,
+// and if both have parents, some parent of one must be the same place as,
+// or lie within or around, a parent of the other.
+func (r *resolver) containmentConflict(ra, rb string) string {
+	xs, ys := r.members[ra], r.members[rb]
+	for _, x := range xs {
+		for _, y := range ys {
+			if r.ancestors(x)[y] {
+				return fmt.Sprintf("%q lies within %q", x, y)
+			}
+			if r.ancestors(y)[x] {
+				return fmt.Sprintf("%q lies within %q", y, x)
+			}
+		}
+	}
+
+	px, py := r.entityParents(xs), r.entityParents(ys)
+	if len(px) == 0 || len(py) == 0 {
+		return ""
+	}
+	for _, p := range px {
+		for _, q := range py {
+			if p == q || r.ancestors(p)[q] || r.ancestors(q)[p] {
+				return ""
+			}
+		}
+	}
+	return fmt.Sprintf("%q lies in %s but %q lies in %s", ra, strings.Join(px, ", "), rb, strings.Join(py, ", "))
+}
+
+// entityParents lists the containment parents of an entity's members that
+// aren't members themselves.
+func (r *resolver) entityParents(members []string) []string {
+	var out []string
+	for _, m := range members {
+		for _, p := range r.parentsOf[m] {
+			if !slices.Contains(members, p) && !slices.Contains(out, p) {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// ancestors returns every place id lies within, up to maxContainmentDepth
+// levels up.
+func (r *resolver) ancestors(id string) map[string]bool {
+	seen := make(map[string]bool)
+	level := []string{id}
+	for range maxContainmentDepth {
+		var next []string
+		for _, c := range level {
+			for _, p := range r.parentsOf[c] {
+				if !seen[p] {
+					seen[p] = true
+					next = append(next, p)
+				}
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		level = next
+	}
+	return seen
+}
+
+// collapse folds each entity's entries into one under its preferred ID and
+// returns the mapping from old IDs to resolved ones.
+func (r *resolver) collapse() func(string) string {
+	rename := make(map[string]string)
+	for _, members := range r.members {
+		if len(members) < 2 {
+			continue
+		}
+		sort.Slice(members, func(i, j int) bool { return r.prefer(members[i], members[j]) })
+		into := r.locs[members[0]]
+		for _, id := range members[1:] {
+			mergeEntry(into, r.locs[id])
+			delete(r.locs, id)
+			rename[id] = members[0]
+		}
+	}
+	return func(id string) string {
+		if to, ok := rename[id]; ok {
+			return to
+		}
+		return id
+	}
+}
+
+// prefer orders an entity's IDs: gazetteer places first, then the most
+// mentioned, then the earliest, so the resolved ID is the most familiar name.
+func (r *resolver) prefer(a, b string) bool {
+	ga, gb := r.g.matchesSeed(a), r.g.matchesSeed(b)
+	if ga != gb {
+		return ga
+	}
+	la, lb := r.locs[a].loc, r.locs[b].loc
+	if la.MentionCount != lb.MentionCount {
+		return la.MentionCount > lb.MentionCount
+	}
+	if la.FirstChapterIndex != lb.FirstChapterIndex {
+		return la.FirstChapterIndex < lb.FirstChapterIndex
+	}
+	return a < b
+}
+
+// mergeEntry folds from into into, keeping into's ID and name, recording
+// from's name as an alias, and settling the type on their combined votes.
+func mergeEntry(into, from *locEntry) {
+	id := into.loc.ID
+	into.spellings = append(into.spellings, from.spellings...)
+	for idx := range from.indices {
+		into.indices[idx] = true
+	}
+	into.loc.MentionCount = len(into.indices)
+	into.loc.FirstChapterIndex = min(into.loc.FirstChapterIndex, from.loc.FirstChapterIndex)
+	into.types.merge(from.types)
+	into.loc.Type = into.types.winner()
+	if n := into.agreed + from.agreed; n > 0 {
+		into.loc.Agreement = (into.loc.Agreement*float64(into.agreed) + from.loc.Agreement*float64(from.agreed)) / float64(n)
+		into.agreed = n
+	}
+	if len(from.loc.Description) > len(into.loc.Description) {
+		into.loc.Description = from.loc.Description
+	}
+	if len(from.loc.VisualDescription) > len(into.loc.VisualDescription) {
+		into.loc.VisualDescription = from.loc.VisualDescription
+	}
+	for _, a := range append([]string{from.loc.Name}, from.loc.Aliases...) {
+		if normalizeName(a) != id && !containsNorm(into.loc.Aliases, a) {
+			into.loc.Aliases = append(into.loc.Aliases, a)
+		}
+	}
+	for idx, rev := range from.revisions {
+		addRevision(into.revisions, id, idx, model.ExtractedLocation{Description: rev.Description, VisualDescription: rev.VisualDescription})
+	}
+}
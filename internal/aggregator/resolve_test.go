@@ -0,0 +1,166 @@
+package aggregator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+// testEntry is a location mentioned once in each of chapters 0 to mentions-1.
+func testEntry(name string, typ model.LocationType, mentions int, aliases ...string) *locEntry {
+	e := &locEntry{
+		loc: model.AggregatedLocation{
+			ID: normalizeName(name), Name: name, Type: typ, Aliases: aliases, MentionCount: mentions,
+		},
+		indices:   make(map[int]bool),
+		revisions: make(map[int]*model.DescriptionRevision),
+		spellings: []string{name},
+		types:     typeVotes{},
+	}
+	for i := range mentions {
+		e.indices[i] = true
+		e.types.add(typ, 0, 0)
+	}
+	return e
+}
+
+func TestResolveAliases(t *testing.T) {
+	locMap := map[string]*locEntry{}
+	for _, e := range []*locEntry{
+		testEntry("Liscor", model.LocationCity, 5),
+		testEntry("the City of Liscor", model.LocationTown, 2, "Liscor"),
+		testEntry("Celum", model.LocationTown, 3, "Human Town"),
+		testEntry("Celum Gate", model.LocationBuilding, 1, "Celum"),
+		testEntry("Esthelm", model.LocationTown, 2, "the town"),
+		testEntry("Remendia", model.LocationTown, 2, "the town"),
+		testEntry("Pallass", model.LocationCity, 4, "City of Invention"),
+		testEntry("Pallass of Chandrar", model.LocationCity, 1, "City of Invention"),
+		testEntry("Hive", model.LocationBuilding, 1, "Liscor's Hive"),
+		testEntry("Antinium Hive", model.LocationBuilding, 1, "Liscor's Hive"),
+	} {
+		locMap[e.loc.ID] = e
+	}
+	locMap["the city of liscor"].revisions[4] = &model.DescriptionRevision{LocationID: "the city of liscor", ChapterIndex: 4, Description: "Walls"}
+	cont := []model.Containment{
+		{Child: "Pallass", Parent: "Izril"},
+		{Child: "Pallass Of Chandrar", Parent: "Chandrar"},
+	}
+
+	// Thresholds above 1 leave fuzzy matching out of it.
+	resolve, merges, _ := resolveEntities(locMap, nil, cont, DefaultGazetteer(), resolveOptions{autoMerge: 2, reviewMerge: 2})
+
+	var ids []string
+	for id := range locMap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	want := "[antinium hive celum celum gate esthelm liscor pallass pallass of chandrar remendia]"
+	if fmt.Sprint(ids) != want {
+		t.Errorf("expected resolved locations %s, got %v", want, ids)
+	}
+	if resolve("the city of liscor") != "liscor" || resolve("celum") != "celum" {
+		t.Error("expected the city of liscor to resolve to liscor")
+	}
+
+	liscor := locMap["liscor"]
+	// The City of Liscor's chapters are all among Liscor's, so count once.
+	if liscor.loc.MentionCount != 5 || liscor.loc.Type != model.LocationCity || !containsNorm(liscor.loc.Aliases, "the City of Liscor") {
+		t.Errorf("expected the merged entry to keep Liscor's type and gain the alias, got %+v", liscor.loc)
+	}
+	if rev := liscor.revisions[4]; rev == nil || rev.LocationID != "liscor" {
+		t.Errorf("expected description revisions moved onto liscor, got %+v", rev)
+	}
+
+	reasons := make(map[string]string)
+	for _, m := range merges {
+		reasons[m.A+"/"+m.B] = fmt.Sprintf("%s %v %s", m.Reason, m.Merged, m.ID)
+	}
+	wantReasons := map[string]string{
+		"liscor/the city of liscor":   "alias true liscor",
+		"celum/celum gate":            "type_conflict false ",
+		"pallass/pallass of chandrar": "containment_conflict false ",
+		"antinium hive/hive":          "shared_alias true antinium hive",
+	}
+	if fmt.Sprint(reasons) != fmt.Sprint(wantReasons) {
+		t.Errorf("expected merge decisions %v, got %v", wantReasons, reasons)
+	}
+}
+
+func TestContainmentConflict(t *testing.T) {
+	r := &resolver{
+		locs:      map[string]*locEntry{},
+		members:   map[string][]string{"a": {"a"}, "b": {"b"}, "c": {"c"}},
+		parentsOf: map[string][]string{"a": {"b"}, "b": {"liscor"}, "c": {"izril"}, "liscor": {"izril"}},
+	}
+	if r.containmentConflict("a", "b") == "" {
+		t.Error("expected a place not to merge with its parent")
+	}
+	if got := r.containmentConflict("b", "c"); got != "" {
+		t.Errorf("expected parents on one chain to be compatible, got %q", got)
+	}
+	r.parentsOf["c"] = []string{"chandrar"}
+	if r.containmentConflict("b", "c") == "" {
+		t.Error("expected parents on different continents to conflict")
+	}
+}
+
+func TestAggregateResolvesAliases(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-resolve")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 3 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	for i := range 3 {
+		ext := &model.ChapterExtraction{
+			ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01",
+			Locations: []model.ExtractedLocation{
+				{Name: "Liscor", Type: "city"},
+				{Name: "The City of Liscor", Type: "city", Aliases: []string{"Liscor"}},
+				{Name: "Izril", Type: "continent"},
+			},
+			Relationships: []model.ExtractedRelationship{
+				{From: "The City of Liscor", To: "Izril", Type: "direction", Detail: "south"},
+				{From: "Liscor", To: "Izril", Type: "direction", Detail: "south"},
+				{From: "Liscor", To: "The City of Liscor", Type: "adjacency"},
+			},
+			Containment: []model.Containment{{Child: "The City of Liscor", Parent: "Izril"}},
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	data, err := Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	if len(data.Locations) != 2 {
+		t.Fatalf("expected liscor and izril, got %+v", data.Locations)
+	}
+	if len(data.Relationships) != 1 || data.Relationships[0].From != "Liscor" {
+		t.Errorf("expected one relationship from the resolved Liscor, got %+v", data.Relationships)
+	}
+	if len(data.Containment) != 1 || data.Containment[0].Child != "Liscor" {
+		t.Errorf("expected containment rewritten onto Liscor, got %+v", data.Containment)
+	}
+	if len(data.Merges) != 1 || data.Merges[0].ID != "liscor" || data.Merges[0].Reason != MergeAlias {
+		t.Errorf("expected one alias merge into liscor, got %+v", data.Merges)
+	}
+}
@@ -0,0 +1,111 @@
+package aggregator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// vagueTypeWeight discounts votes for "other", or no type at all: a place
+// glimpsed in passing is often typed vaguely before a later chapter says
+// what it is, so one specific vote outweighs a few vague ones.
+const vagueTypeWeight = 0.25
+
+// recencyBonusSpan is how many chapters into the story a vote gains one
+// extra vote's weight: a vote's weight grows linearly with its chapter, so
+// later evidence is preferred, as the story tends to say more precisely what
+// a place is the longer it runs, without one late vote overturning a
+// settled majority.
+const recencyBonusSpan = 2000.0
+
+// AmbiguousTypeShare is the share of the weighted type vote below which a
+// location's type is worth a second look.
+const AmbiguousTypeShare = 0.6
+
+// typeVotes tallies the types one location was extracted as.
+type typeVotes map[model.LocationType]*model.TypeVote
+
+// add records one mention's type. Mentions from consensus extractions vote
+// with their agreement; single-run mentions vote with weight 1. Either is
+// scaled up by the mention's chapter; see recencyBonusSpan.
+func (v typeVotes) add(t model.LocationType, chapterIdx int, agreement float64) {
+	w := 1.0
+	if agreement > 0 {
+		w = agreement
+	}
+	if vagueType(t) {
+		w *= vagueTypeWeight
+	}
+	w *= recencyWeight(chapterIdx)
+	vote, ok := v[t]
+	if !ok {
+		vote = &model.TypeVote{Type: t, LastChapterIndex: chapterIdx}
+		v[t] = vote
+	}
+	vote.Mentions++
+	vote.Weight += w
+	vote.LastChapterIndex = max(vote.LastChapterIndex, chapterIdx)
+}
+
+// merge folds other's votes into v.
+func (v typeVotes) merge(other typeVotes) {
+	for t, o := range other {
+		vote, ok := v[t]
+		if !ok {
+			cp := *o
+			v[t] = &cp
+			continue
+		}
+		vote.Mentions += o.Mentions
+		vote.Weight += o.Weight
+		vote.LastChapterIndex = max(vote.LastChapterIndex, o.LastChapterIndex)
+	}
+}
+
+// tally lists the votes winner first: the heaviest, then on a tie the more
+// specific type, then the type voted for most recently. Weights are rescaled
+// so that a plain vote in the latest chapter counts 1.
+func (v typeVotes) tally() []model.TypeVote {
+	out := make([]model.TypeVote, 0, len(v))
+	latest := 0
+	for _, vote := range v {
+		out = append(out, *vote)
+		latest = max(latest, vote.LastChapterIndex)
+	}
+	scale := 1 / recencyWeight(latest)
+	for i := range out {
+		out[i].Weight *= scale
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if math.Abs(a.Weight-b.Weight) > 1e-9 {
+			return a.Weight > b.Weight
+		}
+		if vagueType(a.Type) != vagueType(b.Type) {
+			return !vagueType(a.Type)
+		}
+		if a.LastChapterIndex != b.LastChapterIndex {
+			return a.LastChapterIndex > b.LastChapterIndex
+		}
+		return a.Type < b.Type
+	})
+	return out
+}
+
+// winner is the type the vote settles on, or "" if there were no votes.
+func (v typeVotes) winner() model.LocationType {
+	if len(v) == 0 {
+		return ""
+	}
+	return v.tally()[0].Type
+}
+
+// recencyWeight is the factor by which a vote cast in chapterIdx counts.
+func recencyWeight(chapterIdx int) float64 {
+	return 1 + float64(chapterIdx)/recencyBonusSpan
+}
+
+func vagueType(t model.LocationType) bool {
+	return t == "" || t == model.LocationOther
+}
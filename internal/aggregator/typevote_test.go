@@ -0,0 +1,125 @@
+package aggregator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+	"github.com/intelligrit/twi-map/internal/store"
+)
+
+func TestTypeVotes(t *testing.T) {
+	type mention struct {
+		typ       model.LocationType
+		chapter   int
+		agreement float64
+	}
+	tests := []struct {
+		name     string
+		mentions []mention
+		want     model.LocationType
+	}{
+		{"majority", []mention{{"city", 0, 0}, {"town", 1, 0}, {"city", 2, 0}}, "city"},
+		{"specific beats several vague", []mention{{"other", 0, 0}, {"other", 1, 0}, {"other", 2, 0}, {"city", 3, 0}}, "city"},
+		{"many vague beat one specific", []mention{{"other", 0, 0}, {"other", 1, 0}, {"other", 2, 0}, {"other", 3, 0}, {"other", 4, 0}, {"city", 5, 0}}, "other"},
+		{"later evidence breaks an even split", []mention{{"town", 0, 0}, {"city", 1, 0}, {"town", 2, 0}, {"city", 900, 0}}, "city"},
+		{"one late dissent doesn't overturn a majority", []mention{{"city", 0, 0}, {"city", 1, 0}, {"city", 2, 0}, {"city", 3, 0}, {"city", 4, 0}, {"town", 2000, 0}}, "city"},
+		{"several late votes do", []mention{{"city", 0, 0}, {"city", 1, 0}, {"city", 2, 0}, {"town", 1500, 0}, {"town", 1600, 0}}, "town"},
+		{"tie goes to specific type", []mention{{"city", 1, 0.25}, {"other", 1, 0}}, "city"},
+		{"agreement weighs votes", []mention{{"town", 0, 0.4}, {"town", 1, 0.4}, {"city", 2, 1}}, "city"},
+		{"untyped", []mention{{"", 0, 0}}, ""},
+	}
+	for _, tt := range tests {
+		v := typeVotes{}
+		for _, m := range tt.mentions {
+			v.add(m.typ, m.chapter, m.agreement)
+		}
+		if got := v.winner(); got != tt.want {
+			t.Errorf("%s: winner = %q, want %q (votes %+v)", tt.name, got, tt.want, v.tally())
+		}
+	}
+
+	v := typeVotes{}
+	v.add("city", 2000, 0)
+	v.add("town", 0, 0)
+	if tally := v.tally(); tally[0].Weight != 1 || tally[1].Weight != 0.5 {
+		t.Errorf("expected weights relative to the latest chapter, got %+v", tally)
+	}
+
+	a, b := typeVotes{}, typeVotes{}
+	a.add("town", 0, 0)
+	a.add("town", 4, 0)
+	b.add("town", 2, 0)
+	b.add("city", 3, 0)
+	a.merge(b)
+	tally := a.tally()
+	if len(tally) != 2 || tally[0].Type != "town" || tally[0].Mentions != 3 || tally[0].LastChapterIndex != 4 {
+		t.Errorf("expected merged votes with town first, got %+v", tally)
+	}
+	if b["town"].Mentions != 1 {
+		t.Errorf("expected merge to leave its argument alone, got %+v", b["town"])
+	}
+}
+
+func TestAggregateVotesOnType(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "twi-map-test-agg-types")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatalf("creating store: %v", err)
+	}
+	defer s.Close()
+
+	toc := &model.TOC{}
+	for i := range 4 {
+		toc.Chapters = append(toc.Chapters, model.Chapter{Index: i, WebTitle: fmt.Sprintf("1.0%d", i), Slug: fmt.Sprintf("1-0%d", i), Volume: "vol-1"})
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	// Liscor is first glimpsed as "other"; Celum is split between town and city.
+	types := []struct{ liscor, celum model.LocationType }{
+		{"other", "town"}, {"city", "city"}, {"city", "town"}, {"city", "city"},
+	}
+	for i, ty := range types {
+		ext := &model.ChapterExtraction{
+			ChapterIndex: i, Model: "test", ExtractedAt: "2024-01-01",
+			Locations: []model.ExtractedLocation{{Name: "Liscor", Type: ty.liscor}, {Name: "Celum", Type: ty.celum}},
+		}
+		if err := s.WriteExtraction(ext); err != nil {
+			t.Fatalf("writing extraction %d: %v", i, err)
+		}
+	}
+
+	data, err := Aggregate(s, Options{})
+	if err != nil {
+		t.Fatalf("aggregation failed: %v", err)
+	}
+	got := make(map[string]model.AggregatedLocation)
+	for _, loc := range data.Locations {
+		got[loc.ID] = loc
+	}
+
+	liscor := got["liscor"]
+	if liscor.Type != model.LocationCity {
+		t.Errorf("expected later chapters to correct Liscor to a city, got %q", liscor.Type)
+	}
+	if len(liscor.TypeVotes) != 2 || liscor.TypeVotes[0].Mentions != 3 || liscor.TypeVotes[1].Type != model.LocationOther {
+		t.Errorf("expected Liscor's vote distribution, got %+v", liscor.TypeVotes)
+	}
+	if liscor.TypeShare() < AmbiguousTypeShare {
+		t.Errorf("expected Liscor's type to be settled, share %.2f", liscor.TypeShare())
+	}
+
+	celum := got["celum"]
+	if celum.Type != model.LocationCity {
+		t.Errorf("expected the later chapters to break the even split, got %q", celum.Type)
+	}
+	if celum.TypeShare() >= AmbiguousTypeShare {
+		t.Errorf("expected Celum's type to be ambiguous, share %.2f", celum.TypeShare())
+	}
+}
@@ -8,10 +8,11 @@ import (
 
 // Config holds all user-facing configuration for twi-map.
 type Config struct {
-	Data    DataConfig    `toml:"data"`
-	Server  ServerConfig  `toml:"server"`
-	Extract ExtractConfig `toml:"extract"`
-	Scrape  ScrapeConfig  `toml:"scrape"`
+	Data      DataConfig      `toml:"data"`
+	Server    ServerConfig    `toml:"server"`
+	Extract   ExtractConfig   `toml:"extract"`
+	Scrape    ScrapeConfig    `toml:"scrape"`
+	Aggregate AggregateConfig `toml:"aggregate"`
 }
 
 type DataConfig struct {
@@ -24,21 +25,94 @@ type ServerConfig struct {
 }
 
 type ExtractConfig struct {
-	Model     string `toml:"model"`
-	MaxTokens int    `toml:"max_tokens"`
+	Provider        string `toml:"provider"`
+	BaseURL         string `toml:"base_url"`
+	Model           string `toml:"model"`
+	MaxTokens       int    `toml:"max_tokens"`
+	MaxAttempts     int    `toml:"max_attempts"`
+	TokensPerMinute int    `toml:"tokens_per_minute"`
+	Concurrency     int    `toml:"concurrency"`
+	ChunkChars      int    `toml:"chunk_chars"`
+	ChunkOverlap    int    `toml:"chunk_overlap"`
+	// StructuredOutput requests extractions as a forced tool call. Disable it
+	// for OpenAI-compatible servers without function calling.
+	StructuredOutput bool `toml:"structured_output"`
+	// KnownLocations caps how many locations extracted from earlier
+	// chapters are named in each extraction prompt; 0 disables the list.
+	KnownLocations int `toml:"known_locations"`
+	// Samples runs each chapter this many times and keeps what enough runs
+	// agree on. SampleModels, if set, are cycled through the runs.
+	Samples      int      `toml:"samples"`
+	SampleModels []string `toml:"sample_models"`
+	// Agreement is the share of runs that must find a location or
+	// relationship for a consensus extraction to keep it.
+	Agreement float64 `toml:"agreement"`
+	// Prices maps model names to their per-token prices, for cost estimates
+	// and spend budgets.
+	Prices map[string]ModelPrice `toml:"prices"`
+}
+
+// ModelPrice is a model's price in US dollars per million tokens.
+type ModelPrice struct {
+	Input  float64 `toml:"input"`
+	Output float64 `toml:"output"`
+}
+
+// Cost returns the price in US dollars of the given token counts.
+func (p ModelPrice) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1e6
 }
 
 type ScrapeConfig struct {
 	RateLimit float64 `toml:"rate_limit"`
 }
 
+type AggregateConfig struct {
+	// FilterFile is a TOML file of location names to always allow or deny.
+	FilterFile string `toml:"filter_file"`
+	// Gazetteer is a TOML file of canonical names, seed positions, and Earth
+	// names replacing the built-in one; empty uses the built-in gazetteer.
+	Gazetteer string `toml:"gazetteer"`
+	// AutoMerge is the name similarity at which locations merge without
+	// review; ReviewMerge is where they start being queued for review.
+	AutoMerge   float64 `toml:"auto_merge"`
+	ReviewMerge float64 `toml:"review_merge"`
+	// Inclusion decides which aggregated locations make it onto the map.
+	Inclusion InclusionConfig `toml:"inclusion"`
+}
+
+type InclusionConfig struct {
+	// MinMentions is how many chapters must mention a location for it to be
+	// included; MinMentionsByType overrides it for location types such as
+	// "nation".
+	MinMentions       int            `toml:"min_mentions"`
+	MinMentionsByType map[string]int `toml:"min_mentions_by_type"`
+	// Traceability is "strict", "keyword", or "off".
+	Traceability string `toml:"traceability"`
+	// Include and Exclude name locations to always or never put on the map.
+	Include []string `toml:"include"`
+	Exclude []string `toml:"exclude"`
+}
+
 // Defaults returns a Config populated with built-in default values.
 func Defaults() *Config {
 	return &Config{
 		Data:    DataConfig{Dir: "data"},
 		Server:  ServerConfig{Host: "localhost", Port: 8080},
-		Extract: ExtractConfig{Model: "claude-sonnet-4-20250514", MaxTokens: 64000},
+		Extract: ExtractConfig{Provider: "anthropic", Model: "claude-sonnet-4-20250514", MaxTokens: 64000, MaxAttempts: 5, Concurrency: 1, ChunkChars: 120000, ChunkOverlap: 4000, StructuredOutput: true, Samples: 1, Agreement: 0.5, Prices: defaultPrices()},
 		Scrape:  ScrapeConfig{RateLimit: 1.0},
+		Aggregate: AggregateConfig{FilterFile: "filter.toml", AutoMerge: 0.9, ReviewMerge: 0.75,
+			Inclusion: InclusionConfig{MinMentions: 3, Traceability: "keyword"}},
+	}
+}
+
+// defaultPrices lists current Anthropic prices so estimates work without any
+// [extract.prices] entries. Entries in config.toml are merged over these.
+func defaultPrices() map[string]ModelPrice {
+	return map[string]ModelPrice{
+		"claude-sonnet-4-20250514":  {Input: 3, Output: 15},
+		"claude-opus-4-20250514":    {Input: 15, Output: 75},
+		"claude-3-5-haiku-20241022": {Input: 0.8, Output: 4},
 	}
 }
 
@@ -0,0 +1,270 @@
+// Package eval scores extractions against hand-annotated golden chapters.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// Counts tallies matches for one category of extracted records.
+type Counts struct {
+	Matched   int `json:"matched"`
+	Predicted int `json:"predicted"`
+	Gold      int `json:"gold"`
+}
+
+// Precision is the fraction of predicted records that match a golden one.
+func (c Counts) Precision() float64 { return ratio(c.Matched, c.Predicted) }
+
+// Recall is the fraction of golden records that were predicted.
+func (c Counts) Recall() float64 { return ratio(c.Matched, c.Gold) }
+
+// F1 is the harmonic mean of precision and recall.
+func (c Counts) F1() float64 {
+	p, r := c.Precision(), c.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+func (c *Counts) add(o Counts) {
+	c.Matched += o.Matched
+	c.Predicted += o.Predicted
+	c.Gold += o.Gold
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// Report is the result of scoring a set of chapters. It holds raw counts
+// rather than rates, so reports from different runs can be compared and
+// summed exactly.
+type Report struct {
+	Model         string `json:"model"`
+	PromptHash    string `json:"prompt_hash"`
+	PromptVersion int    `json:"prompt_version"`
+	Source        string `json:"source"` // "live" or "replay"
+	Chapters      []int  `json:"chapters"`
+
+	// Locations counts locations matched by name regardless of type;
+	// LocationTypes requires the type to match as well.
+	Locations     Counts            `json:"locations"`
+	LocationTypes map[string]Counts `json:"location_types"`
+	// NameMatches counts matched locations whose name was exactly the golden
+	// name rather than an alias or variant spelling.
+	NameMatches       int               `json:"name_matches"`
+	Relationships     Counts            `json:"relationships"`
+	RelationshipTypes map[string]Counts `json:"relationship_types"`
+	QuotesVerified    int               `json:"quotes_verified"`
+	Quotes            int               `json:"quotes"`
+}
+
+// NameAccuracy is the fraction of matched locations named exactly as in the
+// golden set.
+func (r *Report) NameAccuracy() float64 { return ratio(r.NameMatches, r.Locations.Matched) }
+
+// QuoteRate is the fraction of predicted quotes found in the chapter text.
+func (r *Report) QuoteRate() float64 { return ratio(r.QuotesVerified, r.Quotes) }
+
+// Add scores pred against gold for one chapter. pred's quotes should already
+// be verified; unverified quotes count against the quote rate.
+func (r *Report) Add(gold, pred *model.ChapterExtraction) {
+	if r.LocationTypes == nil {
+		r.LocationTypes = make(map[string]Counts)
+	}
+	if r.RelationshipTypes == nil {
+		r.RelationshipTypes = make(map[string]Counts)
+	}
+	r.Chapters = append(r.Chapters, gold.ChapterIndex)
+
+	// Resolve every golden name and alias to its golden location.
+	goldByKey := make(map[string]int)
+	for i, loc := range gold.Locations {
+		for _, name := range append([]string{loc.Name}, loc.Aliases...) {
+			if _, dup := goldByKey[matchKey(name)]; !dup {
+				goldByKey[matchKey(name)] = i
+			}
+		}
+	}
+	resolve := func(name string) string {
+		if i, ok := goldByKey[matchKey(name)]; ok {
+			return matchKey(gold.Locations[i].Name)
+		}
+		return matchKey(name)
+	}
+
+	locTypes := make(map[string]Counts)
+	for _, loc := range gold.Locations {
+		c := locTypes[string(loc.Type)]
+		c.Gold++
+		locTypes[string(loc.Type)] = c
+	}
+	matched := make(map[int]bool)
+	for _, loc := range pred.Locations {
+		c := locTypes[string(loc.Type)]
+		c.Predicted++
+		locTypes[string(loc.Type)] = c
+		r.Locations.Predicted++
+
+		i, ok := goldByKey[matchKey(loc.Name)]
+		if !ok {
+			for _, alias := range loc.Aliases {
+				if i, ok = goldByKey[matchKey(alias)]; ok {
+					break
+				}
+			}
+		}
+		if !ok || matched[i] {
+			continue
+		}
+		matched[i] = true
+		r.Locations.Matched++
+		g := gold.Locations[i]
+		if strings.EqualFold(strings.TrimSpace(loc.Name), strings.TrimSpace(g.Name)) {
+			r.NameMatches++
+		}
+		if loc.Type == g.Type {
+			c.Matched++
+			locTypes[string(loc.Type)] = c
+		}
+	}
+	r.Locations.Gold += len(gold.Locations)
+	for t, c := range locTypes {
+		total := r.LocationTypes[t]
+		total.add(c)
+		r.LocationTypes[t] = total
+	}
+
+	relTypes := make(map[string]Counts)
+	goldRels := make(map[string]bool)
+	for _, rel := range gold.Relationships {
+		key := relKey(resolve(rel.From), resolve(rel.To), rel.Type)
+		if goldRels[key] {
+			continue
+		}
+		goldRels[key] = true
+		c := relTypes[string(rel.Type)]
+		c.Gold++
+		relTypes[string(rel.Type)] = c
+	}
+	seen := make(map[string]bool)
+	for _, rel := range pred.Relationships {
+		key := relKey(resolve(rel.From), resolve(rel.To), rel.Type)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		c := relTypes[string(rel.Type)]
+		c.Predicted++
+		if goldRels[key] {
+			c.Matched++
+		}
+		relTypes[string(rel.Type)] = c
+	}
+	for t, c := range relTypes {
+		r.Relationships.add(c)
+		total := r.RelationshipTypes[t]
+		total.add(c)
+		r.RelationshipTypes[t] = total
+	}
+
+	for _, loc := range pred.Locations {
+		r.Quotes += len(loc.ContextQuotes)
+		for _, m := range loc.QuoteMatches {
+			if m.Verified() {
+				r.QuotesVerified++
+			}
+		}
+	}
+	for _, rel := range pred.Relationships {
+		if rel.Quote == "" {
+			continue
+		}
+		r.Quotes++
+		if rel.QuoteMatch.Verified() {
+			r.QuotesVerified++
+		}
+	}
+}
+
+// matchKey reduces a name to lowercase letters and digits without a leading
+// "the", so "The Blood Fields" and "Bloodfields" match.
+func matchKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimPrefix(name, "the ")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, name)
+}
+
+// relKey identifies a relationship by its resolved endpoints and type.
+// Adjacency and routes have no direction, so their endpoints are ordered.
+func relKey(from, to string, t model.RelationshipType) string {
+	if (t == model.RelAdjacency || t == model.RelRoute) && to < from {
+		from, to = to, from
+	}
+	return from + "|" + to + "|" + string(t)
+}
+
+// LoadGolden reads every *.json file in dir as an annotated
+// model.ChapterExtraction, ordered by chapter index.
+func LoadGolden(dir string) ([]*model.ChapterExtraction, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no golden chapters (*.json) in %s", dir)
+	}
+
+	var golden []*model.ChapterExtraction
+	seen := make(map[int]string)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var ext model.ChapterExtraction
+		if err := json.Unmarshal(data, &ext); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if prev, dup := seen[ext.ChapterIndex]; dup {
+			return nil, fmt.Errorf("%s: chapter %d is already annotated in %s", path, ext.ChapterIndex, prev)
+		}
+		seen[ext.ChapterIndex] = path
+		golden = append(golden, &ext)
+	}
+	slices.SortFunc(golden, func(a, b *model.ChapterExtraction) int { return a.ChapterIndex - b.ChapterIndex })
+	return golden, nil
+}
+
+// Regressions lists the headline scores in r that fell more than tolerance
+// below baseline.
+func (r *Report) Regressions(baseline *Report, tolerance float64) []string {
+	var out []string
+	check := func(name string, got, want float64) {
+		if want-got > tolerance {
+			out = append(out, fmt.Sprintf("%s fell from %.3f to %.3f", name, want, got))
+		}
+	}
+	check("location F1", r.Locations.F1(), baseline.Locations.F1())
+	check("relationship F1", r.Relationships.F1(), baseline.Relationships.F1())
+	check("name accuracy", r.NameAccuracy(), baseline.NameAccuracy())
+	check("quote rate", r.QuoteRate(), baseline.QuoteRate())
+	return out
+}
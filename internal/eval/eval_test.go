@@ -0,0 +1,101 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestReportAdd(t *testing.T) {
+	gold := &model.ChapterExtraction{
+		ChapterIndex: 3,
+		Locations: []model.ExtractedLocation{
+			{Name: "Liscor", Type: model.LocationCity},
+			{Name: "Blood Fields", Type: model.LocationLandmark},
+			{Name: "The Wandering Inn", Type: model.LocationBuilding, Aliases: []string{"the inn"}},
+		},
+		Relationships: []model.ExtractedRelationship{
+			{From: "The Wandering Inn", To: "Liscor", Type: model.RelAdjacency},
+			{From: "Liscor", To: "Blood Fields", Type: model.RelDirection},
+		},
+	}
+	verified := model.QuoteMatch{Score: 1}
+	pred := &model.ChapterExtraction{
+		ChapterIndex: 3,
+		Locations: []model.ExtractedLocation{
+			{Name: "Liscor", Type: model.LocationCity, ContextQuotes: []string{"a", "b"}, QuoteMatches: []model.QuoteMatch{verified, {Score: 0.2}}},
+			{Name: "Bloodfields", Type: model.LocationOther}, // variant spelling, wrong type
+			{Name: "The Inn", Type: model.LocationBuilding},  // alias
+			{Name: "Celum", Type: model.LocationCity},        // not annotated
+		},
+		Relationships: []model.ExtractedRelationship{
+			{From: "Liscor", To: "The Inn", Type: model.RelAdjacency, Quote: "c", QuoteMatch: &verified}, // reversed, undirected
+			{From: "Liscor", To: "Bloodfields", Type: model.RelAdjacency},                                // wrong type
+		},
+	}
+
+	var r Report
+	r.Add(gold, pred)
+
+	if r.Locations != (Counts{Matched: 3, Predicted: 4, Gold: 3}) {
+		t.Errorf("unexpected location counts %+v", r.Locations)
+	}
+	if c := r.LocationTypes["city"]; c != (Counts{Matched: 1, Predicted: 2, Gold: 1}) {
+		t.Errorf("unexpected city counts %+v", c)
+	}
+	if c := r.LocationTypes["landmark"]; c.Recall() != 0 || c.Gold != 1 {
+		t.Errorf("expected the mistyped landmark to miss, got %+v", c)
+	}
+	if r.NameMatches != 1 || r.NameAccuracy() != 1.0/3 {
+		t.Errorf("expected only Liscor named exactly, got %d", r.NameMatches)
+	}
+	if r.Relationships != (Counts{Matched: 1, Predicted: 2, Gold: 2}) {
+		t.Errorf("unexpected relationship counts %+v", r.Relationships)
+	}
+	if c := r.RelationshipTypes["direction"]; c.Gold != 1 || c.Matched != 0 {
+		t.Errorf("unexpected direction counts %+v", c)
+	}
+	if r.Quotes != 3 || r.QuotesVerified != 2 {
+		t.Errorf("expected 2 of 3 quotes verified, got %d of %d", r.QuotesVerified, r.Quotes)
+	}
+
+	worse := Report{Locations: Counts{Matched: 1, Predicted: 4, Gold: 3}, Relationships: r.Relationships,
+		NameMatches: r.NameMatches, QuotesVerified: r.QuotesVerified, Quotes: r.Quotes}
+	if regs := worse.Regressions(&r, 0.01); len(regs) != 1 {
+		t.Errorf("expected only a location F1 regression, got %v", regs)
+	}
+	if regs := r.Regressions(&r, 0); len(regs) != 0 {
+		t.Errorf("expected no regressions against itself, got %v", regs)
+	}
+}
+
+func TestLoadGolden(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b.json", `{"chapter_index": 7, "locations": [{"name": "Pallass", "type": "city"}]}`)
+	write("a.json", `{"chapter_index": 2, "locations": []}`)
+	write("notes.txt", "ignored")
+
+	golden, err := LoadGolden(dir)
+	if err != nil {
+		t.Fatalf("loading golden set: %v", err)
+	}
+	if len(golden) != 2 || golden[0].ChapterIndex != 2 || golden[1].Locations[0].Name != "Pallass" {
+		t.Errorf("expected chapters 2 and 7 in order, got %+v", golden)
+	}
+
+	write("c.json", `{"chapter_index": 7}`)
+	if _, err := LoadGolden(dir); err == nil {
+		t.Error("expected an error for a chapter annotated twice")
+	}
+	if _, err := LoadGolden(t.TempDir()); err == nil {
+		t.Error("expected an error for an empty directory")
+	}
+}
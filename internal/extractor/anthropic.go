@@ -0,0 +1,188 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion = "2023-06-01"
+)
+
+// AnthropicProvider calls the Anthropic Messages API.
+type AnthropicProvider struct {
+	APIKey     string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+type apiRequest struct {
+	Model      string         `json:"model"`
+	MaxTokens  int            `json:"max_tokens"`
+	System     string         `json:"system"`
+	Messages   []apiMessage   `json:"messages"`
+	Tools      []apiTool      `json:"tools,omitempty"`
+	ToolChoice *apiToolChoice `json:"tool_choice,omitempty"`
+}
+
+type apiTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type apiToolChoice struct {
+	Type string `json:"type"` // "tool" forces a call to Name
+	Name string `json:"name,omitempty"`
+}
+
+type apiMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type apiResponse struct {
+	Content    []apiContentBlock `json:"content"`
+	StopReason string            `json:"stop_reason"`
+	Usage      apiUsage          `json:"usage"`
+	Error      *apiError         `json:"error,omitempty"`
+}
+
+type apiContentBlock struct {
+	Type  string          `json:"type"` // "text" or "tool_use"
+	Text  string          `json:"text"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+type apiUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type apiError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Complete sends a request to the Messages API.
+func (p *AnthropicProvider) Complete(ctx context.Context, cr CompletionRequest) (*Completion, error) {
+	respBody, err := p.do(ctx, "POST", p.endpoint("/v1/messages"), newAPIRequest(cr))
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResp apiResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	if cr.Tool != nil {
+		return apiResp.completion("") // no prefill was sent
+	}
+	return apiResp.completion(cr.Prefill)
+}
+
+// newAPIRequest converts a backend-neutral request to the Messages API shape.
+// The API rejects an assistant prefill when a tool call is forced, so the
+// prefill is only sent without a Tool.
+func newAPIRequest(cr CompletionRequest) apiRequest {
+	req := apiRequest{
+		Model:     cr.Model,
+		MaxTokens: cr.MaxTokens,
+		System:    cr.System,
+		Messages:  []apiMessage{{Role: "user", Content: cr.User}},
+	}
+	if cr.Tool != nil {
+		req.Tools = []apiTool{{Name: cr.Tool.Name, Description: cr.Tool.Description, InputSchema: cr.Tool.InputSchema}}
+		req.ToolChoice = &apiToolChoice{Type: "tool", Name: cr.Tool.Name}
+	} else if cr.Prefill != "" {
+		req.Messages = append(req.Messages, apiMessage{Role: "assistant", Content: cr.Prefill})
+	}
+	return req
+}
+
+// completion converts a Messages API response, reattaching the prefill the
+// model continued from.
+func (r *apiResponse) completion(prefill string) (*Completion, error) {
+	if r.Error != nil {
+		return nil, &APIError{StatusCode: http.StatusOK, Type: r.Error.Type, Message: r.Error.Message}
+	}
+
+	if len(r.Content) == 0 {
+		return nil, fmt.Errorf("empty response from API")
+	}
+
+	c := &Completion{
+		Usage:      Usage{InputTokens: r.Usage.InputTokens, OutputTokens: r.Usage.OutputTokens},
+		StopReason: r.StopReason,
+	}
+	for _, block := range r.Content {
+		if block.Type == "tool_use" {
+			c.Text, c.Structured = string(block.Input), true
+			return c, nil
+		}
+	}
+
+	// The API returns only the continuation, so prepend the prefill to
+	// reconstruct the full reply.
+	c.Text = prefill + r.Content[0].Text
+	return c, nil
+}
+
+func (p *AnthropicProvider) supportsPrefill() bool { return true }
+
+func (p *AnthropicProvider) supportsTools() bool { return true }
+
+func (p *AnthropicProvider) endpoint(path string) string {
+	return strings.TrimRight(p.BaseURL, "/") + path
+}
+
+// do sends an authenticated request and returns the body of a 200 response.
+// A nil payload sends no body.
+func (p *AnthropicProvider) do(ctx context.Context, method, url string, payload any) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling request: %w", err)
+		}
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		apiErr := newStatusError(resp, respBody)
+		var errResp apiResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != nil {
+			apiErr.Type = errResp.Error.Type
+			apiErr.Message = errResp.Error.Message
+		}
+		return nil, apiErr
+	}
+	return respBody, nil
+}
@@ -0,0 +1,326 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Batcher is implemented by providers that support asynchronous bulk
+// processing. Batched requests are billed at a discount but may take up to a
+// day to complete.
+type Batcher interface {
+	SubmitBatch(ctx context.Context, reqs []BatchRequest) (*Batch, error)
+	GetBatch(ctx context.Context, id string) (*Batch, error)
+	// BatchResults returns one result per request in an ended batch. Result
+	// text is the raw continuation, without the request's prefill.
+	BatchResults(ctx context.Context, b *Batch) ([]BatchResult, error)
+}
+
+// BatchRequest is a single entry in a batch, identified by a caller-chosen ID.
+type BatchRequest struct {
+	CustomID string
+	Request  CompletionRequest
+}
+
+// Batch is the server-side state of a submitted batch.
+type Batch struct {
+	ID               string      `json:"id"`
+	ProcessingStatus string      `json:"processing_status"`
+	RequestCounts    BatchCounts `json:"request_counts"`
+	ResultsURL       string      `json:"results_url"`
+	// Prefills maps each request's custom ID to the prefill it was sent
+	// with, or "" for requests sent with a tool. It is recorded by
+	// Client.SubmitBatch and must be carried over to the batch passed to
+	// CollectBatch; the API does not return it.
+	Prefills map[string]string `json:"-"`
+}
+
+// BatchCounts tallies batch requests by outcome.
+type BatchCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// Ended reports whether the batch has finished and its results can be fetched.
+func (b *Batch) Ended() bool {
+	return b.ProcessingStatus == "ended"
+}
+
+// BatchResult is the outcome of one batch request.
+type BatchResult struct {
+	CustomID   string
+	Completion *Completion
+	Err        error
+}
+
+// BatchChapter is a chapter to include in an extraction batch.
+type BatchChapter struct {
+	Index int
+	Title string
+	Text  string
+	Known []KnownLocation // locations from earlier chapters; may be nil
+}
+
+// BatchOutcome is the extraction result for one chapter of a batch. Texts
+// holds the full reply for each window, ready for ParseChapter.
+type BatchOutcome struct {
+	ChapterIndex int
+	Texts        []string
+	Usage        Usage
+	Err          error
+	// Truncated counts windows whose reply stopped at max_tokens. Batched
+	// requests can't be continued, so these are salvaged by ParseChapter.
+	Truncated   int
+	StopReasons []string // per window, parallel to Texts
+}
+
+const batchIDPrefix = "chapter-"
+
+// SubmitBatch submits the extraction requests for every chapter as a single
+// batch. Each request is identical to what ExtractWindows would send,
+// including the split into windows for long chapters.
+func (c *Client) SubmitBatch(ctx context.Context, chapters []BatchChapter) (*Batch, error) {
+	b, ok := c.Provider.(Batcher)
+	if !ok {
+		return nil, fmt.Errorf("batch mode is not supported by %T", c.Provider)
+	}
+
+	var reqs []BatchRequest
+	prefills := make(map[string]string)
+	for _, ch := range chapters {
+		windows := c.windows(ch.Text)
+		for w, text := range windows {
+			title := ch.Title
+			if len(windows) > 1 {
+				title = fmt.Sprintf("%s (part %d of %d)", ch.Title, w+1, len(windows))
+			}
+			req := BatchRequest{
+				CustomID: fmt.Sprintf("%s%d-%d", batchIDPrefix, ch.Index, w),
+				Request:  c.extractionRequest(title, text, ch.Known),
+			}
+			if req.Request.Tool == nil {
+				prefills[req.CustomID] = req.Request.Prefill
+			} else {
+				prefills[req.CustomID] = ""
+			}
+			reqs = append(reqs, req)
+		}
+	}
+	batch, err := b.SubmitBatch(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	batch.Prefills = prefills
+	return batch, nil
+}
+
+// parseBatchCustomID recovers the chapter index and window number from a
+// custom ID. IDs without a window number come from single-request batches.
+func parseBatchCustomID(id string) (chapterIdx, window int, err error) {
+	rest, ok := strings.CutPrefix(id, batchIDPrefix)
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected custom_id %q", id)
+	}
+	idxStr, winStr, hasWindow := strings.Cut(rest, "-")
+	if chapterIdx, err = strconv.Atoi(idxStr); err != nil {
+		return 0, 0, fmt.Errorf("unexpected custom_id %q", id)
+	}
+	if hasWindow {
+		if window, err = strconv.Atoi(winStr); err != nil {
+			return 0, 0, fmt.Errorf("unexpected custom_id %q", id)
+		}
+	}
+	return chapterIdx, window, nil
+}
+
+// GetBatch refreshes a batch's processing status.
+func (c *Client) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	b, ok := c.Provider.(Batcher)
+	if !ok {
+		return nil, fmt.Errorf("batch mode is not supported by %T", c.Provider)
+	}
+	return b.GetBatch(ctx, id)
+}
+
+// CollectBatch downloads the results of an ended batch and regroups them by
+// chapter, with window replies in order. A chapter fails if any of its
+// windows failed. Each reply is completed with the prefill recorded for its
+// request in batch.Prefills; batches submitted before prefills were
+// recorded are assumed to have been prefilled unless the reply is a tool
+// call.
+func (c *Client) CollectBatch(ctx context.Context, batch *Batch) ([]BatchOutcome, error) {
+	b, ok := c.Provider.(Batcher)
+	if !ok {
+		return nil, fmt.Errorf("batch mode is not supported by %T", c.Provider)
+	}
+
+	results, err := b.BatchResults(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	type windowText struct {
+		window int
+		text   string
+		stop   string
+	}
+	byChapter := make(map[int]*BatchOutcome)
+	windowTexts := make(map[int][]windowText)
+	var order []int
+	for _, r := range results {
+		idx, window, err := parseBatchCustomID(r.CustomID)
+		if err != nil {
+			return nil, fmt.Errorf("batch %s: %w", batch.ID, err)
+		}
+		out, ok := byChapter[idx]
+		if !ok {
+			out = &BatchOutcome{ChapterIndex: idx}
+			byChapter[idx] = out
+			order = append(order, idx)
+		}
+		if r.Err != nil {
+			if out.Err == nil {
+				out.Err = r.Err
+			}
+			continue
+		}
+		out.Usage.InputTokens += r.Completion.Usage.InputTokens
+		out.Usage.OutputTokens += r.Completion.Usage.OutputTokens
+		if r.Completion.StopReason == StopMaxTokens {
+			out.Truncated++
+		}
+		// Prefilled requests return only the continuation.
+		text := r.Completion.Text
+		if prefill, ok := batch.Prefills[r.CustomID]; ok {
+			text = prefill + text
+		} else if batch.Prefills == nil && !r.Completion.Structured {
+			text = extractionPrefill + text
+		}
+		windowTexts[idx] = append(windowTexts[idx], windowText{window, text, r.Completion.StopReason})
+	}
+
+	outcomes := make([]BatchOutcome, 0, len(order))
+	for _, idx := range order {
+		out := byChapter[idx]
+		if out.Err == nil {
+			wts := windowTexts[idx]
+			sort.Slice(wts, func(i, j int) bool { return wts[i].window < wts[j].window })
+			for _, wt := range wts {
+				out.Texts = append(out.Texts, wt.text)
+				out.StopReasons = append(out.StopReasons, wt.stop)
+			}
+		}
+		outcomes = append(outcomes, *out)
+	}
+	return outcomes, nil
+}
+
+type apiBatchRequest struct {
+	Requests []apiBatchEntry `json:"requests"`
+}
+
+type apiBatchEntry struct {
+	CustomID string     `json:"custom_id"`
+	Params   apiRequest `json:"params"`
+}
+
+type apiBatchResultLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string      `json:"type"` // "succeeded", "errored", "canceled", "expired"
+		Message apiResponse `json:"message"`
+		Error   struct {
+			Error *apiError `json:"error"`
+		} `json:"error"`
+	} `json:"result"`
+}
+
+// SubmitBatch creates a Message Batch.
+func (p *AnthropicProvider) SubmitBatch(ctx context.Context, reqs []BatchRequest) (*Batch, error) {
+	payload := apiBatchRequest{Requests: make([]apiBatchEntry, len(reqs))}
+	for i, r := range reqs {
+		payload.Requests[i] = apiBatchEntry{CustomID: r.CustomID, Params: newAPIRequest(r.Request)}
+	}
+
+	respBody, err := p.do(ctx, "POST", p.endpoint("/v1/messages/batches"), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Batch
+	if err := json.Unmarshal(respBody, &b); err != nil {
+		return nil, fmt.Errorf("parsing batch: %w", err)
+	}
+	return &b, nil
+}
+
+// GetBatch retrieves a Message Batch's current status.
+func (p *AnthropicProvider) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	respBody, err := p.do(ctx, "GET", p.endpoint("/v1/messages/batches/"+id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Batch
+	if err := json.Unmarshal(respBody, &b); err != nil {
+		return nil, fmt.Errorf("parsing batch: %w", err)
+	}
+	return &b, nil
+}
+
+// BatchResults downloads the JSONL results of an ended Message Batch.
+func (p *AnthropicProvider) BatchResults(ctx context.Context, b *Batch) ([]BatchResult, error) {
+	if !b.Ended() || b.ResultsURL == "" {
+		return nil, fmt.Errorf("batch %s has not ended (status %q)", b.ID, b.ProcessingStatus)
+	}
+
+	respBody, err := p.do(ctx, "GET", b.ResultsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []BatchResult
+	sc := bufio.NewScanner(bytes.NewReader(respBody))
+	// A single result line carries a whole chapter's extraction.
+	sc.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var rl apiBatchResultLine
+		if err := json.Unmarshal(line, &rl); err != nil {
+			return nil, fmt.Errorf("parsing batch result line: %w", err)
+		}
+
+		r := BatchResult{CustomID: rl.CustomID}
+		switch rl.Result.Type {
+		case "succeeded":
+			// Prefill is reattached by the caller, which knows what was sent.
+			r.Completion, r.Err = rl.Result.Message.completion("")
+		case "errored":
+			if e := rl.Result.Error.Error; e != nil {
+				r.Err = &APIError{Type: e.Type, Message: e.Message}
+			} else {
+				r.Err = fmt.Errorf("request errored")
+			}
+		default:
+			r.Err = fmt.Errorf("request %s", rl.Result.Type)
+		}
+		results = append(results, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading batch results: %w", err)
+	}
+	return results, nil
+}
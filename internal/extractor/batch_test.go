@@ -0,0 +1,174 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeBatchServer emulates the Message Batches endpoints for one batch.
+func fakeBatchServer(t *testing.T, submitted *apiBatchRequest) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/messages/batches", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(submitted); err != nil {
+			t.Errorf("decoding batch request: %v", err)
+		}
+		w.Write([]byte(`{"id":"msgbatch_1","processing_status":"in_progress","request_counts":{"processing":2}}`))
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":"msgbatch_1","processing_status":"ended","request_counts":{"succeeded":1,"errored":1},"results_url":"%s/v1/messages/batches/msgbatch_1/results"}`, srv.URL)
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1/results", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"custom_id":"chapter-3-0","result":{"type":"succeeded","message":{"content":[{"type":"tool_use","name":"record_locations","input":{"locations":[{"name":"Liscor","type":"city"}],"relationships":[],"containment":[]}}],"stop_reason":"tool_use","usage":{"input_tokens":100,"output_tokens":20}}}}
+{"custom_id":"chapter-7-0","result":{"type":"errored","error":{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}}}
+`))
+	})
+	srv = httptest.NewServer(mux)
+	return srv
+}
+
+func TestBatchRoundTrip(t *testing.T) {
+	var submitted apiBatchRequest
+	srv := fakeBatchServer(t, &submitted)
+	defer srv.Close()
+
+	client := NewClient(&AnthropicProvider{APIKey: "k", BaseURL: srv.URL, HTTPClient: srv.Client()}, "test-model", 1000)
+	ctx := context.Background()
+
+	submittedBatch, err := client.SubmitBatch(ctx, []BatchChapter{
+		{Index: 3, Title: "1.03", Text: "Erin walked to Liscor."},
+		{Index: 7, Title: "1.07", Text: "Nothing happened."},
+	})
+	if err != nil {
+		t.Fatalf("submitting batch: %v", err)
+	}
+	if submittedBatch.ID != "msgbatch_1" || submittedBatch.Ended() {
+		t.Errorf("unexpected batch state: %+v", submittedBatch)
+	}
+	if p, ok := submittedBatch.Prefills["chapter-3-0"]; !ok || p != "" {
+		t.Errorf("expected tool requests recorded without a prefill, got %v", submittedBatch.Prefills)
+	}
+	if len(submitted.Requests) != 2 || submitted.Requests[0].CustomID != "chapter-3-0" {
+		t.Fatalf("unexpected submitted requests: %+v", submitted.Requests)
+	}
+	if p := submitted.Requests[0].Params; p.Model != "test-model" || p.System != systemPrompt || len(p.Messages) != 1 || p.ToolChoice == nil {
+		t.Errorf("batch params should match a direct extraction request: %+v", p)
+	}
+
+	b, err := client.GetBatch(ctx, "msgbatch_1")
+	if err != nil {
+		t.Fatalf("getting batch: %v", err)
+	}
+	if !b.Ended() {
+		t.Fatalf("expected batch to have ended, got %q", b.ProcessingStatus)
+	}
+
+	b.Prefills = submittedBatch.Prefills
+	outcomes, err := client.CollectBatch(ctx, b)
+	if err != nil {
+		t.Fatalf("collecting batch: %v", err)
+	}
+	if len(outcomes) != 2 {
+		t.Fatalf("expected 2 outcomes, got %d", len(outcomes))
+	}
+
+	ok := outcomes[0]
+	if ok.ChapterIndex != 3 || ok.Err != nil {
+		t.Fatalf("expected chapter 3 to succeed, got %+v", ok)
+	}
+	if ok.Usage.InputTokens != 100 || ok.Usage.OutputTokens != 20 {
+		t.Errorf("usage mismatch: %+v", ok.Usage)
+	}
+	parsed, err := ParseChapter(ok.Texts)
+	if err != nil {
+		t.Fatalf("parsing collected text: %v", err)
+	}
+	if len(parsed.Locations) != 1 || parsed.Locations[0].Name != "Liscor" {
+		t.Errorf("unexpected parsed locations: %+v", parsed.Locations)
+	}
+
+	if outcomes[1].ChapterIndex != 7 || outcomes[1].Err == nil {
+		t.Errorf("expected chapter 7 to carry an error, got %+v", outcomes[1])
+	}
+}
+
+// cannedBatcher returns fixed results for any batch.
+type cannedBatcher struct {
+	textOnlyProvider
+	results []BatchResult
+}
+
+func (p *cannedBatcher) SubmitBatch(ctx context.Context, reqs []BatchRequest) (*Batch, error) {
+	return &Batch{ID: "b"}, nil
+}
+
+func (p *cannedBatcher) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	return &Batch{ID: id, ProcessingStatus: "ended"}, nil
+}
+
+func (p *cannedBatcher) BatchResults(ctx context.Context, b *Batch) ([]BatchResult, error) {
+	return p.results, nil
+}
+
+func TestCollectBatchPrefills(t *testing.T) {
+	client := NewClient(&cannedBatcher{results: []BatchResult{
+		{CustomID: "chapter-1-0", Completion: &Completion{Text: `"locations":[]}`}},
+		// A tool reply the provider didn't flag as one.
+		{CustomID: "chapter-2-0", Completion: &Completion{Text: ` {"locations":[]}`}},
+	}}, "m", 1000)
+
+	tests := []struct {
+		name     string
+		prefills map[string]string
+		want     []string
+	}{
+		{"recorded", map[string]string{"chapter-1-0": "{", "chapter-2-0": ""}, []string{`{"locations":[]}`, ` {"locations":[]}`}},
+		{"legacy", nil, []string{`{"locations":[]}`, `{ {"locations":[]}`}},
+	}
+	for _, tt := range tests {
+		outcomes, err := client.CollectBatch(context.Background(), &Batch{ID: "b", Prefills: tt.prefills})
+		if err != nil {
+			t.Fatalf("%s: collecting batch: %v", tt.name, err)
+		}
+		for i, out := range outcomes {
+			if len(out.Texts) != 1 || out.Texts[0] != tt.want[i] {
+				t.Errorf("%s: chapter %d texts = %q, want %q", tt.name, out.ChapterIndex, out.Texts, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseBatchCustomID(t *testing.T) {
+	tests := []struct {
+		id              string
+		chapter, window int
+		wantErr         bool
+	}{
+		{"chapter-12", 12, 0, false}, // single-request batches
+		{"chapter-12-3", 12, 3, false},
+		{"chapter-x", 0, 0, true},
+		{"other-1", 0, 0, true},
+	}
+	for _, tt := range tests {
+		idx, w, err := parseBatchCustomID(tt.id)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBatchCustomID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			continue
+		}
+		if idx != tt.chapter || w != tt.window {
+			t.Errorf("parseBatchCustomID(%q) = %d, %d; want %d, %d", tt.id, idx, w, tt.chapter, tt.window)
+		}
+	}
+}
+
+func TestBatchUnsupportedProvider(t *testing.T) {
+	client := NewClient(&OpenAIProvider{BaseURL: "http://localhost"}, "local", 1000)
+	if _, err := client.SubmitBatch(context.Background(), nil); err == nil {
+		t.Fatal("expected error for provider without batch support")
+	}
+}
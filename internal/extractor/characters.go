@@ -0,0 +1,169 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// characterResponse mirrors the JSON structure of a character extraction.
+type characterResponse struct {
+	Sightings []model.CharacterSighting `json:"sightings"`
+	Events    []model.ExtractedEvent    `json:"events"`
+}
+
+// characterTool declares the character extraction response as a tool, as
+// extractionTool does for locations.
+var characterTool = &Tool{
+	Name:        "record_sightings",
+	Description: "Record where named characters are, are travelling to, or left in the chapter, and any named events.",
+	InputSchema: jsonSchema(reflect.TypeOf(characterResponse{})),
+}
+
+// sightingRoleSynonyms maps common near-miss roles onto the
+// model.SightingRole constants. Keys are normalized with normalizeType.
+var sightingRoleSynonyms = invertSynonyms(map[model.SightingRole][]string{
+	model.SightingPresent:      {"at", "in", "arrived", "arrives", "staying", "here"},
+	model.SightingTravellingTo: {"traveling_to", "travelling", "traveling", "heading_to", "en_route", "going_to"},
+	model.SightingDeparted:     {"left", "leaving", "departing", "departs", "fled"},
+})
+
+// ExtractCharacters sends each window of a chapter to the model with the
+// character prompt and returns the raw replies without parsing them.
+func (c *Client) ExtractCharacters(ctx context.Context, chapterTitle, chapterText string) (ChapterResult, error) {
+	return c.runWindows(ctx, chapterTitle, chapterText, c.characterRequest)
+}
+
+func (c *Client) characterRequest(chapterTitle, chapterText string) CompletionRequest {
+	req := CompletionRequest{
+		Model:     c.Model,
+		MaxTokens: c.MaxTokens,
+		System:    characterSystemPrompt,
+		User:      buildCharacterPrompt(chapterTitle, chapterText),
+		Prefill:   extractionPrefill,
+	}
+	if c.useTools() {
+		req.Tool = characterTool
+	}
+	return req
+}
+
+// BuildCharacters parses the raw replies of a character extraction, merging
+// windows in order and dropping sightings without a character or location. Roles are
+// coerced onto model.SightingRoles; sightings with an unknown role are
+// dropped.
+func BuildCharacters(chapterIdx int, modelName, chapterTitle string, texts []string) (*model.ChapterCharacters, error) {
+	out := &model.ChapterCharacters{
+		ChapterIndex: chapterIdx,
+		ChapterTitle: chapterTitle,
+		Model:        modelName,
+		ExtractedAt:  time.Now().UTC().Format(time.RFC3339),
+		PromptHash:   CharacterPromptHash(),
+	}
+	latest := make(map[string]string) // character -> location|role
+	seenEvent := make(map[string]bool)
+	for i, text := range texts {
+		part, err := parseJSON[characterResponse](text)
+		if err != nil {
+			if len(texts) > 1 {
+				return nil, fmt.Errorf("window %d/%d: %w", i+1, len(texts), err)
+			}
+			return nil, err
+		}
+		for _, sg := range part.Sightings {
+			sg.Character, sg.Location = strings.TrimSpace(sg.Character), strings.TrimSpace(sg.Location)
+			role, ok := coerceSightingRole(sg.Role)
+			if sg.Character == "" || sg.Location == "" || !ok {
+				continue
+			}
+			sg.Role = role
+			// Order matters, so only a repeat of the character's latest
+			// sighting, as window overlap produces, is dropped.
+			key := mergeKey(sg.Location) + "|" + string(role)
+			if latest[mergeKey(sg.Character)] != key {
+				latest[mergeKey(sg.Character)] = key
+				out.Sightings = append(out.Sightings, sg)
+			}
+		}
+		for _, ev := range part.Events {
+			ev.Name = strings.TrimSpace(ev.Name)
+			if ev.Name == "" || seenEvent[mergeKey(ev.Name)] {
+				continue
+			}
+			seenEvent[mergeKey(ev.Name)] = true
+			out.Events = append(out.Events, ev)
+		}
+	}
+	return out, nil
+}
+
+// coerceSightingRole maps r onto a known SightingRole.
+func coerceSightingRole(r model.SightingRole) (model.SightingRole, bool) {
+	norm := normalizeType(string(r))
+	if slices.Contains(model.SightingRoles, model.SightingRole(norm)) {
+		return model.SightingRole(norm), true
+	}
+	role, ok := sightingRoleSynonyms[norm]
+	return role, ok
+}
+
+// CharacterPromptHash identifies the character prompt wording, as
+// PromptHash does for the location prompt.
+func CharacterPromptHash() string {
+	sum := sha256.Sum256([]byte(characterSystemPrompt + "\x00" + buildCharacterPrompt("{title}", "{text}")))
+	return hex.EncodeToString(sum[:6])
+}
+
+const characterSystemPrompt = `You are a continuity tracker for "The Wandering Inn" web serial. You analyze chapters and record where named characters are and which named events take place, in structured JSON format.
+
+## Sighting Roles
+- present: The character is at the location during the chapter
+- travelling_to: The character sets out for, or is on the way to, the location
+- departed: The character leaves the location
+
+## Rules
+1. Extract ONLY information explicitly stated in the chapter text
+2. Do NOT include information from your general knowledge about the series
+3. Only record named characters, not unnamed crowds or groups
+4. Use the most specific named location given (a building over its city)
+5. List sightings in the order they happen in the chapter
+6. Events are named or clearly bounded happenings such as battles, sieges, duels, festivals, or disasters, with where they took place
+7. Include a short direct quote from the text supporting each sighting and event`
+
+func buildCharacterPrompt(chapterTitle, chapterText string) string {
+	return `Record where named characters are and the named events in this chapter of "The Wandering Inn".
+
+Chapter: "` + chapterTitle + `"
+
+Respond with ONLY valid JSON in this exact format (no markdown, no explanation):
+{
+  "sightings": [
+    {
+      "character": "Erin Solstice",
+      "location": "The Wandering Inn",
+      "role": "present",
+      "quote": "relevant quote"
+    }
+  ],
+  "events": [
+    {
+      "name": "Battle of the Bloodfields",
+      "location": "Blood Fields",
+      "description": "What happened, based on chapter text",
+      "quote": "relevant quote"
+    }
+  ]
+}
+
+If nothing is found, return: {"sightings": [], "events": []}
+
+--- CHAPTER TEXT ---
+` + chapterText
+}
@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestBuildCharacters(t *testing.T) {
+	texts := []string{
+		`{"sightings":[
+			{"character":"Erin","location":"The Wandering Inn","role":"present"},
+			{"character":"Ryoka","location":"Celum","role":"Traveling To"},
+			{"character":"","location":"Liscor","role":"present"},
+			{"character":"Relc","location":"Liscor","role":"napping"}
+		],"events":[{"name":"Goblin Attack","location":"Liscor","description":"Goblins raid the walls"}]}`,
+		// The second window overlaps the first, then Erin leaves and returns.
+		`Here you go: {"sightings":[
+			{"character":"erin","location":"the wandering inn","role":"present"},
+			{"character":"Erin","location":"The Wandering Inn","role":"left"},
+			{"character":"Erin","location":"The Wandering Inn","role":"present"}
+		],"events":[{"name":"goblin attack","location":"Liscor"}]}`,
+	}
+	got, err := BuildCharacters(5, "test-model", "1.05", texts)
+	if err != nil {
+		t.Fatalf("building characters: %v", err)
+	}
+	if got.ChapterIndex != 5 || got.Model != "test-model" || got.PromptHash != CharacterPromptHash() || got.ExtractedAt == "" {
+		t.Errorf("expected metadata set, got %+v", got)
+	}
+
+	want := []model.CharacterSighting{
+		{Character: "Erin", Location: "The Wandering Inn", Role: model.SightingPresent},
+		{Character: "Ryoka", Location: "Celum", Role: model.SightingTravellingTo},
+		{Character: "Erin", Location: "The Wandering Inn", Role: model.SightingDeparted},
+		{Character: "Erin", Location: "The Wandering Inn", Role: model.SightingPresent},
+	}
+	if len(got.Sightings) != len(want) {
+		t.Fatalf("expected %d sightings, got %+v", len(want), got.Sightings)
+	}
+	for i := range want {
+		if got.Sightings[i] != want[i] {
+			t.Errorf("sighting %d: expected %+v, got %+v", i, want[i], got.Sightings[i])
+		}
+	}
+
+	if len(got.Events) != 1 || got.Events[0].Description != "Goblins raid the walls" {
+		t.Errorf("expected one merged event, got %+v", got.Events)
+	}
+
+	if _, err := BuildCharacters(5, "test-model", "1.05", []string{"no JSON"}); err == nil {
+		t.Error("expected an error for an unparseable reply")
+	}
+}
+
+func TestCharacterToolSchema(t *testing.T) {
+	items := characterTool.InputSchema["properties"].(map[string]any)["sightings"].(map[string]any)["items"].(map[string]any)
+	role := items["properties"].(map[string]any)["role"].(map[string]any)
+	if enum, ok := role["enum"].([]string); !ok || len(enum) != len(model.SightingRoles) {
+		t.Errorf("expected role restricted to the sighting roles, got %v", role)
+	}
+}
@@ -0,0 +1,167 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// paragraphSeparator matches how scraper.ExtractChapterText joins paragraphs.
+const paragraphSeparator = "\n\n"
+
+// splitChapter breaks text into windows of at most maxChars on paragraph
+// boundaries. Each window after the first repeats up to overlapChars of
+// trailing paragraphs from the previous one, so a passage relating two places
+// is never split without one window seeing both. A single paragraph longer
+// than maxChars becomes its own window. maxChars <= 0 disables splitting.
+func splitChapter(text string, maxChars, overlapChars int) []string {
+	if maxChars <= 0 || len(text) <= maxChars {
+		return []string{text}
+	}
+
+	paras := strings.Split(text, paragraphSeparator)
+	var windows []string
+	start := 0
+	for start < len(paras) {
+		end := start
+		size := len(paras[start])
+		for end+1 < len(paras) && size+len(paragraphSeparator)+len(paras[end+1]) <= maxChars {
+			end++
+			size += len(paragraphSeparator) + len(paras[end])
+		}
+		windows = append(windows, strings.Join(paras[start:end+1], paragraphSeparator))
+		if end+1 >= len(paras) {
+			break
+		}
+
+		// Step back over trailing paragraphs for overlap, but always advance.
+		next := end + 1
+		overlap := 0
+		for next-1 > start && overlap+len(paras[next-1]) <= overlapChars {
+			next--
+			overlap += len(paras[next]) + len(paragraphSeparator)
+		}
+		start = next
+	}
+	return windows
+}
+
+// ParseChapter parses the raw response for each window of a chapter, merges
+// them into a single extraction, and validates the result. A window whose
+// JSON was truncated is salvaged element by element and the result marked
+// Partial.
+func ParseChapter(texts []string) (*extractionResponse, error) {
+	parts := make([]*extractionResponse, 0, len(texts))
+	for i, text := range texts {
+		part, err := ParseExtraction(text)
+		if err != nil {
+			if salvaged, serr := salvageExtraction(text); serr == nil {
+				part, err = salvaged, nil
+			}
+		}
+		if err != nil {
+			if len(texts) > 1 {
+				return nil, fmt.Errorf("window %d/%d: %w", i+1, len(texts), err)
+			}
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	merged := mergeExtractions(parts)
+	merged.Warnings = validateExtraction(merged)
+	return merged, nil
+}
+
+// mergeExtractions combines per-window extractions of one chapter, merging
+// locations with the same name and dropping duplicate relationships and
+// containment introduced by window overlap.
+func mergeExtractions(parts []*extractionResponse) *extractionResponse {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+
+	merged := &extractionResponse{}
+	for _, part := range parts {
+		merged.Partial = merged.Partial || part.Partial
+	}
+	locIdx := make(map[string]int)
+	relIdx := make(map[string]int)
+	contSeen := make(map[string]bool)
+
+	for _, part := range parts {
+		for _, loc := range part.Locations {
+			key := mergeKey(loc.Name)
+			i, ok := locIdx[key]
+			if !ok {
+				locIdx[key] = len(merged.Locations)
+				loc.Aliases = appendUnique(nil, loc.Aliases...)
+				loc.ContextQuotes = appendUnique(nil, loc.ContextQuotes...)
+				merged.Locations = append(merged.Locations, loc)
+				continue
+			}
+			mergeLocation(&merged.Locations[i], loc)
+		}
+
+		for _, rel := range part.Relationships {
+			key := mergeKey(rel.From) + "|" + mergeKey(rel.To) + "|" + string(rel.Type)
+			i, ok := relIdx[key]
+			if !ok {
+				relIdx[key] = len(merged.Relationships)
+				merged.Relationships = append(merged.Relationships, rel)
+				continue
+			}
+			if len(rel.Detail) > len(merged.Relationships[i].Detail) {
+				merged.Relationships[i].Detail = rel.Detail
+			}
+			if merged.Relationships[i].Quote == "" {
+				merged.Relationships[i].Quote = rel.Quote
+			}
+		}
+
+		for _, c := range part.Containment {
+			key := mergeKey(c.Child) + "|" + mergeKey(c.Parent)
+			if !contSeen[key] {
+				contSeen[key] = true
+				merged.Containment = append(merged.Containment, c)
+			}
+		}
+	}
+	return merged
+}
+
+// mergeLocation folds a later sighting of the same location into dst.
+func mergeLocation(dst *model.ExtractedLocation, src model.ExtractedLocation) {
+	if dst.Type == model.LocationOther && src.Type != "" {
+		dst.Type = src.Type
+	}
+	if len(src.Description) > len(dst.Description) {
+		dst.Description = src.Description
+	}
+	if len(src.VisualDescription) > len(dst.VisualDescription) {
+		dst.VisualDescription = src.VisualDescription
+	}
+	dst.Aliases = appendUnique(dst.Aliases, src.Aliases...)
+	dst.ContextQuotes = appendUnique(dst.ContextQuotes, src.ContextQuotes...)
+}
+
+// mergeKey normalizes a name for matching within one chapter.
+func mergeKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func appendUnique(dst []string, items ...string) []string {
+	for _, item := range items {
+		dup := false
+		for _, d := range dst {
+			if mergeKey(d) == mergeKey(item) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst = append(dst, item)
+		}
+	}
+	return dst
+}
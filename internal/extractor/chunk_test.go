@@ -0,0 +1,158 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestSplitChapterShort(t *testing.T) {
+	windows := splitChapter("one\n\ntwo", 100, 10)
+	if len(windows) != 1 || windows[0] != "one\n\ntwo" {
+		t.Errorf("expected text unchanged, got %q", windows)
+	}
+	if windows := splitChapter(strings.Repeat("x", 500), 0, 0); len(windows) != 1 {
+		t.Errorf("expected splitting disabled with maxChars=0, got %d windows", len(windows))
+	}
+}
+
+func TestSplitChapterWindows(t *testing.T) {
+	var paras []string
+	for i := range 10 {
+		paras = append(paras, fmt.Sprintf("para%02d-%s", i, strings.Repeat("x", 13))) // 20 chars each
+	}
+	text := strings.Join(paras, paragraphSeparator)
+
+	windows := splitChapter(text, 70, 25)
+	if len(windows) < 2 {
+		t.Fatalf("expected several windows, got %d", len(windows))
+	}
+	for i, w := range windows {
+		if len(w) > 70 {
+			t.Errorf("window %d is %d chars, want <= 70", i, len(w))
+		}
+		if strings.HasPrefix(w, "\n") || strings.HasSuffix(w, "\n") {
+			t.Errorf("window %d not cut on a paragraph boundary: %q", i, w)
+		}
+	}
+
+	// Consecutive windows overlap by one paragraph, and every paragraph is covered.
+	for i := 1; i < len(windows); i++ {
+		prev := strings.Split(windows[i-1], paragraphSeparator)
+		cur := strings.Split(windows[i], paragraphSeparator)
+		if cur[0] != prev[len(prev)-1] {
+			t.Errorf("window %d does not start with the last paragraph of window %d", i, i-1)
+		}
+	}
+	for _, p := range paras {
+		found := false
+		for _, w := range windows {
+			if strings.Contains(w, p) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("paragraph %q missing from all windows", p)
+		}
+	}
+}
+
+func TestSplitChapterOversizedParagraph(t *testing.T) {
+	big := strings.Repeat("y", 200)
+	windows := splitChapter("a\n\n"+big+"\n\nb", 50, 10)
+	if len(windows) != 3 || windows[1] != big {
+		t.Errorf("expected oversized paragraph in its own window, got %d windows", len(windows))
+	}
+}
+
+func TestMergeExtractions(t *testing.T) {
+	a := &extractionResponse{
+		Locations: []model.ExtractedLocation{
+			{Name: "Liscor", Type: "other", Description: "A city", Aliases: []string{"the city"}, ContextQuotes: []string{"q1"}},
+		},
+		Relationships: []model.ExtractedRelationship{
+			{From: "The Wandering Inn", To: "Liscor", Type: "adjacency", Detail: "near"},
+		},
+		Containment: []model.Containment{{Child: "Liscor", Parent: "Izril"}},
+	}
+	b := &extractionResponse{
+		Locations: []model.ExtractedLocation{
+			{Name: "liscor", Type: "city", Description: "A walled Drake city", Aliases: []string{"The City"}, ContextQuotes: []string{"q1", "q2"}},
+			{Name: "Celum", Type: "city"},
+		},
+		Relationships: []model.ExtractedRelationship{
+			{From: "the wandering inn", To: "Liscor", Type: "adjacency", Detail: "just outside the walls"},
+		},
+		Containment: []model.Containment{{Child: "liscor", Parent: "izril"}},
+	}
+
+	merged := mergeExtractions([]*extractionResponse{a, b})
+	if len(merged.Locations) != 2 {
+		t.Fatalf("expected 2 locations, got %d", len(merged.Locations))
+	}
+	liscor := merged.Locations[0]
+	if liscor.Type != "city" {
+		t.Errorf("expected 'other' to be upgraded to 'city', got %q", liscor.Type)
+	}
+	if liscor.Description != "A walled Drake city" {
+		t.Errorf("expected longest description, got %q", liscor.Description)
+	}
+	if len(liscor.Aliases) != 1 || len(liscor.ContextQuotes) != 2 {
+		t.Errorf("expected deduplicated aliases/quotes, got %v / %v", liscor.Aliases, liscor.ContextQuotes)
+	}
+	if len(merged.Relationships) != 1 || merged.Relationships[0].Detail != "just outside the walls" {
+		t.Errorf("expected one relationship with the longer detail, got %+v", merged.Relationships)
+	}
+	if len(merged.Containment) != 1 {
+		t.Errorf("expected 1 containment, got %d", len(merged.Containment))
+	}
+}
+
+func TestExtractChapterChunked(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		var req apiRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Messages[0].Content, "of 2)") {
+			t.Errorf("expected window title in prompt")
+		}
+		input := fmt.Sprintf(`{"locations":[{"name":"Liscor","type":"city"},{"name":"Place%d","type":"town"}],"relationships":[],"containment":[]}`, n)
+		body, _ := json.Marshal(map[string]any{
+			"content": []map[string]any{{"type": "tool_use", "name": extractionToolName, "input": json.RawMessage(input)}},
+			"usage":   map[string]int{"input_tokens": 10, "output_tokens": 5},
+		})
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	client := NewClient(&AnthropicProvider{APIKey: "k", BaseURL: srv.URL, HTTPClient: srv.Client()}, "m", 100)
+	client.ChunkChars = 30
+	client.ChunkOverlap = 0
+
+	text := strings.Repeat("a", 20) + paragraphSeparator + strings.Repeat("b", 20)
+	res, err := client.ExtractWindows(context.Background(), "9.99", text, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := BuildExtraction(NewRawExtraction(0, "m", res.Texts, res.StopReasons, res.Usage), "9.99", "")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if len(res.Texts) != 2 || res.Attempts != 2 {
+		t.Errorf("expected 2 windows / 2 attempts, got %d / %d", len(res.Texts), res.Attempts)
+	}
+	if res.Usage.InputTokens != 20 || res.Usage.OutputTokens != 10 {
+		t.Errorf("expected summed usage, got %+v", res.Usage)
+	}
+	if len(parsed.Locations) != 3 {
+		t.Errorf("expected Liscor deduplicated plus two places, got %+v", parsed.Locations)
+	}
+}
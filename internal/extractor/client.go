@@ -1,131 +1,204 @@
 package extractor
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"strings"
+	"time"
 )
 
-const (
-	anthropicAPI     = "https://api.anthropic.com/v1/messages"
-	anthropicVersion = "2023-06-01"
-)
+// extractionPrefill seeds the assistant reply so the model starts directly
+// with the JSON object.
+const extractionPrefill = "{"
 
-// Client calls the Anthropic Messages API.
+// Client runs chapter extractions against an LLM provider.
 type Client struct {
-	APIKey     string
-	Model      string
-	MaxTokens  int
-	HTTPClient *http.Client
+	Provider  Provider
+	Model     string
+	MaxTokens int
+	Retry     RetryPolicy
+	// Limiter, if set, is shared by every request made through this client.
+	Limiter *TokenLimiter
+	// ChunkChars splits chapters longer than this into overlapping windows
+	// of paragraphs, each extracted separately. Zero disables splitting.
+	ChunkChars   int
+	ChunkOverlap int
+	// MaxContinuations bounds how many times a reply truncated at
+	// max_tokens is continued before falling back to salvage.
+	MaxContinuations int
+	// Structured asks for the extraction as a forced tool call when the
+	// provider supports it, instead of prefilling "{" and parsing free text.
+	Structured bool
 }
 
-// NewClient creates a Client using the ANTHROPIC_API_KEY env var.
-func NewClient(model string, maxTokens int) (*Client, error) {
-	key := os.Getenv("ANTHROPIC_API_KEY")
-	if key == "" {
-		return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
-	}
+// NewClient creates a Client that sends extraction requests through p.
+func NewClient(p Provider, model string, maxTokens int) *Client {
 	return &Client{
-		APIKey:     key,
-		Model:      model,
-		MaxTokens:  maxTokens,
-		HTTPClient: &http.Client{},
-	}, nil
+		Provider:  p,
+		Model:     model,
+		MaxTokens: maxTokens,
+		Retry:     DefaultRetryPolicy(),
+
+		MaxContinuations: 2,
+		Structured:       true,
+	}
 }
 
-type apiRequest struct {
-	Model     string       `json:"model"`
-	MaxTokens int          `json:"max_tokens"`
-	System    string       `json:"system"`
-	Messages  []apiMessage `json:"messages"`
+// ExtractResult is the raw outcome of extracting one chapter.
+type ExtractResult struct {
+	Text     string
+	Usage    Usage
+	Attempts int
+	// StopReason is the stop reason of the final request. StopMaxTokens
+	// means the reply is still truncated after any continuations.
+	StopReason    string
+	Continuations int
 }
 
-type apiMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// run sends req and returns the raw text response. Retryable failures are
+// retried according to c.Retry; Attempts is set even when an error is
+// returned.
+//
+// If the model stops at max_tokens, run asks it to keep going, sending the
+// truncated output back as the assistant prefill, up to c.MaxContinuations
+// times. Providers that can't prefill are not continued.
+func (c *Client) run(ctx context.Context, req CompletionRequest) (ExtractResult, error) {
+	var res ExtractResult
+	resp, err := c.complete(ctx, req, &res)
+	if err != nil {
+		return res, err
+	}
+	res.Text, res.StopReason = resp.Text, resp.StopReason
+
+	// A truncated tool call can't be continued; it is salvaged instead.
+	p, ok := c.Provider.(prefiller)
+	canContinue := ok && p.supportsPrefill() && req.Tool == nil
+	for canContinue && res.StopReason == StopMaxTokens && res.Continuations < c.MaxContinuations {
+		// The API rejects an assistant prefill ending in whitespace.
+		req.Prefill = strings.TrimRight(res.Text, " \t\r\n")
+		res.Continuations++
+
+		resp, err := c.complete(ctx, req, &res)
+		if err != nil {
+			// Keep what we have; the caller can still salvage it.
+			return res, nil
+		}
+		res.Text, res.StopReason = resp.Text, resp.StopReason
+	}
+	return res, nil
 }
 
-type apiResponse struct {
-	Content []apiContentBlock `json:"content"`
-	Usage   apiUsage          `json:"usage"`
-	Error   *apiError         `json:"error,omitempty"`
+// complete sends one request, retrying transient failures, and adds its
+// attempts and token usage to res.
+func (c *Client) complete(ctx context.Context, req CompletionRequest, res *ExtractResult) (*Completion, error) {
+	inputEstimate := estimateTokens(req.System) + estimateTokens(req.User) + estimateTokens(req.Prefill)
+
+	for attempt := 1; ; attempt++ {
+		res.Attempts++
+
+		if err := c.Limiter.Wait(ctx, inputEstimate); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.Provider.Complete(ctx, req)
+		if err == nil {
+			c.Limiter.Charge(resp.Usage.OutputTokens)
+			res.Usage.InputTokens += resp.Usage.InputTokens
+			res.Usage.OutputTokens += resp.Usage.OutputTokens
+			return resp, nil
+		}
+
+		if !IsRetryable(err) || attempt >= c.Retry.MaxAttempts {
+			return nil, err
+		}
+
+		delay, err := c.Retry.backoff(attempt, err)
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
 }
 
-type apiContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+// ChapterResult is the raw outcome of extracting a whole chapter.
+type ChapterResult struct {
+	Texts    []string // raw response per window, in window order
+	Usage    Usage    // summed over all windows
+	Attempts int      // summed over all windows
+	// Continuations counts follow-up requests for replies cut off at
+	// max_tokens; Truncated counts windows still cut off after them.
+	Continuations int
+	Truncated     int
+	StopReasons   []string // per window, parallel to Texts
 }
 
-type apiUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+// ExtractWindows sends each window of a chapter to the model, splitting it
+// into overlapping windows when it is longer than c.ChunkChars, and returns
+// the raw replies without parsing them (see BuildExtraction). known lists
+// locations from earlier chapters whose names the model should reuse; it
+// may be nil.
+func (c *Client) ExtractWindows(ctx context.Context, chapterTitle, chapterText string, known []KnownLocation) (ChapterResult, error) {
+	return c.runWindows(ctx, chapterTitle, chapterText, func(title, window string) CompletionRequest {
+		return c.extractionRequest(title, window, known)
+	})
 }
 
-type apiError struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
+// runWindows sends the request built for each window of a chapter and
+// collects the raw replies.
+func (c *Client) runWindows(ctx context.Context, chapterTitle, chapterText string, request func(title, window string) CompletionRequest) (ChapterResult, error) {
+	windows := c.windows(chapterText)
+
+	var cr ChapterResult
+	for i, w := range windows {
+		title := chapterTitle
+		if len(windows) > 1 {
+			title = fmt.Sprintf("%s (part %d of %d)", chapterTitle, i+1, len(windows))
+		}
+
+		res, err := c.run(ctx, request(title, w))
+		cr.Attempts += res.Attempts
+		cr.Usage.InputTokens += res.Usage.InputTokens
+		cr.Usage.OutputTokens += res.Usage.OutputTokens
+		cr.Continuations += res.Continuations
+		if err != nil {
+			if len(windows) > 1 {
+				err = fmt.Errorf("window %d/%d: %w", i+1, len(windows), err)
+			}
+			return cr, err
+		}
+		if res.StopReason == StopMaxTokens {
+			cr.Truncated++
+		}
+		cr.Texts = append(cr.Texts, res.Text)
+		cr.StopReasons = append(cr.StopReasons, res.StopReason)
+	}
+	return cr, nil
 }
 
-// Extract sends a chapter to Claude and returns the raw text response.
-func (c *Client) Extract(ctx context.Context, chapterTitle, chapterText string) (string, apiUsage, error) {
-	prompt := buildExtractionPrompt(chapterTitle, chapterText)
+func (c *Client) windows(chapterText string) []string {
+	return splitChapter(chapterText, c.ChunkChars, c.ChunkOverlap)
+}
 
-	reqBody := apiRequest{
+func (c *Client) extractionRequest(chapterTitle, chapterText string, known []KnownLocation) CompletionRequest {
+	req := CompletionRequest{
 		Model:     c.Model,
 		MaxTokens: c.MaxTokens,
 		System:    systemPrompt,
-		Messages: []apiMessage{
-			{Role: "user", Content: prompt},
-			{Role: "assistant", Content: "{"},
-		},
-	}
-
-	body, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", apiUsage{}, fmt.Errorf("marshaling request: %w", err)
+		User:      buildExtractionPrompt(chapterTitle, chapterText, known),
+		Prefill:   extractionPrefill,
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", anthropicAPI, bytes.NewReader(body))
-	if err != nil {
-		return "", apiUsage{}, fmt.Errorf("creating request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", c.APIKey)
-	req.Header.Set("anthropic-version", anthropicVersion)
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", apiUsage{}, fmt.Errorf("API request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", apiUsage{}, fmt.Errorf("reading response: %w", err)
-	}
-
-	if resp.StatusCode != 200 {
-		return "", apiUsage{}, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	var apiResp apiResponse
-	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return "", apiUsage{}, fmt.Errorf("parsing response: %w", err)
-	}
-
-	if apiResp.Error != nil {
-		return "", apiUsage{}, fmt.Errorf("API error (%s): %s", apiResp.Error.Type, apiResp.Error.Message)
-	}
-
-	if len(apiResp.Content) == 0 {
-		return "", apiUsage{}, fmt.Errorf("empty response from API")
+	if c.useTools() {
+		req.Tool = extractionTool
 	}
+	return req
+}
 
-	// Prepend the "{" from the assistant prefill to reconstruct full JSON
-	return "{" + apiResp.Content[0].Text, apiResp.Usage, nil
+// useTools reports whether extraction requests use structured output.
+func (c *Client) useTools() bool {
+	tc, ok := c.Provider.(toolCaller)
+	return c.Structured && ok && tc.supportsTools()
 }
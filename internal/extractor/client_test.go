@@ -0,0 +1,232 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func fastRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+}
+
+func TestExtractRetriesOverload(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(529)
+			w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+			return
+		}
+		w.Write([]byte(`{"content":[{"type":"tool_use","name":"record_locations","input":{"locations":[]}}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(&AnthropicProvider{APIKey: "k", BaseURL: srv.URL, HTTPClient: srv.Client()}, "m", 10)
+	client.Retry = fastRetry()
+
+	res, err := client.ExtractWindows(context.Background(), "1.00", "text", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", res.Attempts)
+	}
+	if len(res.Texts) != 1 || res.Texts[0] != `{"locations":[]}` {
+		t.Errorf("unexpected texts %q", res.Texts)
+	}
+}
+
+func TestExtractDoesNotRetryFatal(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(&AnthropicProvider{APIKey: "k", BaseURL: srv.URL, HTTPClient: srv.Client()}, "m", 10)
+	client.Retry = fastRetry()
+
+	res, err := client.ExtractWindows(context.Background(), "1.00", "text", nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 || res.Attempts != 1 {
+		t.Errorf("expected a single attempt, got %d calls / %d attempts", calls, res.Attempts)
+	}
+	if IsRetryable(err) || !IsFatal(err) {
+		t.Errorf("expected 401 to be fatal and not retryable: %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Type != "authentication_error" {
+		t.Errorf("expected authentication_error APIError, got %v", err)
+	}
+}
+
+func TestExtractGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("retry-after", "0")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := NewClient(&OpenAIProvider{BaseURL: srv.URL, HTTPClient: srv.Client()}, "m", 10)
+	client.Retry = fastRetry()
+
+	res, err := client.ExtractWindows(context.Background(), "1.00", "text", nil)
+	if err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if calls != 4 || res.Attempts != 4 {
+		t.Errorf("expected 4 attempts, got %d calls / %d attempts", calls, res.Attempts)
+	}
+}
+
+func TestExtractContinuesTruncated(t *testing.T) {
+	var prefills []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req apiRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prefills = append(prefills, req.Messages[len(req.Messages)-1].Content)
+		if len(prefills) == 1 {
+			w.Write([]byte(`{"content":[{"type":"text","text":"\"locations\":[{\"name\": \"Lis"}],"stop_reason":"max_tokens","usage":{"input_tokens":5,"output_tokens":10}}`))
+			return
+		}
+		w.Write([]byte(`{"content":[{"type":"text","text":"cor\", \"type\": \"city\"}]}"}],"stop_reason":"end_turn","usage":{"input_tokens":7,"output_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(&AnthropicProvider{APIKey: "k", BaseURL: srv.URL, HTTPClient: srv.Client()}, "m", 10)
+	client.Retry = fastRetry()
+	client.Structured = false
+
+	res, err := client.ExtractWindows(context.Background(), "1.00", "text", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Continuations != 1 || len(res.StopReasons) != 1 || res.StopReasons[0] != StopEndTurn {
+		t.Errorf("expected one continuation ending in end_turn, got %d / %q", res.Continuations, res.StopReasons)
+	}
+	if len(prefills) != 2 || prefills[1] != `{"locations":[{"name": "Lis` {
+		t.Errorf("unexpected prefills %q", prefills)
+	}
+	if res.Usage.InputTokens != 12 || res.Usage.OutputTokens != 13 {
+		t.Errorf("expected usage summed over both requests, got %+v", res.Usage)
+	}
+	parsed, err := ParseExtraction(res.Texts[0])
+	if err != nil {
+		t.Fatalf("continued text did not parse: %v", err)
+	}
+	if parsed.Partial || len(parsed.Locations) != 1 || parsed.Locations[0].Name != "Liscor" {
+		t.Errorf("unexpected parse %+v", parsed)
+	}
+}
+
+func TestExtractWindowsMarksTruncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"content":[{"type":"text","text":"\"locations\":[{\"name\": \"Liscor\"}, {\"na"}],"stop_reason":"max_tokens","usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(&AnthropicProvider{APIKey: "k", BaseURL: srv.URL, HTTPClient: srv.Client()}, "m", 10)
+	client.Retry = fastRetry()
+	client.Structured = false
+	client.MaxContinuations = 0
+
+	cr, err := client.ExtractWindows(context.Background(), "1.00", "text", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ext, err := BuildExtraction(NewRawExtraction(0, "m", cr.Texts, cr.StopReasons, cr.Usage), "1.00", "")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if cr.Truncated != 1 || !ext.Partial {
+		t.Errorf("expected a partial extraction, got truncated=%d partial=%v", cr.Truncated, ext.Partial)
+	}
+	if len(ext.Locations) != 1 {
+		t.Errorf("expected the complete location to be salvaged, got %d", len(ext.Locations))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0.5", 500 * time.Millisecond},
+		{"garbage", 0},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 8 * time.Second}
+	for attempt := 1; attempt <= 8; attempt++ {
+		d, err := p.backoff(attempt, errors.New("transient"))
+		if err != nil || d < 0 || d > p.MaxDelay {
+			t.Errorf("attempt %d: backoff %v (%v) outside [0, %v]", attempt, d, err, p.MaxDelay)
+		}
+	}
+	if d, _ := p.backoff(6, errors.New("transient")); d < p.MaxDelay/2 {
+		t.Errorf("expected capped backoff of at least %v, got %v", p.MaxDelay/2, d)
+	}
+
+	retryAfter := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Second}
+	if d, err := p.backoff(1, retryAfter); err != nil || d != 5*time.Second {
+		t.Errorf("expected retry-after to be honored, got %v (%v)", d, err)
+	}
+	retryAfter.RetryAfter = 42 * time.Second
+	if _, err := p.backoff(1, retryAfter); !errors.Is(err, ErrRetryAfterTooLong) || !IsFatal(err) {
+		t.Errorf("expected a retry-after beyond %v to be fatal, got %v", p.MaxDelay, err)
+	}
+}
+
+func TestExtractStopsOnLongRetryAfter(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("retry-after", "3600")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := NewClient(&AnthropicProvider{APIKey: "k", BaseURL: srv.URL, HTTPClient: srv.Client()}, "m", 10)
+	client.Retry = fastRetry()
+
+	_, err := client.ExtractWindows(context.Background(), "1.00", "text", nil)
+	if calls != 1 || !IsFatal(err) {
+		t.Errorf("expected one call and a fatal error, got %d calls: %v", calls, err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the 429 kept in the error, got %v", err)
+	}
+}
+
+func TestTokenLimiterNil(t *testing.T) {
+	var l *TokenLimiter
+	if err := l.Wait(context.Background(), 1_000_000); err != nil {
+		t.Errorf("nil limiter should never block: %v", err)
+	}
+	l.Charge(1_000_000)
+	if NewTokenLimiter(0) != nil {
+		t.Error("expected zero budget to mean unlimited")
+	}
+}
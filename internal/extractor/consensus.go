@@ -0,0 +1,179 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// DefaultAgreement is the share of consensus samples that must find an item
+// for it to be kept.
+const DefaultAgreement = 0.5
+
+// Consensus merges independent extractions of one chapter by vote. A
+// location, relationship, or containment rule is kept when at least
+// threshold of the samples found it, and each kept location and relationship
+// records its share as Agreement. Matching items are merged as windows are,
+// keeping the longest text. Metadata comes from the first sample, with the
+// models of all samples joined by "+".
+func Consensus(samples []*model.ChapterExtraction, threshold float64) *model.ChapterExtraction {
+	first := samples[0]
+	out := &model.ChapterExtraction{
+		ChapterIndex:  first.ChapterIndex,
+		ChapterTitle:  first.ChapterTitle,
+		ExtractedAt:   first.ExtractedAt,
+		PromptHash:    first.PromptHash,
+		PromptVersion: first.PromptVersion,
+		Samples:       len(samples),
+	}
+
+	var models []string
+	locIdx := make(map[string]int)
+	relIdx := make(map[string]int)
+	contIdx := make(map[string]int)
+	var locVotes, relVotes, contVotes []int
+	warned := make(map[model.ExtractionWarning]bool)
+
+	for _, ext := range samples {
+		if !containsString(models, ext.Model) {
+			models = append(models, ext.Model)
+		}
+		out.Partial = out.Partial || ext.Partial
+		for _, w := range ext.Warnings {
+			if !warned[w] {
+				warned[w] = true
+				out.Warnings = append(out.Warnings, w)
+			}
+		}
+
+		// Each sample votes at most once per item, even if it repeats one.
+		voted := make(map[string]bool)
+		for _, loc := range ext.Locations {
+			key := mergeKey(loc.Name)
+			i, ok := locIdx[key]
+			if !ok {
+				i = len(out.Locations)
+				locIdx[key] = i
+				loc.Aliases = appendUnique(nil, loc.Aliases...)
+				out.Locations = append(out.Locations, loc)
+				locVotes = append(locVotes, 0)
+			} else {
+				mergeSampledLocation(&out.Locations[i], loc)
+			}
+			if !voted["loc|"+key] {
+				voted["loc|"+key] = true
+				locVotes[i]++
+			}
+		}
+
+		for _, rel := range ext.Relationships {
+			key := mergeKey(rel.From) + "|" + mergeKey(rel.To) + "|" + string(rel.Type)
+			i, ok := relIdx[key]
+			if !ok {
+				i = len(out.Relationships)
+				relIdx[key] = i
+				out.Relationships = append(out.Relationships, rel)
+				relVotes = append(relVotes, 0)
+			} else {
+				cur := &out.Relationships[i]
+				if len(rel.Detail) > len(cur.Detail) {
+					cur.Detail = rel.Detail
+				}
+				// Prefer a quote that was found in the chapter text.
+				if rel.Quote != "" && (cur.Quote == "" || !cur.QuoteMatch.Verified() && rel.QuoteMatch.Verified()) {
+					cur.Quote, cur.QuoteMatch = rel.Quote, rel.QuoteMatch
+				}
+			}
+			if !voted["rel|"+key] {
+				voted["rel|"+key] = true
+				relVotes[i]++
+			}
+		}
+
+		for _, c := range ext.Containment {
+			key := mergeKey(c.Child) + "|" + mergeKey(c.Parent)
+			i, ok := contIdx[key]
+			if !ok {
+				i = len(out.Containment)
+				contIdx[key] = i
+				out.Containment = append(out.Containment, c)
+				contVotes = append(contVotes, 0)
+			}
+			if !voted["cont|"+key] {
+				voted["cont|"+key] = true
+				contVotes[i]++
+			}
+		}
+	}
+	out.Model = strings.Join(models, "+")
+
+	n := float64(len(samples))
+	var locs []model.ExtractedLocation
+	for i, loc := range out.Locations {
+		if share := float64(locVotes[i]) / n; share >= threshold {
+			loc.Agreement = share
+			locs = append(locs, loc)
+		}
+	}
+	var rels []model.ExtractedRelationship
+	for i, rel := range out.Relationships {
+		if share := float64(relVotes[i]) / n; share >= threshold {
+			rel.Agreement = share
+			rels = append(rels, rel)
+		}
+	}
+	var conts []model.Containment
+	for i, c := range out.Containment {
+		if float64(contVotes[i])/n >= threshold {
+			conts = append(conts, c)
+		}
+	}
+	out.Locations, out.Relationships, out.Containment = locs, rels, conts
+	return out
+}
+
+// mergeSampledLocation folds another sample's sighting of a location into
+// dst, carrying quote matches along with their quotes.
+func mergeSampledLocation(dst *model.ExtractedLocation, src model.ExtractedLocation) {
+	for i, q := range src.ContextQuotes {
+		if containsString(dst.ContextQuotes, q) {
+			continue
+		}
+		dst.ContextQuotes = append(dst.ContextQuotes, q)
+		if dst.QuoteMatches != nil && i < len(src.QuoteMatches) {
+			dst.QuoteMatches = append(dst.QuoteMatches, src.QuoteMatches[i])
+		}
+	}
+	src.ContextQuotes = nil
+	mergeLocation(dst, src)
+}
+
+// containsString reports whether items holds s, compared as mergeKey does.
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if mergeKey(item) == mergeKey(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildConsensus builds the extraction of each run stored in raw, as
+// BuildExtraction does, and merges them with Consensus. A raw extraction
+// without samples is built as a single run. Any run that fails to parse
+// fails the whole chapter, so its replies can be re-parsed later.
+func BuildConsensus(raw *model.RawExtraction, chapterTitle, chapterText string, threshold float64) (*model.ChapterExtraction, error) {
+	first, err := BuildExtraction(raw, chapterTitle, chapterText)
+	if err != nil || len(raw.Samples) == 0 {
+		return first, err
+	}
+	samples := []*model.ChapterExtraction{first}
+	for i := range raw.Samples {
+		ext, err := BuildExtraction(&raw.Samples[i], chapterTitle, chapterText)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, ext)
+	}
+	return Consensus(samples, threshold), nil
+}
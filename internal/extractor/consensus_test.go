@@ -0,0 +1,91 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestConsensus(t *testing.T) {
+	sample := func(modelName string, locs []string, rels ...model.ExtractedRelationship) *model.ChapterExtraction {
+		ext := &model.ChapterExtraction{ChapterIndex: 4, Model: modelName, ExtractedAt: "2024-01-01"}
+		for _, name := range locs {
+			ext.Locations = append(ext.Locations, model.ExtractedLocation{Name: name, Type: model.LocationCity})
+		}
+		ext.Relationships = rels
+		ext.Containment = []model.Containment{{Child: locs[0], Parent: "Izril"}}
+		return ext
+	}
+	near := model.ExtractedRelationship{From: "The Inn", To: "Liscor", Type: model.RelAdjacency, Detail: "near"}
+	nearer := model.ExtractedRelationship{From: "the inn", To: "liscor", Type: model.RelAdjacency, Detail: "just outside the walls", Quote: "outside", QuoteMatch: &model.QuoteMatch{Score: 1}}
+	invented := model.ExtractedRelationship{From: "Liscor", To: "Celum", Type: model.RelDirection}
+
+	samples := []*model.ChapterExtraction{
+		sample("a", []string{"Liscor", "The Inn", "Celum"}, near, invented),
+		sample("b", []string{"liscor", "The Inn"}, nearer),
+		sample("a", []string{"Liscor", "Pallass"}, near),
+	}
+	ext := Consensus(samples, 0.6)
+
+	if ext.Model != "a+b" || ext.Samples != 3 || ext.ChapterIndex != 4 || ext.ExtractedAt != samples[0].ExtractedAt {
+		t.Errorf("expected metadata from the first sample with models joined, got %+v", ext)
+	}
+
+	agreement := make(map[string]float64)
+	for _, loc := range ext.Locations {
+		agreement[loc.Name] = loc.Agreement
+	}
+	if len(agreement) != 2 || agreement["Liscor"] != 1 || agreement["The Inn"] != 2.0/3 {
+		t.Errorf("expected Liscor (3/3) and The Inn (2/3) kept, got %v", agreement)
+	}
+
+	if len(ext.Relationships) != 1 {
+		t.Fatalf("expected only the relationship most samples found, got %+v", ext.Relationships)
+	}
+	rel := ext.Relationships[0]
+	if rel.Agreement != 1 || rel.Detail != "just outside the walls" || rel.Quote != "outside" {
+		t.Errorf("expected the agreed relationship merged with the longest detail and verified quote, got %+v", rel)
+	}
+
+	if len(ext.Containment) != 1 || ext.Containment[0].Child != "Liscor" {
+		t.Errorf("expected the containment every sample found, got %+v", ext.Containment)
+	}
+}
+
+func TestBuildConsensus(t *testing.T) {
+	reply := func(names ...string) []string {
+		s := `{"locations":[`
+		for i, n := range names {
+			if i > 0 {
+				s += ","
+			}
+			s += `{"name":"` + n + `","type":"city"}`
+		}
+		return []string{s + `],"relationships":[],"containment":[]}`}
+	}
+	raw := NewRawExtraction(0, "a", reply("Liscor", "Celum"), []string{"end_turn"}, Usage{})
+	single, err := BuildConsensus(raw, "1.00", "", DefaultAgreement)
+	if err != nil {
+		t.Fatalf("building single run: %v", err)
+	}
+	if single.Samples != 0 || len(single.Locations) != 2 || single.Locations[0].Agreement != 0 {
+		t.Errorf("expected a raw response without samples built as a single run, got %+v", single)
+	}
+
+	raw.Samples = []model.RawExtraction{
+		*NewRawExtraction(0, "b", reply("Liscor"), []string{"end_turn"}, Usage{}),
+		*NewRawExtraction(0, "a", reply("Liscor", "Pallass"), []string{"end_turn"}, Usage{}),
+	}
+	ext, err := BuildConsensus(raw, "1.00", "", DefaultAgreement)
+	if err != nil {
+		t.Fatalf("building consensus: %v", err)
+	}
+	if ext.Samples != 3 || len(ext.Locations) != 1 || ext.Locations[0].Name != "Liscor" {
+		t.Errorf("expected only Liscor to reach agreement, got %+v", ext)
+	}
+
+	raw.Samples[1].Responses = []string{"no JSON here"}
+	if _, err := BuildConsensus(raw, "1.00", "", DefaultAgreement); err == nil {
+		t.Error("expected an unparseable sample to fail the chapter")
+	}
+}
@@ -0,0 +1,112 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// APIError is an error reported by an LLM backend, either as a non-200 status
+// or as an error object in the response body.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+	// RetryAfter is the server's requested delay before retrying, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.StatusCode == 0 || e.StatusCode == http.StatusOK:
+		return fmt.Sprintf("API error (%s): %s", e.Type, e.Message)
+	case e.Type != "":
+		return fmt.Sprintf("API returned status %d (%s): %s", e.StatusCode, e.Type, e.Message)
+	default:
+		return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Message)
+	}
+}
+
+// Retryable reports whether the same request may succeed if sent again:
+// rate limiting, overload (Anthropic's 529), and transient server errors.
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusRequestTimeout,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout, 529:
+		return true
+	}
+	switch e.Type {
+	case "rate_limit_error", "overloaded_error", "api_error":
+		return true
+	}
+	return false
+}
+
+// Fatal reports whether no request in this run can succeed, e.g. a bad API
+// key or an unknown model, so the run should stop rather than move on.
+func (e *APIError) Fatal() bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// IsRetryable reports whether err is worth retrying. Network failures are
+// retryable; cancellation and malformed responses are not.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// ErrRetryAfterTooLong is returned when the server asks for a longer wait
+// before retrying than the retry policy allows. It is fatal: every other
+// request in the run would be told the same.
+var ErrRetryAfterTooLong = errors.New("server asked to wait longer than the max backoff before retrying")
+
+// IsFatal reports whether err means the whole extraction run should stop.
+func IsFatal(err error) bool {
+	if errors.Is(err, ErrRetryAfterTooLong) {
+		return true
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Fatal()
+}
+
+// newStatusError builds an APIError from a non-200 HTTP response.
+func newStatusError(resp *http.Response, body []byte) *APIError {
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("retry-after")),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an
+// HTTP date. It returns zero when the header is absent or unparseable.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
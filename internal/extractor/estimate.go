@@ -0,0 +1,33 @@
+package extractor
+
+import "encoding/json"
+
+// outputRatio is the typical size of an extraction reply relative to the
+// window it was extracted from.
+const outputRatio = 0.15
+
+// EstimateChapter predicts the tokens an extraction of chapterText will use
+// without calling the API: the prompt, known locations, the tool schema when
+// structured output is enabled, and the chapter text for each window, plus a reply
+// proportional to the window and capped at MaxTokens. Continuations and
+// retries are not counted. Tokens are approximated as by estimateTokens;
+// billing uses reported usage.
+func (c *Client) EstimateChapter(chapterTitle, chapterText string, known []KnownLocation) Usage {
+	overhead := estimateTokens(systemPrompt)
+	if c.Structured {
+		schema, _ := json.Marshal(extractionTool)
+		overhead += estimateTokens(string(schema))
+	}
+
+	var u Usage
+	for _, window := range c.windows(chapterText) {
+		in := overhead + estimateTokens(buildExtractionPrompt(chapterTitle, window, known))
+		out := int(float64(estimateTokens(window)) * outputRatio)
+		if c.MaxTokens > 0 {
+			out = min(out, c.MaxTokens)
+		}
+		u.InputTokens += in
+		u.OutputTokens += out
+	}
+	return u
+}
@@ -0,0 +1,35 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateChapter(t *testing.T) {
+	para := strings.Repeat("Erin walked from the inn to Liscor. ", 100) // 3,600 chars
+	text := strings.Repeat(para+"\n\n", 9) + para                       // 10 paragraphs
+
+	client := NewClient(nil, "test-model", 64000)
+	whole := client.EstimateChapter("1.00", text, nil)
+	if whole.InputTokens <= estimateTokens(text) || whole.OutputTokens <= 0 {
+		t.Fatalf("expected the estimate to cover the text plus prompt, got %+v", whole)
+	}
+
+	client.Structured = false
+	plain := client.EstimateChapter("1.00", text, nil)
+	if plain.InputTokens >= whole.InputTokens {
+		t.Errorf("expected the tool schema to add input tokens: %d vs %d", plain.InputTokens, whole.InputTokens)
+	}
+
+	// Splitting repeats the prompt and overlap in every window.
+	client.ChunkChars, client.ChunkOverlap = 10000, 4000
+	split := client.EstimateChapter("1.00", text, nil)
+	if split.InputTokens <= plain.InputTokens {
+		t.Errorf("expected windows to cost more input than one request: %d vs %d", split.InputTokens, plain.InputTokens)
+	}
+
+	client.MaxTokens = 10
+	if capped := client.EstimateChapter("1.00", text, nil); capped.OutputTokens > 10*len(client.windows(text)) {
+		t.Errorf("expected output capped at MaxTokens per window, got %d", capped.OutputTokens)
+	}
+}
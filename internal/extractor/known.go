@@ -0,0 +1,68 @@
+package extractor
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// KnownLocation is a canonical location from earlier chapters, listed in the
+// prompt so the model reuses its name instead of inventing a new spelling.
+type KnownLocation struct {
+	Name string
+	Type model.LocationType
+}
+
+// KnownLocationsBefore returns up to limit of locs mentioned before
+// chapterIdx, those mentioned in the most earlier chapters first. Mentions at
+// or after chapterIdx are ignored, but locs' names and types are taken as
+// given: build them from earlier chapters only (see aggregator.KnownIndex),
+// or a chapter's prompt may name places by what later chapters revealed.
+func KnownLocationsBefore(locs []model.AggregatedLocation, chapterIdx, limit int) []KnownLocation {
+	if limit <= 0 {
+		return nil
+	}
+
+	type candidate struct {
+		loc   *model.AggregatedLocation
+		count int
+	}
+	var candidates []candidate
+	for i := range locs {
+		n := 0
+		for _, idx := range locs[i].ChapterIndices {
+			if idx < chapterIdx {
+				n++
+			}
+		}
+		if n > 0 {
+			candidates = append(candidates, candidate{&locs[i], n})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(b.count, a.count), cmp.Compare(a.loc.FirstChapterIndex, b.loc.FirstChapterIndex))
+	})
+
+	known := make([]KnownLocation, 0, min(limit, len(candidates)))
+	for _, c := range candidates[:min(limit, len(candidates))] {
+		known = append(known, KnownLocation{Name: c.loc.Name, Type: c.loc.Type})
+	}
+	return known
+}
+
+// knownLocationsSection renders known for the extraction prompt, or "" if
+// there are none.
+func knownLocationsSection(known []KnownLocation) string {
+	if len(known) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nLocations named in earlier chapters are listed below as name (type). When this chapter mentions one of them, use exactly the name given here rather than a variant spelling. Only include them if this chapter mentions them.\n")
+	for _, k := range known {
+		fmt.Fprintf(&b, "- %s (%s)\n", k.Name, k.Type)
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestKnownLocationsBefore(t *testing.T) {
+	locs := []model.AggregatedLocation{
+		{Name: "Liscor", Type: model.LocationCity, FirstChapterIndex: 0, ChapterIndices: []int{0, 1, 2, 5}},
+		{Name: "Blood Fields", Type: model.LocationLandmark, FirstChapterIndex: 1, ChapterIndices: []int{1, 9, 10, 11, 12}},
+		{Name: "Celum", Type: model.LocationCity, FirstChapterIndex: 1, ChapterIndices: []int{1, 2}},
+		{Name: "Pallass", Type: model.LocationCity, FirstChapterIndex: 5, ChapterIndices: []int{5, 6}},
+	}
+
+	known := KnownLocationsBefore(locs, 5, 10)
+	var names []string
+	for _, k := range known {
+		names = append(names, k.Name)
+	}
+	// Pallass first appears in chapter 5 itself, and the Blood Fields' later
+	// mentions must not rank it above Celum.
+	if got := strings.Join(names, ","); got != "Liscor,Celum,Blood Fields" {
+		t.Errorf("expected Liscor,Celum,Blood Fields, got %s", got)
+	}
+	if known[0].Type != model.LocationCity {
+		t.Errorf("expected types carried over, got %+v", known[0])
+	}
+
+	if got := KnownLocationsBefore(locs, 5, 1); len(got) != 1 || got[0].Name != "Liscor" {
+		t.Errorf("expected the limit to keep the most mentioned, got %+v", got)
+	}
+	if got := KnownLocationsBefore(locs, 0, 10); len(got) != 0 {
+		t.Errorf("expected nothing known before the first chapter, got %+v", got)
+	}
+	if got := KnownLocationsBefore(locs, 5, 0); got != nil {
+		t.Errorf("expected a zero limit to disable the list, got %+v", got)
+	}
+}
+
+func TestExtractionPromptKnownLocations(t *testing.T) {
+	plain := buildExtractionPrompt("1.05", "text", nil)
+	if strings.Contains(plain, "earlier chapters") {
+		t.Error("expected no known-locations section without known locations")
+	}
+
+	prompt := buildExtractionPrompt("1.05", "text", []KnownLocation{{Name: "Blood Fields", Type: model.LocationLandmark}})
+	if !strings.Contains(prompt, "- Blood Fields (landmark)\n") {
+		t.Errorf("expected the known location listed, got:\n%s", prompt)
+	}
+	if strings.Index(prompt, "Blood Fields") > strings.Index(prompt, "--- CHAPTER TEXT ---") {
+		t.Error("expected known locations before the chapter text")
+	}
+}
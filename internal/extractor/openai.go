@@ -0,0 +1,172 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAIProvider calls an OpenAI-compatible Chat Completions endpoint, such as
+// a llama.cpp server, vLLM, or Ollama.
+type OpenAIProvider struct {
+	APIKey     string // optional; sent as a bearer token when set
+	BaseURL    string // e.g. http://localhost:8080/v1
+	HTTPClient *http.Client
+}
+
+type oaiRequest struct {
+	Model      string         `json:"model"`
+	MaxTokens  int            `json:"max_tokens"`
+	Messages   []apiMessage   `json:"messages"`
+	Tools      []oaiTool      `json:"tools,omitempty"`
+	ToolChoice *oaiToolChoice `json:"tool_choice,omitempty"`
+}
+
+type oaiTool struct {
+	Type     string      `json:"type"` // always "function"
+	Function oaiFunction `json:"function"`
+}
+
+type oaiFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type oaiToolChoice struct {
+	Type     string      `json:"type"` // always "function"
+	Function oaiFunction `json:"function"`
+}
+
+type oaiResponse struct {
+	Choices []oaiChoice `json:"choices"`
+	Usage   oaiUsage    `json:"usage"`
+	Error   *apiError   `json:"error,omitempty"`
+}
+
+type oaiChoice struct {
+	Message      oaiMessage `json:"message"`
+	FinishReason string     `json:"finish_reason"`
+}
+
+type oaiMessage struct {
+	Role      string        `json:"role"`
+	Content   string        `json:"content"`
+	ToolCalls []oaiToolCall `json:"tool_calls,omitempty"`
+}
+
+type oaiToolCall struct {
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded input
+	} `json:"function"`
+}
+
+type oaiUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Complete sends a request to the Chat Completions API. Assistant prefill is
+// not portable across OpenAI-compatible servers, so it is not sent; the
+// extraction prompt already asks for bare JSON. A Tool is sent as a forced
+// function call.
+func (p *OpenAIProvider) Complete(ctx context.Context, cr CompletionRequest) (*Completion, error) {
+	oaiReq := oaiRequest{
+		Model:     cr.Model,
+		MaxTokens: cr.MaxTokens,
+		Messages: []apiMessage{
+			{Role: "system", Content: cr.System},
+			{Role: "user", Content: cr.User},
+		},
+	}
+	if cr.Tool != nil {
+		fn := oaiFunction{Name: cr.Tool.Name, Description: cr.Tool.Description, Parameters: cr.Tool.InputSchema}
+		oaiReq.Tools = []oaiTool{{Type: "function", Function: fn}}
+		oaiReq.ToolChoice = &oaiToolChoice{Type: "function", Function: oaiFunction{Name: cr.Tool.Name}}
+	}
+	body, err := json.Marshal(oaiReq)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	url := strings.TrimRight(p.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		apiErr := newStatusError(resp, respBody)
+		var errResp oaiResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != nil {
+			apiErr.Type = errResp.Error.Type
+			apiErr.Message = errResp.Error.Message
+		}
+		return nil, apiErr
+	}
+
+	var oaiResp oaiResponse
+	if err := json.Unmarshal(respBody, &oaiResp); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	if oaiResp.Error != nil {
+		return nil, &APIError{StatusCode: http.StatusOK, Type: oaiResp.Error.Type, Message: oaiResp.Error.Message}
+	}
+
+	if len(oaiResp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from API")
+	}
+
+	choice := oaiResp.Choices[0]
+	c := &Completion{
+		Text:       choice.Message.Content,
+		Usage:      Usage{InputTokens: oaiResp.Usage.PromptTokens, OutputTokens: oaiResp.Usage.CompletionTokens},
+		StopReason: oaiStopReason(choice.FinishReason),
+	}
+	for _, call := range choice.Message.ToolCalls {
+		if cr.Tool != nil && call.Function.Name == cr.Tool.Name {
+			c.Text, c.Structured = call.Function.Arguments, true
+			break
+		}
+	}
+	return c, nil
+}
+
+// supportsTools is true for most OpenAI-compatible servers; set
+// extract.structured_output = false for those without function calling.
+func (p *OpenAIProvider) supportsTools() bool { return true }
+
+// oaiStopReason maps a Chat Completions finish_reason onto the Anthropic
+// stop_reason vocabulary used by Completion.
+func oaiStopReason(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return StopMaxTokens
+	case "stop", "tool_calls":
+		return StopEndTurn
+	default:
+		return finishReason
+	}
+}
@@ -2,6 +2,7 @@ package extractor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -13,15 +14,30 @@ type extractionResponse struct {
 	Locations     []model.ExtractedLocation     `json:"locations"`
 	Relationships []model.ExtractedRelationship `json:"relationships"`
 	Containment   []model.Containment           `json:"containment"`
+
+	// Partial is set when the response was truncated and only its complete
+	// elements could be recovered.
+	Partial bool `json:"-"`
+	// Warnings is filled in by ParseChapter's validation pass.
+	Warnings []model.ExtractionWarning `json:"-"`
 }
 
+// ErrUnparseable is returned when a response contains no usable JSON.
+var ErrUnparseable = errors.New("failed to parse extraction response as JSON")
+
 // ParseExtraction attempts to parse the LLM response text as JSON.
 // Tries multiple strategies: direct parse, brace extraction, code block extraction.
 func ParseExtraction(text string) (*extractionResponse, error) {
+	return parseJSON[extractionResponse](text)
+}
+
+// parseJSON decodes the JSON object in an LLM reply into a T, using the
+// strategies ParseExtraction describes.
+func parseJSON[T any](text string) (*T, error) {
 	text = strings.TrimSpace(text)
 
 	// Strategy 1: direct parse
-	var result extractionResponse
+	var result T
 	if err := json.Unmarshal([]byte(text), &result); err == nil {
 		return &result, nil
 	}
@@ -56,5 +72,5 @@ func ParseExtraction(text string) (*extractionResponse, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("failed to parse extraction response as JSON: %.200s...", text)
+	return nil, fmt.Errorf("%w: %.200s...", ErrUnparseable, text)
 }
@@ -1,5 +1,25 @@
 package extractor
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// PromptVersion numbers revisions of the extraction prompt. Bump it whenever
+// the prompt changes in a way worth re-extracting for, so older extractions
+// can be selected with 'extract --older-than-prompt'.
+const PromptVersion = 1
+
+// PromptHash identifies the current prompt wording: the system prompt plus
+// the user prompt template, including the known-locations instructions. It
+// is stored with every extraction so data from an older prompt can be told
+// apart.
+func PromptHash() string {
+	template := buildExtractionPrompt("{title}", "{text}", []KnownLocation{{Name: "{name}", Type: "{type}"}})
+	sum := sha256.Sum256([]byte(systemPrompt + "\x00" + template))
+	return hex.EncodeToString(sum[:6])
+}
+
 const systemPrompt = `You are a geographical data extraction specialist. You analyze chapters from "The Wandering Inn" web serial and extract all location/geographical information in structured JSON format.
 
 ## Location Types
@@ -37,10 +57,11 @@ const systemPrompt = `You are a geographical data extraction specialist. You ana
 9. Pay special attention to physical descriptions of locations: terrain, climate, architecture, landscape, colors, vegetation, size, shape, atmosphere
 10. Capture how the world LOOKS - descriptions of plains, mountains, walls, buildings, weather, seasons, flora and fauna associated with locations`
 
-func buildExtractionPrompt(chapterTitle, chapterText string) string {
+func buildExtractionPrompt(chapterTitle, chapterText string, known []KnownLocation) string {
 	return `Extract all geographical and location data from this chapter of "The Wandering Inn".
 
 Chapter: "` + chapterTitle + `"
+` + knownLocationsSection(known) + `
 
 Respond with ONLY valid JSON in this exact format (no markdown, no explanation):
 {
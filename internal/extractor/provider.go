@@ -0,0 +1,102 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Provider names accepted in the [extract] config block.
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderOpenAI    = "openai"
+)
+
+// Provider is an LLM backend that answers a single-turn completion request.
+type Provider interface {
+	// Complete sends the prompts to the model and returns its text output.
+	Complete(ctx context.Context, req CompletionRequest) (*Completion, error)
+}
+
+// CompletionRequest is a backend-neutral chat request.
+type CompletionRequest struct {
+	Model     string
+	MaxTokens int
+	System    string
+	User      string
+	// Prefill seeds the assistant's reply on backends that support it.
+	// It is not sent when Tool is set.
+	Prefill string
+	// Tool, when set, forces the model to answer by calling this tool.
+	Tool *Tool
+}
+
+// Tool declares a function the model can call, with a JSON Schema for its
+// input.
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema map[string]any
+}
+
+// Completion is a backend-neutral chat response. Text always holds the full
+// reply, including any prefill the backend continued from.
+type Completion struct {
+	Text  string
+	Usage Usage
+	// Structured reports that Text is the JSON input of a call to the
+	// request's Tool rather than free-form text.
+	Structured bool
+	// StopReason says why generation ended, using Anthropic's vocabulary
+	// (StopEndTurn, StopMaxTokens, ...) for every backend.
+	StopReason string
+}
+
+// Stop reasons reported in Completion.StopReason.
+const (
+	StopEndTurn   = "end_turn"
+	StopMaxTokens = "max_tokens"
+)
+
+// prefiller is implemented by providers that continue the assistant reply
+// from CompletionRequest.Prefill rather than ignoring it.
+type prefiller interface {
+	supportsPrefill() bool
+}
+
+// toolCaller is implemented by providers that honor CompletionRequest.Tool.
+// Requests to other providers fall back to prompting for bare JSON.
+type toolCaller interface {
+	supportsTools() bool
+}
+
+// Usage reports token consumption for a single request.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// NewProvider creates the named provider. baseURL overrides the backend's
+// default endpoint and is required for OpenAI-compatible servers.
+func NewProvider(name, baseURL string) (Provider, error) {
+	switch name {
+	case "", ProviderAnthropic:
+		key := os.Getenv("ANTHROPIC_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
+		}
+		if baseURL == "" {
+			baseURL = anthropicBaseURL
+		}
+		return &AnthropicProvider{APIKey: key, BaseURL: baseURL, HTTPClient: &http.Client{}}, nil
+	case ProviderOpenAI:
+		if baseURL == "" {
+			return nil, fmt.Errorf("provider %q requires extract.base_url (e.g. http://localhost:8080/v1)", name)
+		}
+		// Local servers (llama.cpp, vLLM, Ollama) usually don't check the key.
+		return &OpenAIProvider{APIKey: os.Getenv("OPENAI_API_KEY"), BaseURL: baseURL, HTTPClient: &http.Client{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown extract provider %q (want %q or %q)", name, ProviderAnthropic, ProviderOpenAI)
+	}
+}
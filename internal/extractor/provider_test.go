@@ -0,0 +1,205 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestAnthropicProvider(t *testing.T) {
+	var got apiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("expected x-api-key header, got %q", r.Header.Get("x-api-key"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"content":[{"type":"text","text":"\"locations\":[]}"}],"usage":{"input_tokens":12,"output_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	p := &AnthropicProvider{APIKey: "test-key", BaseURL: srv.URL, HTTPClient: srv.Client()}
+	resp, err := p.Complete(context.Background(), CompletionRequest{
+		Model: "m", MaxTokens: 10, System: "sys", User: "hi", Prefill: "{",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Text != `{"locations":[]}` {
+		t.Errorf("expected prefill prepended, got %q", resp.Text)
+	}
+	if resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 3 {
+		t.Errorf("usage mismatch: %+v", resp.Usage)
+	}
+	if len(got.Messages) != 2 || got.Messages[1].Role != "assistant" {
+		t.Errorf("expected user + assistant prefill messages, got %+v", got.Messages)
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	var got oaiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected no Authorization header without a key, got %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"locations\":[]}"}}],"usage":{"prompt_tokens":20,"completion_tokens":5}}`))
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{BaseURL: srv.URL + "/v1/", HTTPClient: srv.Client()}
+	resp, err := p.Complete(context.Background(), CompletionRequest{
+		Model: "local", MaxTokens: 10, System: "sys", User: "hi", Prefill: "{",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Text != `{"locations":[]}` {
+		t.Errorf("unexpected text %q", resp.Text)
+	}
+	if resp.Usage.InputTokens != 20 || resp.Usage.OutputTokens != 5 {
+		t.Errorf("usage mismatch: %+v", resp.Usage)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[1].Role != "user" {
+		t.Errorf("expected system + user messages, got %+v", got.Messages)
+	}
+}
+
+func TestOpenAIProviderErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	if _, err := p.Complete(context.Background(), CompletionRequest{Model: "local"}); err == nil {
+		t.Fatal("expected error for non-200 status")
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := NewProvider(ProviderAnthropic, ""); err == nil {
+		t.Error("expected error without ANTHROPIC_API_KEY")
+	}
+	if _, err := NewProvider(ProviderOpenAI, ""); err == nil {
+		t.Error("expected error for openai provider without base_url")
+	}
+	if _, err := NewProvider("bogus", ""); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	p, err := NewProvider(ProviderOpenAI, "http://localhost:8080/v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := p.(*OpenAIProvider); !ok {
+		t.Errorf("expected *OpenAIProvider, got %T", p)
+	}
+}
+
+func TestAnthropicProviderTool(t *testing.T) {
+	var got apiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"content":[{"type":"tool_use","id":"toolu_1","name":"record_locations","input":{"locations":[{"name":"Liscor","type":"city"}]}}],"stop_reason":"tool_use","usage":{"input_tokens":12,"output_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	p := &AnthropicProvider{APIKey: "test-key", BaseURL: srv.URL, HTTPClient: srv.Client()}
+	resp, err := p.Complete(context.Background(), CompletionRequest{
+		Model: "m", MaxTokens: 10, System: "sys", User: "hi", Prefill: "{", Tool: extractionTool,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Messages) != 1 {
+		t.Errorf("expected no prefill with a forced tool call, got %+v", got.Messages)
+	}
+	if len(got.Tools) != 1 || got.ToolChoice == nil || got.ToolChoice.Type != "tool" || got.ToolChoice.Name != extractionToolName {
+		t.Errorf("expected forced record_locations tool, got %+v / %+v", got.Tools, got.ToolChoice)
+	}
+	if !resp.Structured {
+		t.Error("expected a structured completion")
+	}
+	parsed, err := ParseExtraction(resp.Text)
+	if err != nil || len(parsed.Locations) != 1 {
+		t.Errorf("expected tool input to parse directly, got %v / %q", err, resp.Text)
+	}
+}
+
+func TestOpenAIProviderTool(t *testing.T) {
+	var got oaiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"record_locations","arguments":"{\"locations\":[]}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":20,"completion_tokens":5}}`))
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	resp, err := p.Complete(context.Background(), CompletionRequest{Model: "local", Tool: extractionTool})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Function.Name != extractionToolName || got.ToolChoice == nil {
+		t.Errorf("expected forced function call, got %+v / %+v", got.Tools, got.ToolChoice)
+	}
+	if !resp.Structured || resp.Text != `{"locations":[]}` || resp.StopReason != StopEndTurn {
+		t.Errorf("unexpected completion %+v", resp)
+	}
+}
+
+// textOnlyProvider is a Provider without tool support.
+type textOnlyProvider struct{ got CompletionRequest }
+
+func (p *textOnlyProvider) Complete(ctx context.Context, req CompletionRequest) (*Completion, error) {
+	p.got = req
+	return &Completion{Text: `{"locations":[]}`}, nil
+}
+
+func TestExtractFallsBackWithoutTools(t *testing.T) {
+	p := &textOnlyProvider{}
+	client := NewClient(p, "m", 10)
+	if _, err := client.ExtractWindows(context.Background(), "1.00", "text", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.got.Tool != nil || p.got.Prefill != extractionPrefill {
+		t.Errorf("expected the prefill path, got tool=%v prefill=%q", p.got.Tool, p.got.Prefill)
+	}
+}
+
+func TestExtractionSchema(t *testing.T) {
+	schema := extractionTool.InputSchema
+	props := schema["properties"].(map[string]any)
+	for _, key := range []string{"locations", "relationships", "containment"} {
+		if _, ok := props[key]; !ok {
+			t.Errorf("expected %q in schema", key)
+		}
+	}
+	if _, ok := props["Partial"]; ok {
+		t.Error("json:\"-\" fields should be skipped")
+	}
+
+	loc := props["locations"].(map[string]any)["items"].(map[string]any)
+	locProps := loc["properties"].(map[string]any)
+	if enum := locProps["type"].(map[string]any)["enum"].([]string); len(enum) != len(model.LocationTypes) {
+		t.Errorf("expected location type enum, got %v", enum)
+	}
+	if req := loc["required"].([]string); !slices.Equal(req, []string{"name", "type", "description"}) {
+		t.Errorf("expected omitempty fields to be optional, got %v", req)
+	}
+
+	cont := props["containment"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := cont["first_chapter_index"]; ok {
+		t.Error("schema:\"-\" fields should be skipped")
+	}
+}
@@ -0,0 +1,129 @@
+package extractor
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// quoteToken is a normalized word and its byte offset in the original text.
+type quoteToken struct {
+	word   string
+	offset int
+}
+
+// tokenizeQuote splits text into lowercase words of letters and digits.
+// Apostrophes are dropped rather than splitting words, so "Erin's" and
+// "Erin’s" both become "erins", and punctuation and whitespace differences
+// between a quote and its source don't matter.
+func tokenizeQuote(text string) []quoteToken {
+	var tokens []quoteToken
+	var b strings.Builder
+	start := -1
+	flush := func() {
+		if start >= 0 {
+			tokens = append(tokens, quoteToken{word: b.String(), offset: start})
+			b.Reset()
+			start = -1
+		}
+	}
+	for i, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case r == '\'' || r == '’':
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// matchQuote finds the window of chapter words, as long as the quote, that
+// shares the most words with it. The score is the share of the quote's words
+// found in that window, so a verbatim quote scores 1 and a hallucinated one
+// close to 0, while small misquotes (a dropped word, changed punctuation)
+// still verify.
+func matchQuote(quote string, chapter []quoteToken, text string) model.QuoteMatch {
+	q := tokenizeQuote(quote)
+	if len(q) == 0 || len(chapter) == 0 {
+		return model.QuoteMatch{Offset: -1}
+	}
+
+	need := make(map[string]int)
+	for _, t := range q {
+		need[t.word]++
+	}
+
+	// Slide a window of len(q) words, tracking how many quote words it holds.
+	have := make(map[string]int)
+	matched, best, bestStart := 0, -1, 0
+	for i, t := range chapter {
+		if n, ok := need[t.word]; ok {
+			if have[t.word] < n {
+				matched++
+			}
+			have[t.word]++
+		}
+		if i >= len(q) {
+			old := chapter[i-len(q)].word
+			if n, ok := need[old]; ok {
+				have[old]--
+				if have[old] < n {
+					matched--
+				}
+			}
+		}
+		if matched > best {
+			best, bestStart = matched, max(i-len(q)+1, 0)
+			if best == len(q) {
+				break
+			}
+		}
+	}
+
+	offset := chapter[bestStart].offset
+	return model.QuoteMatch{
+		Offset:    offset,
+		Paragraph: strings.Count(text[:offset], paragraphSeparator),
+		Score:     float64(best) / float64(len(q)),
+	}
+}
+
+// VerifyQuotes matches every context quote and relationship quote in ext
+// against the chapter text, recording where each was found and how closely
+// it matched. It returns how many quotes verified out of how many were
+// checked.
+func VerifyQuotes(ext *model.ChapterExtraction, chapterText string) (verified, total int) {
+	chapter := tokenizeQuote(chapterText)
+	check := func(quote string) model.QuoteMatch {
+		m := matchQuote(quote, chapter, chapterText)
+		total++
+		if m.Verified() {
+			verified++
+		}
+		return m
+	}
+
+	for i := range ext.Locations {
+		loc := &ext.Locations[i]
+		loc.QuoteMatches = make([]model.QuoteMatch, len(loc.ContextQuotes))
+		for j, quote := range loc.ContextQuotes {
+			loc.QuoteMatches[j] = check(quote)
+		}
+	}
+	for i := range ext.Relationships {
+		rel := &ext.Relationships[i]
+		rel.QuoteMatch = nil
+		if rel.Quote != "" {
+			m := check(rel.Quote)
+			rel.QuoteMatch = &m
+		}
+	}
+	return verified, total
+}
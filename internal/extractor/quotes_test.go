@@ -0,0 +1,74 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+const quoteChapter = "Erin walked out of the inn.\n\n" +
+	"The city of Liscor stood to the south, its walls green and tall. “It’s huge,” she said.\n\n" +
+	"Beyond the Floodplains, the High Passes loomed."
+
+func TestMatchQuote(t *testing.T) {
+	chapter := tokenizeQuote(quoteChapter)
+	tests := []struct {
+		name      string
+		quote     string
+		verified  bool
+		paragraph int
+	}{
+		{"verbatim", "its walls green and tall", true, 1},
+		{"punctuation and apostrophes", `"It's huge," she said`, true, 1},
+		{"dropped word", "The city Liscor stood to the south, its walls green", true, 1},
+		{"later paragraph", "the High Passes loomed", true, 2},
+		{"hallucinated", "a dragon circled the tower of Wistram", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := matchQuote(tt.quote, chapter, quoteChapter)
+			if m.Verified() != tt.verified {
+				t.Fatalf("expected verified=%v, got score %.2f", tt.verified, m.Score)
+			}
+			if tt.verified && m.Paragraph != tt.paragraph {
+				t.Errorf("expected paragraph %d, got %d", tt.paragraph, m.Paragraph)
+			}
+		})
+	}
+
+	m := matchQuote("its walls green", chapter, quoteChapter)
+	if m.Offset != strings.Index(quoteChapter, "its walls") || m.Score != 1 {
+		t.Errorf("expected exact offset of a verbatim quote, got %+v", m)
+	}
+	if m := matchQuote("", chapter, quoteChapter); m.Offset != -1 || m.Verified() {
+		t.Errorf("expected empty quote unmatched, got %+v", m)
+	}
+}
+
+func TestVerifyQuotes(t *testing.T) {
+	ext := &model.ChapterExtraction{
+		Locations: []model.ExtractedLocation{
+			{Name: "Liscor", ContextQuotes: []string{"its walls green and tall", "Liscor is a city of Drakes"}},
+			{Name: "The Wandering Inn"},
+		},
+		Relationships: []model.ExtractedRelationship{
+			{From: "Liscor", To: "The Wandering Inn", Quote: "The city of Liscor stood to the south"},
+			{From: "Liscor", To: "High Passes"},
+		},
+	}
+
+	verified, total := VerifyQuotes(ext, quoteChapter)
+	if verified != 2 || total != 3 {
+		t.Errorf("expected 2 of 3 quotes verified, got %d of %d", verified, total)
+	}
+	if m := ext.Locations[0].QuoteMatches; len(m) != 2 || !m[0].Verified() || m[1].Verified() {
+		t.Errorf("unexpected location quote matches %+v", m)
+	}
+	if !ext.Relationships[0].QuoteMatch.Verified() {
+		t.Errorf("expected relationship quote verified, got %+v", ext.Relationships[0].QuoteMatch)
+	}
+	if ext.Relationships[1].QuoteMatch != nil {
+		t.Error("expected no match for a relationship without a quote")
+	}
+}
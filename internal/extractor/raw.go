@@ -0,0 +1,53 @@
+package extractor
+
+import (
+	"slices"
+	"time"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// NewRawExtraction records a chapter's unparsed window replies, stamped with
+// the current prompt hash and time.
+func NewRawExtraction(chapterIdx int, modelName string, texts, stopReasons []string, usage Usage) *model.RawExtraction {
+	return &model.RawExtraction{
+		ChapterIndex:  chapterIdx,
+		Model:         modelName,
+		PromptHash:    PromptHash(),
+		PromptVersion: PromptVersion,
+		Responses:     texts,
+		StopReasons:   stopReasons,
+		InputTokens:   usage.InputTokens,
+		OutputTokens:  usage.OutputTokens,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// BuildExtraction parses and validates a chapter's raw replies and, when
+// chapterText is non-empty, verifies their quotes against it. The result
+// carries the raw model, prompt hash, and time, so re-parsing stored
+// replies reproduces what the original run would have saved.
+func BuildExtraction(raw *model.RawExtraction, chapterTitle, chapterText string) (*model.ChapterExtraction, error) {
+	parsed, err := ParseChapter(raw.Responses)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := &model.ChapterExtraction{
+		ChapterIndex:  raw.ChapterIndex,
+		ChapterTitle:  chapterTitle,
+		Locations:     parsed.Locations,
+		Relationships: parsed.Relationships,
+		Containment:   parsed.Containment,
+		Model:         raw.Model,
+		ExtractedAt:   raw.CreatedAt,
+		PromptHash:    raw.PromptHash,
+		PromptVersion: raw.PromptVersion,
+		Partial:       parsed.Partial || slices.Contains(raw.StopReasons, StopMaxTokens),
+		Warnings:      parsed.Warnings,
+	}
+	if chapterText != "" {
+		VerifyQuotes(ext, chapterText)
+	}
+	return ext, nil
+}
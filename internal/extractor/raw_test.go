@@ -0,0 +1,38 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestBuildExtraction(t *testing.T) {
+	raw := NewRawExtraction(2, "test-model",
+		[]string{`{"locations":[{"name":"Liscor","type":"walled city"}],"relationships":[{"from":"Liscor","to":"Floodplains","type":"adjacency","quote":"its walls green and tall"}],"containment":[]}`},
+		[]string{StopMaxTokens}, Usage{InputTokens: 100, OutputTokens: 20})
+	if raw.PromptHash != PromptHash() || raw.PromptHash == "" || raw.CreatedAt == "" {
+		t.Fatalf("expected raw response stamped with prompt hash and time, got %+v", raw)
+	}
+
+	ext, err := BuildExtraction(raw, "1.02", quoteChapter)
+	if err != nil {
+		t.Fatalf("building extraction: %v", err)
+	}
+	if ext.ChapterIndex != 2 || ext.ChapterTitle != "1.02" || ext.Model != "test-model" || ext.PromptHash != raw.PromptHash || ext.ExtractedAt != raw.CreatedAt {
+		t.Errorf("expected metadata taken from the raw response, got %+v", ext)
+	}
+	if !ext.Partial {
+		t.Error("expected a max_tokens stop reason to mark the extraction partial")
+	}
+	if len(ext.Locations) != 1 || ext.Locations[0].Type != model.LocationCity || len(ext.Warnings) == 0 {
+		t.Errorf("expected validation to run, got %+v with warnings %+v", ext.Locations, ext.Warnings)
+	}
+	if !ext.Relationships[0].QuoteMatch.Verified() {
+		t.Errorf("expected the quote verified against chapter text, got %+v", ext.Relationships[0].QuoteMatch)
+	}
+
+	raw.Responses = []string{"I could not find any locations."}
+	if _, err := BuildExtraction(raw, "1.02", ""); err == nil {
+		t.Error("expected an error for an unparseable response")
+	}
+}
@@ -0,0 +1,89 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RetryPolicy controls how transient API failures are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts per request, including the first
+	BaseDelay   time.Duration // delay before the first retry
+	MaxDelay    time.Duration // cap on any single backoff delay
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 5, BaseDelay: 2 * time.Second, MaxDelay: 2 * time.Minute}
+}
+
+// backoff returns the delay before retry number attempt (1-based). A server
+// Retry-After is always waited out in full; when it is longer than MaxDelay,
+// backoff returns an error wrapping ErrRetryAfterTooLong instead, as an
+// earlier retry would only be refused again. Otherwise the delay doubles per
+// attempt up to MaxDelay, with the upper half randomized so concurrent
+// callers spread out.
+func (p RetryPolicy) backoff(attempt int, err error) (time.Duration, error) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > p.MaxDelay {
+			return 0, fmt.Errorf("%w (%v, max backoff %v): %w", ErrRetryAfterTooLong, apiErr.RetryAfter, p.MaxDelay, err)
+		}
+		return apiErr.RetryAfter, nil
+	}
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, p.MaxDelay)
+	if d <= 0 {
+		return 0, nil
+	}
+	return d/2 + rand.N(d/2+1), nil
+}
+
+// TokenLimiter enforces a tokens-per-minute budget shared by every request in
+// a run. A nil *TokenLimiter imposes no limit.
+type TokenLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewTokenLimiter creates a limiter allowing tokensPerMinute tokens per
+// minute. It returns nil (unlimited) when tokensPerMinute is not positive.
+func NewTokenLimiter(tokensPerMinute int) *TokenLimiter {
+	if tokensPerMinute <= 0 {
+		return nil
+	}
+	return &TokenLimiter{
+		limiter: rate.NewLimiter(rate.Limit(float64(tokensPerMinute)/60), tokensPerMinute),
+	}
+}
+
+// Wait blocks until n tokens may be spent. Requests larger than the whole
+// per-minute budget wait for a full bucket rather than failing.
+func (l *TokenLimiter) Wait(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	return l.limiter.WaitN(ctx, min(n, l.limiter.Burst()))
+}
+
+// Charge records n tokens already spent (e.g. output tokens reported after a
+// response) so that later requests are delayed accordingly.
+func (l *TokenLimiter) Charge(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+	l.limiter.ReserveN(time.Now(), min(n, l.limiter.Burst()))
+}
+
+// estimateTokens approximates the token count of a prompt. English prose
+// averages about four characters per token.
+func estimateTokens(s string) int {
+	return len(s) / 4
+}
@@ -0,0 +1,82 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// salvageExtraction recovers every complete array element from a response
+// that was cut off mid-JSON, typically because the model hit max_tokens. It
+// streams the object token by token and stops at the first element that
+// fails to decode, so a half-written location is dropped rather than
+// guessed at. The result is marked Partial.
+func salvageExtraction(text string) (*extractionResponse, error) {
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return nil, fmt.Errorf("%w: no JSON object found", ErrUnparseable)
+	}
+
+	dec := json.NewDecoder(strings.NewReader(text[start:]))
+	result := &extractionResponse{Partial: true}
+	if _, err := dec.Token(); err != nil { // opening {
+		return nil, fmt.Errorf("%w: %v", ErrUnparseable, err)
+	}
+
+	recovered := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, ok := tok.(string)
+		if !ok {
+			break
+		}
+
+		var n int
+		var complete bool
+		switch key {
+		case "locations":
+			n, complete = decodeArray(dec, &result.Locations)
+		case "relationships":
+			n, complete = decodeArray(dec, &result.Relationships)
+		case "containment":
+			n, complete = decodeArray(dec, &result.Containment)
+		default:
+			var skip json.RawMessage
+			complete = dec.Decode(&skip) == nil
+		}
+		recovered += n
+		if !complete {
+			break
+		}
+	}
+
+	if recovered == 0 {
+		return nil, fmt.Errorf("%w: no complete elements in truncated response", ErrUnparseable)
+	}
+	return result, nil
+}
+
+// decodeArray appends elements of the JSON array at the decoder's position to
+// dst until the array closes or an element fails to decode. It reports how
+// many elements were decoded and whether the array was read to its end.
+func decodeArray[T any](dec *json.Decoder, dst *[]T) (int, bool) {
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return 0, false
+	}
+	n := 0
+	for dec.More() {
+		var elem T
+		if err := dec.Decode(&elem); err != nil {
+			return n, false
+		}
+		*dst = append(*dst, elem)
+		n++
+	}
+	if _, err := dec.Token(); err != nil { // closing ]
+		return n, false
+	}
+	return n, true
+}
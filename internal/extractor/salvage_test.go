@@ -0,0 +1,69 @@
+package extractor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSalvageTruncated(t *testing.T) {
+	text := `{"locations": [
+		{"name": "Liscor", "type": "city"},
+		{"name": "The Wandering Inn", "type": "building"},
+		{"name": "Flood Pla`
+
+	if _, err := ParseExtraction(text); err == nil {
+		t.Fatal("expected truncated text not to parse")
+	}
+
+	result, err := salvageExtraction(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Partial {
+		t.Error("expected Partial to be set")
+	}
+	if len(result.Locations) != 2 {
+		t.Fatalf("expected 2 complete locations, got %d", len(result.Locations))
+	}
+	if result.Locations[1].Name != "The Wandering Inn" {
+		t.Errorf("unexpected location %q", result.Locations[1].Name)
+	}
+}
+
+func TestSalvageLaterArray(t *testing.T) {
+	text := `{"locations": [{"name": "Liscor", "type": "city"}],
+		"relationships": [{"from": "Liscor", "to": "Esthelm", "type": "direction", "detail": "north"},
+		{"from": "Liscor", "to": "Celum", "ty`
+
+	result, err := salvageExtraction(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Locations) != 1 || len(result.Relationships) != 1 {
+		t.Errorf("expected 1 location and 1 relationship, got %d and %d",
+			len(result.Locations), len(result.Relationships))
+	}
+}
+
+func TestSalvageNothing(t *testing.T) {
+	_, err := salvageExtraction(`{"locations": [{"name": "Lis`)
+	if !errors.Is(err, ErrUnparseable) {
+		t.Errorf("expected ErrUnparseable, got %v", err)
+	}
+}
+
+func TestParseChapterSalvages(t *testing.T) {
+	result, err := ParseChapter([]string{
+		`{"locations": [{"name": "Liscor", "type": "city"}]}`,
+		`{"locations": [{"name": "Celum", "type": "city"}, {"name": "Es`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Partial {
+		t.Error("expected merged result to be Partial")
+	}
+	if len(result.Locations) != 2 {
+		t.Errorf("expected 2 locations, got %d", len(result.Locations))
+	}
+}
@@ -0,0 +1,79 @@
+package extractor
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// extractionToolName is the tool the model is forced to call with its
+// extraction when structured output is in use.
+const extractionToolName = "record_locations"
+
+// extractionTool declares the extraction response as a tool whose input
+// schema is derived from the model types, so the schema can't drift from
+// what ParseExtraction decodes.
+var extractionTool = &Tool{
+	Name:        extractionToolName,
+	Description: "Record every location, spatial relationship, and containment found in the chapter.",
+	InputSchema: jsonSchema(reflect.TypeOf(extractionResponse{})),
+}
+
+// schemaEnums restricts named string types to their known values.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(model.LocationType("")):     enumValues(model.LocationTypes),
+	reflect.TypeOf(model.RelationshipType("")): enumValues(model.RelationshipTypes),
+	reflect.TypeOf(model.SightingRole("")):     enumValues(model.SightingRoles),
+}
+
+func enumValues[T ~string](vals []T) []string {
+	out := make([]string, len(vals))
+	for i, v := range vals {
+		out[i] = string(v)
+	}
+	return out
+}
+
+// jsonSchema builds a JSON Schema for t from its json struct tags. Fields
+// tagged json:"-" or schema:"-" are skipped, and fields without omitempty
+// are required. Only the kinds used by the extraction types are handled.
+func jsonSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		s := map[string]any{"type": "string"}
+		if enum, ok := schemaEnums[t]; ok {
+			s["enum"] = enum
+		}
+		return s
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Struct:
+		props := make(map[string]any)
+		required := []string{}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() || f.Tag.Get("schema") == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = jsonSchema(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": props, "required": required}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,202 @@
+package extractor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+// Validation warning kinds recorded in model.ExtractionWarning.Kind.
+const (
+	WarnCoercedType       = "coerced_type"
+	WarnUnknownType       = "unknown_type"
+	WarnEmptyName         = "empty_name"
+	WarnDuplicateLocation = "duplicate_location"
+	WarnSelfReference     = "self_reference"
+	WarnDanglingReference = "dangling_reference"
+)
+
+// locationTypeSynonyms maps common near-miss location types onto the
+// model.LocationType constants. Keys are normalized with normalizeType.
+var locationTypeSynonyms = invertSynonyms(map[model.LocationType][]string{
+	model.LocationContinent:   {"landmass"},
+	model.LocationNation:      {"kingdom", "empire", "country", "realm", "republic"},
+	model.LocationCity:        {"walled_city", "capital", "metropolis"},
+	model.LocationTown:        {"settlement", "outpost"},
+	model.LocationVillage:     {"hamlet"},
+	model.LocationBuilding:    {"inn", "tavern", "shop", "temple", "castle", "palace", "tower", "guild", "academy", "room", "structure"},
+	model.LocationLandmark:    {"mountain", "mountains", "hill", "ruins", "cave", "plains", "field", "fields", "desert", "valley", "island", "bridge"},
+	model.LocationDungeon:     {"crypt", "labyrinth"},
+	model.LocationBodyOfWater: {"river", "lake", "sea", "ocean", "bay", "stream", "water", "waterfall"},
+	model.LocationForest:      {"woods", "jungle", "grove"},
+	model.LocationRoad:        {"path", "pass", "trail", "highway", "street", "route"},
+})
+
+// relationshipTypeSynonyms does the same for model.RelationshipType.
+var relationshipTypeSynonyms = invertSynonyms(map[model.RelationshipType][]string{
+	model.RelTravelTime:  {"travel", "time"},
+	model.RelContainment: {"contains", "inside", "location"},
+	model.RelAdjacency:   {"near", "nearby", "adjacent", "border", "borders"},
+	model.RelRoute:       {"road", "connection", "connected"},
+	model.RelRelative:    {"relative_position", "comparison"},
+})
+
+func invertSynonyms[T ~string](byType map[T][]string) map[string]T {
+	m := make(map[string]T)
+	for t, synonyms := range byType {
+		for _, syn := range synonyms {
+			m[syn] = t
+		}
+	}
+	return m
+}
+
+// normalizeType lowercases a type and joins its words with underscores, so
+// "Body of Water" matches "body_of_water".
+func normalizeType(t string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(t), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "_")
+}
+
+// coerceLocationType maps t onto a known LocationType. ok is false when t had
+// to be replaced by a synonym or by "other".
+func coerceLocationType(t model.LocationType) (model.LocationType, bool) {
+	norm := normalizeType(string(t))
+	if slices.Contains(model.LocationTypes, model.LocationType(norm)) {
+		return model.LocationType(norm), true
+	}
+	if lt, found := locationTypeSynonyms[norm]; found {
+		return lt, false
+	}
+	// Try the last word, so "trade_city" is a city and "underground_river"
+	// a body of water.
+	if i := strings.LastIndex(norm, "_"); i >= 0 {
+		last := norm[i+1:]
+		if slices.Contains(model.LocationTypes, model.LocationType(last)) {
+			return model.LocationType(last), false
+		}
+		if lt, found := locationTypeSynonyms[last]; found {
+			return lt, false
+		}
+	}
+	return model.LocationOther, false
+}
+
+// coerceRelationshipType maps t onto a known RelationshipType, falling back
+// to "relative" for anything unrecognized.
+func coerceRelationshipType(t model.RelationshipType) (model.RelationshipType, bool) {
+	norm := normalizeType(string(t))
+	if slices.Contains(model.RelationshipTypes, model.RelationshipType(norm)) {
+		return model.RelationshipType(norm), true
+	}
+	if rt, found := relationshipTypeSynonyms[norm]; found {
+		return rt, false
+	}
+	return model.RelRelative, false
+}
+
+// validateExtraction normalizes resp in place and returns a warning for every
+// record it coerced, merged, or dropped:
+//
+//   - names are trimmed, and records with an empty name are dropped
+//   - location and relationship types outside the model constants are mapped
+//     to the nearest known type, or to "other"/"relative"
+//   - locations repeated under the same name are merged
+//   - relationships and containment from a place to itself are dropped
+//   - relationships and containment naming a place that isn't among the
+//     chapter's locations are kept but flagged, since they often refer to a
+//     place introduced in an earlier chapter
+func validateExtraction(resp *extractionResponse) []model.ExtractionWarning {
+	var warnings []model.ExtractionWarning
+	warn := func(kind, format string, args ...any) {
+		warnings = append(warnings, model.ExtractionWarning{Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	locations := resp.Locations[:0]
+	known := make(map[string]int)
+	for _, loc := range resp.Locations {
+		loc.Name = strings.TrimSpace(loc.Name)
+		if loc.Name == "" {
+			warn(WarnEmptyName, "dropped location with empty name")
+			continue
+		}
+		lt, ok := coerceLocationType(loc.Type)
+		switch {
+		case ok:
+		case lt == model.LocationOther:
+			warn(WarnUnknownType, "%s: unknown type %q, using %q", loc.Name, loc.Type, lt)
+		default:
+			warn(WarnCoercedType, "%s: type %q coerced to %q", loc.Name, loc.Type, lt)
+		}
+		loc.Type = lt
+		key := mergeKey(loc.Name)
+		if i, dup := known[key]; dup {
+			warn(WarnDuplicateLocation, "%s: listed more than once, merged", loc.Name)
+			mergeLocation(&locations[i], loc)
+			continue
+		}
+		known[key] = len(locations)
+		locations = append(locations, loc)
+	}
+	resp.Locations = locations
+
+	// References may use a location's alias rather than its name.
+	names := make(map[string]bool)
+	for _, loc := range locations {
+		names[mergeKey(loc.Name)] = true
+		for _, alias := range loc.Aliases {
+			names[mergeKey(alias)] = true
+		}
+	}
+	isKnown := func(name string) bool { return names[mergeKey(name)] }
+
+	relationships := resp.Relationships[:0]
+	for _, rel := range resp.Relationships {
+		rel.From, rel.To = strings.TrimSpace(rel.From), strings.TrimSpace(rel.To)
+		if rel.From == "" || rel.To == "" {
+			warn(WarnEmptyName, "dropped %s relationship with an empty endpoint", rel.Type)
+			continue
+		}
+		if mergeKey(rel.From) == mergeKey(rel.To) {
+			warn(WarnSelfReference, "dropped %s relationship from %s to itself", rel.Type, rel.From)
+			continue
+		}
+		rt, ok := coerceRelationshipType(rel.Type)
+		if !ok {
+			warn(WarnCoercedType, "%s -> %s: relationship type %q coerced to %q", rel.From, rel.To, rel.Type, rt)
+		}
+		rel.Type = rt
+		for _, end := range []string{rel.From, rel.To} {
+			if !isKnown(end) {
+				warn(WarnDanglingReference, "%s -> %s: %q is not among the chapter's locations", rel.From, rel.To, end)
+			}
+		}
+		relationships = append(relationships, rel)
+	}
+	resp.Relationships = relationships
+
+	containment := resp.Containment[:0]
+	for _, c := range resp.Containment {
+		c.Child, c.Parent = strings.TrimSpace(c.Child), strings.TrimSpace(c.Parent)
+		if c.Child == "" || c.Parent == "" {
+			warn(WarnEmptyName, "dropped containment with an empty endpoint")
+			continue
+		}
+		if mergeKey(c.Child) == mergeKey(c.Parent) {
+			warn(WarnSelfReference, "dropped containment of %s in itself", c.Child)
+			continue
+		}
+		for _, end := range []string{c.Child, c.Parent} {
+			if !isKnown(end) {
+				warn(WarnDanglingReference, "%s in %s: %q is not among the chapter's locations", c.Child, c.Parent, end)
+			}
+		}
+		containment = append(containment, c)
+	}
+	resp.Containment = containment
+
+	return warnings
+}
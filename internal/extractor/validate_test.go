@@ -0,0 +1,93 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/intelligrit/twi-map/internal/model"
+)
+
+func TestCoerceLocationType(t *testing.T) {
+	tests := []struct {
+		in   model.LocationType
+		want model.LocationType
+		ok   bool
+	}{
+		{"city", model.LocationCity, true},
+		{"Body of Water", model.LocationBodyOfWater, true},
+		{"walled city", model.LocationCity, false},
+		{"river", model.LocationBodyOfWater, false},
+		{"Underground River", model.LocationBodyOfWater, false},
+		{"trade-city", model.LocationCity, false},
+		{"dimension", model.LocationOther, false},
+		{"", model.LocationOther, false},
+	}
+	for _, tt := range tests {
+		got, ok := coerceLocationType(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("coerceLocationType(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestValidateExtraction(t *testing.T) {
+	resp := &extractionResponse{
+		Locations: []model.ExtractedLocation{
+			{Name: " Liscor ", Type: "walled city", Aliases: []string{"the Walled City"}},
+			{Name: "", Type: "city"},
+			{Name: "The Floodplains", Type: "plains"},
+			{Name: "liscor", Type: "city", Description: "Longer description of the city"},
+		},
+		Relationships: []model.ExtractedRelationship{
+			{From: "Liscor", To: "Liscor", Type: "direction"},
+			{From: "The Floodplains", To: "the walled city", Type: "near"},
+			{From: "Liscor", To: "Pallass", Type: "direction"},
+			{From: "Liscor", To: " ", Type: "direction"},
+		},
+		Containment: []model.Containment{
+			{Child: "Liscor", Parent: "liscor"},
+			{Child: "Liscor", Parent: "Izril"},
+		},
+	}
+
+	warnings := validateExtraction(resp)
+
+	if len(resp.Locations) != 2 {
+		t.Fatalf("expected empty name dropped and duplicate merged, got %+v", resp.Locations)
+	}
+	if resp.Locations[0].Name != "Liscor" || resp.Locations[0].Type != model.LocationCity {
+		t.Errorf("expected trimmed, coerced Liscor, got %+v", resp.Locations[0])
+	}
+	if resp.Locations[0].Description != "Longer description of the city" {
+		t.Errorf("expected duplicate merged into first, got %+v", resp.Locations[0])
+	}
+	if resp.Locations[1].Type != model.LocationLandmark {
+		t.Errorf("expected plains coerced to landmark, got %q", resp.Locations[1].Type)
+	}
+
+	if len(resp.Relationships) != 2 {
+		t.Fatalf("expected self and empty relationships dropped, got %+v", resp.Relationships)
+	}
+	if resp.Relationships[0].Type != model.RelAdjacency {
+		t.Errorf("expected near coerced to adjacency, got %q", resp.Relationships[0].Type)
+	}
+	if len(resp.Containment) != 1 || resp.Containment[0].Parent != "Izril" {
+		t.Errorf("expected self-containment dropped, got %+v", resp.Containment)
+	}
+
+	counts := make(map[string]int)
+	for _, w := range warnings {
+		counts[w.Kind]++
+	}
+	want := map[string]int{
+		WarnCoercedType:       3, // walled city, plains, near
+		WarnEmptyName:         2,
+		WarnDuplicateLocation: 1,
+		WarnSelfReference:     2,
+		WarnDanglingReference: 2, // Pallass, Izril; the alias resolves
+	}
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("expected %d %s warnings, got %d (%+v)", n, kind, counts[kind], warnings)
+		}
+	}
+}
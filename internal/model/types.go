@@ -1,5 +1,7 @@
 package model
 
+import "math"
+
 // Chapter represents a single chapter from the TOC.
 type Chapter struct {
 	WebTitle         string `json:"web_title"`
@@ -36,6 +38,13 @@ const (
 	LocationOther       LocationType = "other"
 )
 
+// LocationTypes lists every LocationType, in prompt order.
+var LocationTypes = []LocationType{
+	LocationContinent, LocationNation, LocationCity, LocationTown, LocationVillage,
+	LocationBuilding, LocationLandmark, LocationDungeon, LocationBodyOfWater,
+	LocationForest, LocationRoad, LocationOther,
+}
+
 // RelationshipType classifies spatial relationships between locations.
 type RelationshipType string
 
@@ -49,6 +58,11 @@ const (
 	RelRelative    RelationshipType = "relative"
 )
 
+// RelationshipTypes lists every RelationshipType, in prompt order.
+var RelationshipTypes = []RelationshipType{
+	RelDistance, RelTravelTime, RelDirection, RelContainment, RelAdjacency, RelRoute, RelRelative,
+}
+
 // ExtractedLocation is a location found in a single chapter.
 type ExtractedLocation struct {
 	Name              string       `json:"name"`
@@ -57,6 +71,11 @@ type ExtractedLocation struct {
 	Description       string       `json:"description"`
 	VisualDescription string       `json:"visual_description,omitempty"`
 	ContextQuotes     []string     `json:"context_quotes,omitempty"`
+	// QuoteMatches parallels ContextQuotes once the quotes are verified.
+	QuoteMatches []QuoteMatch `json:"quote_matches,omitempty" schema:"-"`
+	// Agreement is the share of consensus samples that found the location;
+	// 0 when the chapter was extracted in a single run.
+	Agreement float64 `json:"agreement,omitempty" schema:"-"`
 }
 
 // ExtractedRelationship is a spatial relationship found in a single chapter.
@@ -66,12 +85,36 @@ type ExtractedRelationship struct {
 	Type   RelationshipType `json:"type"`
 	Detail string           `json:"detail"`
 	Quote  string           `json:"quote,omitempty"`
+	// QuoteMatch is set once Quote is verified against the chapter text.
+	QuoteMatch *QuoteMatch `json:"quote_match,omitempty" schema:"-"`
+	// Agreement is the share of consensus samples that found the
+	// relationship; 0 when the chapter was extracted in a single run.
+	Agreement float64 `json:"agreement,omitempty" schema:"-"`
+}
+
+// QuoteVerifiedScore is the similarity at or above which a quote counts as
+// found in the chapter text.
+const QuoteVerifiedScore = 0.8
+
+// QuoteMatch locates an extracted quote in its chapter's text.
+type QuoteMatch struct {
+	Offset    int     `json:"offset"`    // byte offset of the best match, -1 if none
+	Paragraph int     `json:"paragraph"` // index of the paragraph containing Offset
+	Score     float64 `json:"score"`     // share of the quote's words found there, 0-1
+}
+
+// Verified reports whether the quote was found in the chapter text.
+func (m *QuoteMatch) Verified() bool {
+	return m != nil && m.Score >= QuoteVerifiedScore
 }
 
 // Containment represents a parent-child containment relationship.
+// FirstChapterIndex is only set on aggregated containment, so it is left out
+// of the extraction schema.
 type Containment struct {
-	Child  string `json:"child"`
-	Parent string `json:"parent"`
+	Child             string `json:"child"`
+	Parent            string `json:"parent"`
+	FirstChapterIndex int    `json:"first_chapter_index" schema:"-"`
 }
 
 // ChapterExtraction is the full extraction result for one chapter.
@@ -83,6 +126,42 @@ type ChapterExtraction struct {
 	Containment   []Containment           `json:"containment"`
 	Model         string                  `json:"model"`
 	ExtractedAt   string                  `json:"extracted_at"`
+	// PromptHash identifies the prompt wording that produced the extraction.
+	PromptHash string `json:"prompt_hash,omitempty"`
+	// PromptVersion is the numbered prompt revision; 0 means unrecorded.
+	PromptVersion int `json:"prompt_version,omitempty"`
+	// Partial marks an extraction recovered from a truncated response.
+	Partial bool `json:"partial,omitempty"`
+	// Samples is how many runs were merged by vote; 0 for a single run.
+	Samples int `json:"samples,omitempty"`
+	// Warnings lists records that validation coerced, merged, or dropped.
+	Warnings []ExtractionWarning `json:"warnings,omitempty"`
+}
+
+// RawExtraction is the unparsed model output behind a chapter's extraction,
+// kept so it can be re-parsed without calling the API again. It is archived
+// and restored with the extraction it produced, and saved even when parsing
+// fails as long as no earlier extraction of the chapter is stored.
+type RawExtraction struct {
+	ChapterIndex  int      `json:"chapter_index"`
+	Model         string   `json:"model"`
+	PromptHash    string   `json:"prompt_hash"`
+	PromptVersion int      `json:"prompt_version"`
+	Responses     []string `json:"responses"`    // one per window, in order
+	StopReasons   []string `json:"stop_reasons"` // parallel to Responses
+	InputTokens   int      `json:"input_tokens"`
+	OutputTokens  int      `json:"output_tokens"`
+	CreatedAt     string   `json:"created_at"`
+	// Samples holds the other runs of a consensus extraction; the fields
+	// above describe the first run.
+	Samples []RawExtraction `json:"samples,omitempty"`
+}
+
+// ExtractionWarning records a problem found while validating a chapter's
+// extraction.
+type ExtractionWarning struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
 }
 
 // AggregatedLocation is a deduplicated location with cross-chapter data.
@@ -94,8 +173,38 @@ type AggregatedLocation struct {
 	Description       string       `json:"description"`
 	VisualDescription string       `json:"visual_description,omitempty"`
 	FirstChapterIndex int          `json:"first_chapter_index"`
-	MentionCount      int          `json:"mention_count"`
+	MentionCount      int          `json:"mention_count"` // chapters mentioning it
 	ChapterIndices    []int        `json:"chapter_indices"`
+	// Agreement is the mean consensus agreement over the chapters extracted
+	// by vote, a confidence score; 0 when none were.
+	Agreement float64 `json:"agreement,omitempty"`
+	// TypeVotes is how the chapters that mention the location typed it,
+	// the winning Type first.
+	TypeVotes []TypeVote `json:"type_votes,omitempty"`
+}
+
+// TypeVote tallies the mentions that extracted a location as one type.
+// Weight is their combined vote after discounting vague types,
+// low-agreement extractions, and earlier chapters, relative to a plain vote
+// in the location's latest chapter.
+type TypeVote struct {
+	Type             LocationType `json:"type"`
+	Mentions         int          `json:"mentions"`
+	Weight           float64      `json:"weight"`
+	LastChapterIndex int          `json:"last_chapter_index"`
+}
+
+// TypeShare is the winning type's share of the weighted type vote: 1 when
+// every mention agreed, 0 when there were no votes.
+func (l *AggregatedLocation) TypeShare() float64 {
+	var total float64
+	for _, v := range l.TypeVotes {
+		total += v.Weight
+	}
+	if total == 0 {
+		return 0
+	}
+	return l.TypeVotes[0].Weight / total
 }
 
 // AggregatedRelationship is a deduplicated relationship.
@@ -106,6 +215,51 @@ type AggregatedRelationship struct {
 	Detail            string           `json:"detail"`
 	Quote             string           `json:"quote,omitempty"`
 	FirstChapterIndex int              `json:"first_chapter_index"`
+	// QuoteVerified is set when Quote was found in the text of chapter
+	// QuoteChapterIndex, at paragraph QuoteParagraph.
+	QuoteVerified     bool `json:"quote_verified"`
+	QuoteChapterIndex int  `json:"quote_chapter_index"`
+	QuoteParagraph    int  `json:"quote_paragraph"`
+	// Agreement is the mean consensus agreement over the chapters extracted
+	// by vote, a confidence score; 0 when none were.
+	Agreement float64 `json:"agreement,omitempty"`
+	// Quotes holds the quote each chapter gave for the relationship, in
+	// chapter order, so readers are only shown quotes they have reached.
+	Quotes []RelationshipQuote `json:"quotes,omitempty"`
+}
+
+// RelationshipQuote is a relationship's quote as extracted from one chapter.
+type RelationshipQuote struct {
+	ChapterIndex int    `json:"chapter_index"`
+	Quote        string `json:"quote"`
+	Verified     bool   `json:"verified"`
+	Paragraph    int    `json:"paragraph"`
+}
+
+// SetQuoteAsOf sets Quote and its verification from the quotes of chapters
+// at or before through: the first verified quote, or failing that the first
+// quote. Quote is blank when no such chapter gave one.
+func (r *AggregatedRelationship) SetQuoteAsOf(through int) {
+	r.Quote, r.QuoteVerified, r.QuoteChapterIndex, r.QuoteParagraph = "", false, 0, 0
+	for _, q := range r.Quotes {
+		if q.ChapterIndex > through {
+			break
+		}
+		if r.Quote == "" || (q.Verified && !r.QuoteVerified) {
+			r.Quote, r.QuoteVerified, r.QuoteChapterIndex, r.QuoteParagraph = q.Quote, q.Verified, q.ChapterIndex, q.Paragraph
+		}
+		if r.QuoteVerified {
+			return
+		}
+	}
+}
+
+// DescriptionRevision is a location's description as extracted from one chapter.
+type DescriptionRevision struct {
+	LocationID        string `json:"location_id"`
+	ChapterIndex      int    `json:"chapter_index"`
+	Description       string `json:"description"`
+	VisualDescription string `json:"visual_description,omitempty"`
 }
 
 // AggregatedData is the full aggregated dataset.
@@ -113,16 +267,138 @@ type AggregatedData struct {
 	Locations     []AggregatedLocation     `json:"locations"`
 	Relationships []AggregatedRelationship `json:"relationships"`
 	Containment   []Containment            `json:"containment"`
-	AggregatedAt  string                   `json:"aggregated_at"`
+	Descriptions  []DescriptionRevision    `json:"descriptions"`
+	// Rejected lists extracted names judged not to be map locations.
+	Rejected []RejectedEntity `json:"rejected,omitempty"`
+	// Merges records each alias-driven merge of extracted locations, and
+	// each merge refused as inconsistent.
+	Merges []MergeDecision `json:"merges,omitempty"`
+	// Candidates are possible duplicates awaiting review.
+	Candidates []MergeCandidate `json:"candidates,omitempty"`
+	// Inclusion records why each location that survived filtering and
+	// merging is or isn't on the map. It is not saved.
+	Inclusion    []InclusionDecision `json:"inclusion,omitempty"`
+	AggregatedAt string              `json:"aggregated_at"`
 }
 
+// InclusionDecision records which rule of the inclusion policy put a
+// location on the map or kept it off.
+type InclusionDecision struct {
+	ID       string `json:"id"`
+	Included bool   `json:"included"`
+	Rule     string `json:"rule"`
+	Detail   string `json:"detail"`
+}
+
+// RejectedEntity is an extracted name the aggregator left off the map, such
+// as a person or organization, kept so the decision can be reviewed.
+type RejectedEntity struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Reason            string `json:"reason"`
+	Detail            string `json:"detail,omitempty"`
+	MentionCount      int    `json:"mention_count"`
+	FirstChapterIndex int    `json:"first_chapter_index"`
+}
+
+// MergeDecision records why the aggregator merged locations A and B, or why
+// it kept them apart. Via is the name they shared, if any. ID is the
+// location both became; it is empty when the merge was refused.
+type MergeDecision struct {
+	ID     string `json:"id,omitempty"`
+	A      string `json:"a"`
+	B      string `json:"b"`
+	Via    string `json:"via"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+	Merged bool   `json:"merged"`
+}
+
+// MergeCandidate is a pair of locations whose names are similar enough that
+// they may be the same place, but not so similar they were merged without
+// review. Score combines NameScore with ContextScore, the overlap of the
+// places each is related to.
+type MergeCandidate struct {
+	A            string  `json:"a"`
+	B            string  `json:"b"`
+	Score        float64 `json:"score"`
+	NameScore    float64 `json:"name_score"`
+	ContextScore float64 `json:"context_score"`
+}
+
+// MergeReview is a reviewer's verdict on merging locations A and B, which
+// every later aggregation honors.
+type MergeReview struct {
+	A          string `json:"a"`
+	B          string `json:"b"`
+	Accepted   bool   `json:"accepted"`
+	ReviewedAt string `json:"reviewed_at"`
+}
+
+// SightingRole says how a character stands toward a location in a chapter.
+type SightingRole string
+
+const (
+	SightingPresent      SightingRole = "present"
+	SightingTravellingTo SightingRole = "travelling_to"
+	SightingDeparted     SightingRole = "departed"
+)
+
+// SightingRoles lists every SightingRole, in prompt order.
+var SightingRoles = []SightingRole{SightingPresent, SightingTravellingTo, SightingDeparted}
+
+// CharacterSighting places a named character at, toward, or away from a
+// location in a single chapter.
+type CharacterSighting struct {
+	Character string       `json:"character"`
+	Location  string       `json:"location"`
+	Role      SightingRole `json:"role"`
+	Quote     string       `json:"quote,omitempty"`
+}
+
+// ExtractedEvent is a named event, such as a battle, found in a single chapter.
+type ExtractedEvent struct {
+	Name        string `json:"name"`
+	Location    string `json:"location"`
+	Description string `json:"description"`
+	Quote       string `json:"quote,omitempty"`
+}
+
+// ChapterCharacters is the character and event extraction for one chapter,
+// made separately from the location extraction.
+type ChapterCharacters struct {
+	ChapterIndex int                 `json:"chapter_index"`
+	ChapterTitle string              `json:"chapter_title"`
+	Sightings    []CharacterSighting `json:"sightings"`
+	Events       []ExtractedEvent    `json:"events"`
+	Model        string              `json:"model"`
+	ExtractedAt  string              `json:"extracted_at"`
+	PromptHash   string              `json:"prompt_hash,omitempty"`
+}
+
+// CharacterLocation is a character's last known whereabouts as of some chapter.
+type CharacterLocation struct {
+	Character    string       `json:"character"`
+	Location     string       `json:"location"`
+	LocationID   string       `json:"location_id,omitempty"`
+	Role         SightingRole `json:"role"`
+	ChapterIndex int          `json:"chapter_index"`
+	Quote        string       `json:"quote,omitempty"`
+}
+
+// UnrevealedChapter is the FirstChapterIndex of data that no extracted chapter
+// supports yet (e.g. a seed position for a place the story hasn't reached).
+// It sorts after every real chapter, so "through" filters always exclude it.
+const UnrevealedChapter = math.MaxInt32
+
 // Coordinate holds map coordinates for a location.
 type Coordinate struct {
-	LocationID string  `json:"location_id"`
-	X          float64 `json:"x"`
-	Y          float64 `json:"y"`
-	Confidence string  `json:"confidence"` // "high", "medium", "low", "estimated"
-	Manual     bool    `json:"manual"`
+	LocationID        string  `json:"location_id"`
+	X                 float64 `json:"x"`
+	Y                 float64 `json:"y"`
+	Confidence        string  `json:"confidence"` // "high", "medium", "low", "estimated"
+	Manual            bool    `json:"manual"`
+	FirstChapterIndex int     `json:"first_chapter_index"`
 }
 
 // CoordinateData is the full coordinate file.
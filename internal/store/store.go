@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"github.com/intelligrit/twi-map/internal/model"
@@ -44,6 +48,11 @@ func (s *Store) Close() error {
 }
 
 func (s *Store) migrate() error {
+	// Rows never stamped with the chapter that reveals them, such as those
+	// from before the column existed, stay hidden from readers until
+	// 'aggregate' stamps them.
+	unrevealed := strconv.Itoa(model.UnrevealedChapter)
+
 	stmts := []string{
 		`CREATE TABLE IF NOT EXISTS chapters (
 			idx INTEGER PRIMARY KEY,
@@ -62,7 +71,35 @@ func (s *Store) migrate() error {
 		`CREATE TABLE IF NOT EXISTS extraction_meta (
 			chapter_idx INTEGER PRIMARY KEY REFERENCES chapters(idx),
 			model TEXT NOT NULL,
-			extracted_at TEXT NOT NULL
+			extracted_at TEXT NOT NULL,
+			partial BOOLEAN DEFAULT false,
+			warnings TEXT,
+			prompt_hash TEXT,
+			prompt_version INTEGER,
+			samples INTEGER
+		)`,
+		`CREATE TABLE IF NOT EXISTS extraction_versions (
+			chapter_idx INTEGER NOT NULL REFERENCES chapters(idx),
+			version INTEGER NOT NULL,
+			model TEXT NOT NULL,
+			extracted_at TEXT NOT NULL,
+			prompt_version INTEGER,
+			archived_at TEXT NOT NULL,
+			data TEXT NOT NULL,
+			raw TEXT,
+			PRIMARY KEY (chapter_idx, version)
+		)`,
+		`CREATE TABLE IF NOT EXISTS raw_responses (
+			chapter_idx INTEGER PRIMARY KEY REFERENCES chapters(idx),
+			model TEXT NOT NULL,
+			prompt_hash TEXT,
+			prompt_version INTEGER,
+			responses TEXT NOT NULL,
+			stop_reasons TEXT,
+			input_tokens INTEGER,
+			output_tokens INTEGER,
+			created_at TEXT NOT NULL,
+			samples TEXT
 		)`,
 		`CREATE TABLE IF NOT EXISTS extracted_locations (
 			id INTEGER PRIMARY KEY DEFAULT nextval('extracted_locations_seq'),
@@ -72,7 +109,9 @@ func (s *Store) migrate() error {
 			aliases TEXT,
 			description TEXT,
 			visual_description TEXT,
-			context_quotes TEXT
+			context_quotes TEXT,
+			quote_matches TEXT,
+			agreement DOUBLE
 		)`,
 		`CREATE TABLE IF NOT EXISTS extracted_relationships (
 			id INTEGER PRIMARY KEY DEFAULT nextval('extracted_relationships_seq'),
@@ -81,7 +120,9 @@ func (s *Store) migrate() error {
 			to_loc TEXT NOT NULL,
 			type TEXT NOT NULL,
 			detail TEXT,
-			quote TEXT
+			quote TEXT,
+			quote_match TEXT,
+			agreement DOUBLE
 		)`,
 		`CREATE TABLE IF NOT EXISTS extracted_containment (
 			id INTEGER PRIMARY KEY DEFAULT nextval('extracted_containment_seq'),
@@ -89,6 +130,28 @@ func (s *Store) migrate() error {
 			child TEXT NOT NULL,
 			parent TEXT NOT NULL
 		)`,
+		`CREATE TABLE IF NOT EXISTS character_extraction_meta (
+			chapter_idx INTEGER PRIMARY KEY REFERENCES chapters(idx),
+			model TEXT NOT NULL,
+			extracted_at TEXT NOT NULL,
+			prompt_hash TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS character_sightings (
+			id INTEGER PRIMARY KEY DEFAULT nextval('character_sightings_seq'),
+			chapter_idx INTEGER NOT NULL REFERENCES chapters(idx),
+			character TEXT NOT NULL,
+			location TEXT NOT NULL,
+			role TEXT NOT NULL,
+			quote TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS extracted_events (
+			id INTEGER PRIMARY KEY DEFAULT nextval('extracted_events_seq'),
+			chapter_idx INTEGER NOT NULL REFERENCES chapters(idx),
+			name TEXT NOT NULL,
+			location TEXT,
+			description TEXT,
+			quote TEXT
+		)`,
 		`CREATE TABLE IF NOT EXISTS locations (
 			id TEXT PRIMARY KEY,
 			name TEXT NOT NULL,
@@ -98,7 +161,9 @@ func (s *Store) migrate() error {
 			visual_description TEXT,
 			first_chapter_idx INTEGER NOT NULL,
 			mention_count INTEGER NOT NULL,
-			chapter_indices TEXT
+			chapter_indices TEXT,
+			agreement DOUBLE,
+			type_votes TEXT
 		)`,
 		`CREATE TABLE IF NOT EXISTS relationships (
 			id INTEGER PRIMARY KEY DEFAULT nextval('relationships_seq'),
@@ -106,19 +171,65 @@ func (s *Store) migrate() error {
 			to_loc TEXT NOT NULL,
 			type TEXT NOT NULL,
 			detail TEXT,
-			first_chapter_idx INTEGER NOT NULL
+			first_chapter_idx INTEGER NOT NULL,
+			quote_verified BOOLEAN DEFAULT false,
+			quote_chapter_idx INTEGER,
+			quote_paragraph INTEGER,
+			agreement DOUBLE,
+			quotes TEXT
 		)`,
 		`CREATE TABLE IF NOT EXISTS containment (
 			id INTEGER PRIMARY KEY DEFAULT nextval('containment_seq'),
 			child TEXT NOT NULL,
-			parent TEXT NOT NULL
+			parent TEXT NOT NULL,
+			first_chapter_idx INTEGER NOT NULL DEFAULT ` + unrevealed + `
+		)`,
+		`CREATE TABLE IF NOT EXISTS location_descriptions (
+			location_id TEXT NOT NULL,
+			chapter_idx INTEGER NOT NULL,
+			description TEXT,
+			visual_description TEXT,
+			PRIMARY KEY (location_id, chapter_idx)
+		)`,
+		`CREATE TABLE IF NOT EXISTS rejected_entities (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			detail TEXT,
+			mention_count INTEGER NOT NULL,
+			first_chapter_idx INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS merge_decisions (
+			a TEXT NOT NULL,
+			b TEXT NOT NULL,
+			resolved_id TEXT,
+			via TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			detail TEXT,
+			merged BOOLEAN NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS merge_candidates (
+			a TEXT NOT NULL,
+			b TEXT NOT NULL,
+			score DOUBLE NOT NULL,
+			name_score DOUBLE NOT NULL,
+			context_score DOUBLE NOT NULL,
+			PRIMARY KEY (a, b)
+		)`,
+		`CREATE TABLE IF NOT EXISTS merge_reviews (
+			a TEXT NOT NULL,
+			b TEXT NOT NULL,
+			accepted BOOLEAN NOT NULL,
+			reviewed_at TEXT NOT NULL,
+			PRIMARY KEY (a, b)
 		)`,
 		`CREATE TABLE IF NOT EXISTS coordinates (
 			location_id TEXT PRIMARY KEY,
 			x DOUBLE NOT NULL,
 			y DOUBLE NOT NULL,
 			confidence TEXT NOT NULL DEFAULT 'estimated',
-			manual BOOLEAN NOT NULL DEFAULT false
+			manual BOOLEAN NOT NULL DEFAULT false,
+			first_chapter_idx INTEGER NOT NULL DEFAULT ` + unrevealed + `
 		)`,
 		`CREATE TABLE IF NOT EXISTS meta (
 			key TEXT PRIMARY KEY,
@@ -131,6 +242,8 @@ func (s *Store) migrate() error {
 		"CREATE SEQUENCE IF NOT EXISTS extracted_locations_seq",
 		"CREATE SEQUENCE IF NOT EXISTS extracted_relationships_seq",
 		"CREATE SEQUENCE IF NOT EXISTS extracted_containment_seq",
+		"CREATE SEQUENCE IF NOT EXISTS character_sightings_seq",
+		"CREATE SEQUENCE IF NOT EXISTS extracted_events_seq",
 		"CREATE SEQUENCE IF NOT EXISTS relationships_seq",
 		"CREATE SEQUENCE IF NOT EXISTS containment_seq",
 	}
@@ -151,6 +264,29 @@ func (s *Store) migrate() error {
 		"ALTER TABLE extracted_locations ADD COLUMN visual_description TEXT",
 		"ALTER TABLE locations ADD COLUMN visual_description TEXT",
 		"ALTER TABLE relationships ADD COLUMN quote TEXT",
+		"ALTER TABLE containment ADD COLUMN first_chapter_idx INTEGER DEFAULT " + unrevealed,
+		"ALTER TABLE coordinates ADD COLUMN first_chapter_idx INTEGER DEFAULT " + unrevealed,
+		"ALTER TABLE containment ALTER COLUMN first_chapter_idx SET DEFAULT " + unrevealed,
+		"ALTER TABLE coordinates ALTER COLUMN first_chapter_idx SET DEFAULT " + unrevealed,
+		"ALTER TABLE extraction_meta ADD COLUMN partial BOOLEAN DEFAULT false",
+		"ALTER TABLE extraction_meta ADD COLUMN warnings TEXT",
+		"ALTER TABLE extraction_meta ADD COLUMN prompt_hash TEXT",
+		"ALTER TABLE extraction_meta ADD COLUMN prompt_version INTEGER",
+		"ALTER TABLE raw_responses ADD COLUMN prompt_version INTEGER",
+		"ALTER TABLE extracted_locations ADD COLUMN quote_matches TEXT",
+		"ALTER TABLE extracted_relationships ADD COLUMN quote_match TEXT",
+		"ALTER TABLE relationships ADD COLUMN quote_verified BOOLEAN DEFAULT false",
+		"ALTER TABLE relationships ADD COLUMN quote_chapter_idx INTEGER",
+		"ALTER TABLE relationships ADD COLUMN quote_paragraph INTEGER",
+		"ALTER TABLE extraction_meta ADD COLUMN samples INTEGER",
+		"ALTER TABLE raw_responses ADD COLUMN samples TEXT",
+		"ALTER TABLE extracted_locations ADD COLUMN agreement DOUBLE",
+		"ALTER TABLE extracted_relationships ADD COLUMN agreement DOUBLE",
+		"ALTER TABLE locations ADD COLUMN agreement DOUBLE",
+		"ALTER TABLE relationships ADD COLUMN agreement DOUBLE",
+		"ALTER TABLE locations ADD COLUMN type_votes TEXT",
+		"ALTER TABLE relationships ADD COLUMN quotes TEXT",
+		"ALTER TABLE extraction_versions ADD COLUMN raw TEXT",
 	}
 	for _, alt := range alters {
 		s.DB.Exec(alt) // ignore errors (column already exists)
@@ -235,14 +371,71 @@ func (s *Store) ChapterTextExists(chapterIdx int) bool {
 	return n == 1
 }
 
-// WriteExtraction saves a chapter's extraction results.
+// WriteExtraction saves a chapter's extraction results. An existing
+// extraction from a different run (a different ExtractedAt) is archived as a
+// prior version first, along with the raw responses behind it, so both can
+// be restored with RollbackExtraction; rewrites of the same run, such as
+// quote verification, replace it in place.
 func (s *Store) WriteExtraction(ext *model.ChapterExtraction) error {
+	return s.WriteExtractionWithRaw(ext, nil)
+}
+
+// WriteExtractionWithRaw saves a chapter's extraction as WriteExtraction
+// does and, in the same transaction, replaces its raw responses with raw,
+// so the stored replies always match the stored extraction. A nil raw keeps
+// the stored responses.
+func (s *Store) WriteExtractionWithRaw(ext *model.ChapterExtraction, raw *model.RawExtraction) error {
+	var prior *model.ChapterExtraction
+	var priorRaw any // NULL when no raw response is stored
+	if s.ExtractionExists(ext.ChapterIndex) {
+		cur, err := s.ReadExtraction(ext.ChapterIndex)
+		if err != nil {
+			return fmt.Errorf("reading current extraction: %w", err)
+		}
+		if cur.ExtractedAt != ext.ExtractedAt {
+			prior = cur
+			if s.RawExtractionExists(ext.ChapterIndex) {
+				curRaw, err := s.ReadRawExtraction(ext.ChapterIndex)
+				if err != nil {
+					return fmt.Errorf("reading current raw response: %w", err)
+				}
+				b, _ := json.Marshal(curRaw)
+				priorRaw = string(b)
+			}
+		}
+	}
+
 	tx, err := s.DB.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if prior != nil {
+		data, err := json.Marshal(prior)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO extraction_versions (chapter_idx, version, model, extracted_at, prompt_version, archived_at, data, raw)
+			SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ?, ?, ? FROM extraction_versions WHERE chapter_idx = ?`,
+			prior.ChapterIndex, prior.Model, prior.ExtractedAt, prior.PromptVersion,
+			time.Now().UTC().Format(time.RFC3339), string(data), priorRaw, prior.ChapterIndex); err != nil {
+			return fmt.Errorf("archiving extraction: %w", err)
+		}
+	}
+	if raw != nil {
+		if err := writeRawExtraction(tx, raw); err != nil {
+			return fmt.Errorf("saving raw response: %w", err)
+		}
+	}
+	if err := writeExtraction(tx, ext); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// writeExtraction replaces a chapter's extraction rows with ext within tx.
+func writeExtraction(tx *sql.Tx, ext *model.ChapterExtraction) error {
 	// Clear any previous extraction for this chapter.
 	// Table names are compile-time constants, not user input.
 	for _, tbl := range []string{"extracted_locations", "extracted_relationships", "extracted_containment", "extraction_meta"} {
@@ -252,8 +445,9 @@ func (s *Store) WriteExtraction(ext *model.ChapterExtraction) error {
 	}
 
 	// Insert meta
-	if _, err := tx.Exec("INSERT INTO extraction_meta (chapter_idx, model, extracted_at) VALUES (?, ?, ?)",
-		ext.ChapterIndex, ext.Model, ext.ExtractedAt); err != nil {
+	warnings, _ := json.Marshal(ext.Warnings)
+	if _, err := tx.Exec("INSERT INTO extraction_meta (chapter_idx, model, extracted_at, partial, warnings, prompt_hash, prompt_version, samples) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		ext.ChapterIndex, ext.Model, ext.ExtractedAt, ext.Partial, string(warnings), ext.PromptHash, ext.PromptVersion, ext.Samples); err != nil {
 		return err
 	}
 
@@ -261,16 +455,26 @@ func (s *Store) WriteExtraction(ext *model.ChapterExtraction) error {
 	for _, loc := range ext.Locations {
 		aliases, _ := json.Marshal(loc.Aliases)
 		quotes, _ := json.Marshal(loc.ContextQuotes)
-		if _, err := tx.Exec("INSERT INTO extracted_locations (chapter_idx, name, type, aliases, description, visual_description, context_quotes) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			ext.ChapterIndex, loc.Name, loc.Type, string(aliases), loc.Description, loc.VisualDescription, string(quotes)); err != nil {
+		var matches any // NULL until the quotes are verified
+		if loc.QuoteMatches != nil {
+			b, _ := json.Marshal(loc.QuoteMatches)
+			matches = string(b)
+		}
+		if _, err := tx.Exec("INSERT INTO extracted_locations (chapter_idx, name, type, aliases, description, visual_description, context_quotes, quote_matches, agreement) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			ext.ChapterIndex, loc.Name, loc.Type, string(aliases), loc.Description, loc.VisualDescription, string(quotes), matches, loc.Agreement); err != nil {
 			return err
 		}
 	}
 
 	// Insert relationships
 	for _, rel := range ext.Relationships {
-		if _, err := tx.Exec("INSERT INTO extracted_relationships (chapter_idx, from_loc, to_loc, type, detail, quote) VALUES (?, ?, ?, ?, ?, ?)",
-			ext.ChapterIndex, rel.From, rel.To, rel.Type, rel.Detail, rel.Quote); err != nil {
+		var match any
+		if rel.QuoteMatch != nil {
+			b, _ := json.Marshal(rel.QuoteMatch)
+			match = string(b)
+		}
+		if _, err := tx.Exec("INSERT INTO extracted_relationships (chapter_idx, from_loc, to_loc, type, detail, quote, quote_match, agreement) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			ext.ChapterIndex, rel.From, rel.To, rel.Type, rel.Detail, rel.Quote, match, rel.Agreement); err != nil {
 			return err
 		}
 	}
@@ -283,7 +487,7 @@ func (s *Store) WriteExtraction(ext *model.ChapterExtraction) error {
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 // ReadExtraction loads a chapter's extraction results.
@@ -291,27 +495,39 @@ func (s *Store) ReadExtraction(chapterIdx int) (*model.ChapterExtraction, error)
 	ext := &model.ChapterExtraction{ChapterIndex: chapterIdx}
 
 	// Meta
-	err := s.DB.QueryRow("SELECT model, extracted_at FROM extraction_meta WHERE chapter_idx = ?", chapterIdx).
-		Scan(&ext.Model, &ext.ExtractedAt)
+	var partial sql.NullBool
+	var warnings, promptHash sql.NullString
+	var promptVersion, samples sql.NullInt64
+	err := s.DB.QueryRow("SELECT model, extracted_at, partial, warnings, prompt_hash, prompt_version, samples FROM extraction_meta WHERE chapter_idx = ?", chapterIdx).
+		Scan(&ext.Model, &ext.ExtractedAt, &partial, &warnings, &promptHash, &promptVersion, &samples)
 	if err != nil {
 		return nil, err
 	}
+	ext.Partial = partial.Bool
+	ext.Samples = int(samples.Int64)
+	ext.PromptHash = promptHash.String
+	ext.PromptVersion = int(promptVersion.Int64)
+	if warnings.Valid {
+		json.Unmarshal([]byte(warnings.String), &ext.Warnings)
+	}
 
 	// Chapter title
 	s.DB.QueryRow("SELECT web_title FROM chapters WHERE idx = ?", chapterIdx).Scan(&ext.ChapterTitle)
 
 	// Locations
-	rows, err := s.DB.Query("SELECT name, type, aliases, description, visual_description, context_quotes FROM extracted_locations WHERE chapter_idx = ?", chapterIdx)
+	rows, err := s.DB.Query("SELECT name, type, aliases, description, visual_description, context_quotes, quote_matches, agreement FROM extracted_locations WHERE chapter_idx = ?", chapterIdx)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var loc model.ExtractedLocation
-		var aliases, quotes, visualDesc sql.NullString
-		if err := rows.Scan(&loc.Name, &loc.Type, &aliases, &loc.Description, &visualDesc, &quotes); err != nil {
+		var aliases, quotes, visualDesc, matches sql.NullString
+		var agreement sql.NullFloat64
+		if err := rows.Scan(&loc.Name, &loc.Type, &aliases, &loc.Description, &visualDesc, &quotes, &matches, &agreement); err != nil {
 			return nil, err
 		}
+		loc.Agreement = agreement.Float64
 		if aliases.Valid {
 			json.Unmarshal([]byte(aliases.String), &loc.Aliases)
 		}
@@ -321,20 +537,29 @@ func (s *Store) ReadExtraction(chapterIdx int) (*model.ChapterExtraction, error)
 		if quotes.Valid {
 			json.Unmarshal([]byte(quotes.String), &loc.ContextQuotes)
 		}
+		if matches.Valid {
+			json.Unmarshal([]byte(matches.String), &loc.QuoteMatches)
+		}
 		ext.Locations = append(ext.Locations, loc)
 	}
 
 	// Relationships
-	relRows, err := s.DB.Query("SELECT from_loc, to_loc, type, detail, quote FROM extracted_relationships WHERE chapter_idx = ?", chapterIdx)
+	relRows, err := s.DB.Query("SELECT from_loc, to_loc, type, detail, quote, quote_match, agreement FROM extracted_relationships WHERE chapter_idx = ?", chapterIdx)
 	if err != nil {
 		return nil, err
 	}
 	defer relRows.Close()
 	for relRows.Next() {
 		var rel model.ExtractedRelationship
-		if err := relRows.Scan(&rel.From, &rel.To, &rel.Type, &rel.Detail, &rel.Quote); err != nil {
+		var match sql.NullString
+		var agreement sql.NullFloat64
+		if err := relRows.Scan(&rel.From, &rel.To, &rel.Type, &rel.Detail, &rel.Quote, &match, &agreement); err != nil {
 			return nil, err
 		}
+		rel.Agreement = agreement.Float64
+		if match.Valid {
+			json.Unmarshal([]byte(match.String), &rel.QuoteMatch)
+		}
 		ext.Relationships = append(ext.Relationships, rel)
 	}
 
@@ -362,6 +587,377 @@ func (s *Store) ExtractionExists(chapterIdx int) bool {
 	return n == 1
 }
 
+// ReadExtractionMeta returns every chapter's extraction metadata (model,
+// time, prompt, and partial flag) keyed by chapter index, without its records.
+func (s *Store) ReadExtractionMeta() (map[int]*model.ChapterExtraction, error) {
+	rows, err := s.DB.Query("SELECT chapter_idx, model, extracted_at, partial, prompt_hash, prompt_version FROM extraction_meta")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metas := make(map[int]*model.ChapterExtraction)
+	for rows.Next() {
+		ext := &model.ChapterExtraction{}
+		var partial sql.NullBool
+		var promptHash sql.NullString
+		var promptVersion sql.NullInt64
+		if err := rows.Scan(&ext.ChapterIndex, &ext.Model, &ext.ExtractedAt, &partial, &promptHash, &promptVersion); err != nil {
+			return nil, err
+		}
+		ext.Partial = partial.Bool
+		ext.PromptHash = promptHash.String
+		ext.PromptVersion = int(promptVersion.Int64)
+		metas[ext.ChapterIndex] = ext
+	}
+	return metas, rows.Err()
+}
+
+// ExtractionVersion describes an archived prior extraction of a chapter.
+type ExtractionVersion struct {
+	ChapterIndex  int    `json:"chapter_index"`
+	Version       int    `json:"version"`
+	Model         string `json:"model"`
+	ExtractedAt   string `json:"extracted_at"`
+	PromptVersion int    `json:"prompt_version"`
+	ArchivedAt    string `json:"archived_at"`
+}
+
+// ExtractionVersions lists a chapter's archived extractions, newest first.
+func (s *Store) ExtractionVersions(chapterIdx int) ([]ExtractionVersion, error) {
+	rows, err := s.DB.Query(`SELECT version, model, extracted_at, prompt_version, archived_at
+		FROM extraction_versions WHERE chapter_idx = ? ORDER BY version DESC`, chapterIdx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []ExtractionVersion
+	for rows.Next() {
+		v := ExtractionVersion{ChapterIndex: chapterIdx}
+		var promptVersion sql.NullInt64
+		if err := rows.Scan(&v.Version, &v.Model, &v.ExtractedAt, &promptVersion, &v.ArchivedAt); err != nil {
+			return nil, err
+		}
+		v.PromptVersion = int(promptVersion.Int64)
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// ReadExtractionVersion loads one archived extraction of a chapter, or
+// returns sql.ErrNoRows if there is no such version.
+func (s *Store) ReadExtractionVersion(chapterIdx, version int) (*model.ChapterExtraction, error) {
+	var data string
+	err := s.DB.QueryRow("SELECT data FROM extraction_versions WHERE chapter_idx = ? AND version = ?", chapterIdx, version).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+	var ext model.ChapterExtraction
+	if err := json.Unmarshal([]byte(data), &ext); err != nil {
+		return nil, fmt.Errorf("decoding archived extraction: %w", err)
+	}
+	return &ext, nil
+}
+
+// RollbackExtraction discards a chapter's current extraction and restores
+// its most recent archived version, with the raw responses behind it, which
+// is removed from the archive. If no raw responses were archived with it, the
+// current ones are deleted, so they can't be re-parsed over the rollback. It
+// returns the restored extraction, or sql.ErrNoRows if there is none.
+func (s *Store) RollbackExtraction(chapterIdx int) (*model.ChapterExtraction, error) {
+	var version int
+	var data string
+	var rawData sql.NullString
+	err := s.DB.QueryRow(`SELECT version, data, raw FROM extraction_versions
+		WHERE chapter_idx = ? ORDER BY version DESC LIMIT 1`, chapterIdx).Scan(&version, &data, &rawData)
+	if err != nil {
+		return nil, err
+	}
+	var ext model.ChapterExtraction
+	if err := json.Unmarshal([]byte(data), &ext); err != nil {
+		return nil, fmt.Errorf("decoding archived extraction: %w", err)
+	}
+	var raw *model.RawExtraction
+	if rawData.Valid {
+		raw = &model.RawExtraction{}
+		if err := json.Unmarshal([]byte(rawData.String), raw); err != nil {
+			return nil, fmt.Errorf("decoding archived raw response: %w", err)
+		}
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := writeExtraction(tx, &ext); err != nil {
+		return nil, err
+	}
+	if raw != nil {
+		err = writeRawExtraction(tx, raw)
+	} else {
+		_, err = tx.Exec("DELETE FROM raw_responses WHERE chapter_idx = ?", chapterIdx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("restoring raw response: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM extraction_versions WHERE chapter_idx = ? AND version = ?", chapterIdx, version); err != nil {
+		return nil, err
+	}
+	return &ext, tx.Commit()
+}
+
+// WriteRawExtraction saves the unparsed responses for a chapter, replacing
+// any earlier ones. Use WriteExtractionWithRaw instead once the responses
+// have been parsed, so they are archived with the extraction they produced.
+func (s *Store) WriteRawExtraction(raw *model.RawExtraction) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := writeRawExtraction(tx, raw); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// writeRawExtraction replaces a chapter's raw responses with raw within tx.
+func writeRawExtraction(tx *sql.Tx, raw *model.RawExtraction) error {
+	responses, _ := json.Marshal(raw.Responses)
+	stops, _ := json.Marshal(raw.StopReasons)
+	var samples any // NULL for a single run
+	if len(raw.Samples) > 0 {
+		b, _ := json.Marshal(raw.Samples)
+		samples = string(b)
+	}
+	_, err := tx.Exec(`INSERT OR REPLACE INTO raw_responses
+		(chapter_idx, model, prompt_hash, prompt_version, responses, stop_reasons, input_tokens, output_tokens, created_at, samples)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		raw.ChapterIndex, raw.Model, raw.PromptHash, raw.PromptVersion, string(responses), string(stops),
+		raw.InputTokens, raw.OutputTokens, raw.CreatedAt, samples)
+	return err
+}
+
+// ReadRawExtraction loads the unparsed responses for a chapter.
+func (s *Store) ReadRawExtraction(chapterIdx int) (*model.RawExtraction, error) {
+	raw := &model.RawExtraction{ChapterIndex: chapterIdx}
+	var promptHash, stops, samples sql.NullString
+	var responses string
+	var promptVersion, inTok, outTok sql.NullInt64
+	err := s.DB.QueryRow(`SELECT model, prompt_hash, prompt_version, responses, stop_reasons, input_tokens, output_tokens, created_at, samples
+		FROM raw_responses WHERE chapter_idx = ?`, chapterIdx).
+		Scan(&raw.Model, &promptHash, &promptVersion, &responses, &stops, &inTok, &outTok, &raw.CreatedAt, &samples)
+	if err != nil {
+		return nil, err
+	}
+	raw.PromptHash = promptHash.String
+	raw.PromptVersion = int(promptVersion.Int64)
+	raw.InputTokens, raw.OutputTokens = int(inTok.Int64), int(outTok.Int64)
+	if err := json.Unmarshal([]byte(responses), &raw.Responses); err != nil {
+		return nil, fmt.Errorf("decoding raw responses: %w", err)
+	}
+	if stops.Valid {
+		json.Unmarshal([]byte(stops.String), &raw.StopReasons)
+	}
+	if samples.Valid {
+		if err := json.Unmarshal([]byte(samples.String), &raw.Samples); err != nil {
+			return nil, fmt.Errorf("decoding raw samples: %w", err)
+		}
+	}
+	return raw, nil
+}
+
+// RawExtractionExists checks if unparsed responses are stored for a chapter.
+func (s *Store) RawExtractionExists(chapterIdx int) bool {
+	var n int
+	s.DB.QueryRow("SELECT 1 FROM raw_responses WHERE chapter_idx = ?", chapterIdx).Scan(&n)
+	return n == 1
+}
+
+// WriteCharacters saves a chapter's character and event extraction,
+// replacing any earlier one. Sightings keep their order within the chapter.
+func (s *Store) WriteCharacters(ch *model.ChapterCharacters) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Table names are compile-time constants, not user input.
+	for _, tbl := range []string{"character_sightings", "extracted_events", "character_extraction_meta"} {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE chapter_idx = ?", tbl), ch.ChapterIndex); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO character_extraction_meta (chapter_idx, model, extracted_at, prompt_hash) VALUES (?, ?, ?, ?)",
+		ch.ChapterIndex, ch.Model, ch.ExtractedAt, ch.PromptHash); err != nil {
+		return err
+	}
+	for _, sg := range ch.Sightings {
+		if _, err := tx.Exec("INSERT INTO character_sightings (chapter_idx, character, location, role, quote) VALUES (?, ?, ?, ?, ?)",
+			ch.ChapterIndex, sg.Character, sg.Location, sg.Role, sg.Quote); err != nil {
+			return err
+		}
+	}
+	for _, ev := range ch.Events {
+		if _, err := tx.Exec("INSERT INTO extracted_events (chapter_idx, name, location, description, quote) VALUES (?, ?, ?, ?, ?)",
+			ch.ChapterIndex, ev.Name, ev.Location, ev.Description, ev.Quote); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReadCharacters loads a chapter's character and event extraction.
+func (s *Store) ReadCharacters(chapterIdx int) (*model.ChapterCharacters, error) {
+	ch := &model.ChapterCharacters{ChapterIndex: chapterIdx}
+	var promptHash sql.NullString
+	err := s.DB.QueryRow("SELECT model, extracted_at, prompt_hash FROM character_extraction_meta WHERE chapter_idx = ?", chapterIdx).
+		Scan(&ch.Model, &ch.ExtractedAt, &promptHash)
+	if err != nil {
+		return nil, err
+	}
+	ch.PromptHash = promptHash.String
+	s.DB.QueryRow("SELECT web_title FROM chapters WHERE idx = ?", chapterIdx).Scan(&ch.ChapterTitle)
+
+	rows, err := s.DB.Query("SELECT character, location, role, quote FROM character_sightings WHERE chapter_idx = ? ORDER BY id", chapterIdx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sg model.CharacterSighting
+		var quote sql.NullString
+		if err := rows.Scan(&sg.Character, &sg.Location, &sg.Role, &quote); err != nil {
+			return nil, err
+		}
+		sg.Quote = quote.String
+		ch.Sightings = append(ch.Sightings, sg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	evRows, err := s.DB.Query("SELECT name, location, description, quote FROM extracted_events WHERE chapter_idx = ? ORDER BY id", chapterIdx)
+	if err != nil {
+		return nil, err
+	}
+	defer evRows.Close()
+	for evRows.Next() {
+		var ev model.ExtractedEvent
+		var loc, desc, quote sql.NullString
+		if err := evRows.Scan(&ev.Name, &loc, &desc, &quote); err != nil {
+			return nil, err
+		}
+		ev.Location, ev.Description, ev.Quote = loc.String, desc.String, quote.String
+		ch.Events = append(ch.Events, ev)
+	}
+	return ch, evRows.Err()
+}
+
+// CharactersExtracted checks if a chapter has a character extraction.
+func (s *Store) CharactersExtracted(chapterIdx int) bool {
+	var n int
+	s.DB.QueryRow("SELECT 1 FROM character_extraction_meta WHERE chapter_idx = ?", chapterIdx).Scan(&n)
+	return n == 1
+}
+
+// LastCharacterLocations returns each character's latest sighting in a
+// chapter at or before through, ordered by character. Characters are
+// matched case-insensitively; the latest spelling is returned. LocationID
+// is left for the caller to fill in.
+func (s *Store) LastCharacterLocations(through int) ([]model.CharacterLocation, error) {
+	rows, err := s.DB.Query(`SELECT character, location, role, quote, chapter_idx FROM character_sightings
+		WHERE chapter_idx <= ? ORDER BY chapter_idx, id`, through)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	latest := make(map[string]model.CharacterLocation)
+	for rows.Next() {
+		var cl model.CharacterLocation
+		var quote sql.NullString
+		if err := rows.Scan(&cl.Character, &cl.Location, &cl.Role, &quote, &cl.ChapterIndex); err != nil {
+			return nil, err
+		}
+		cl.Quote = quote.String
+		latest[strings.ToLower(strings.TrimSpace(cl.Character))] = cl
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]model.CharacterLocation, 0, len(latest))
+	for _, cl := range latest {
+		out = append(out, cl)
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Character) < strings.ToLower(out[j].Character) })
+	return out, nil
+}
+
+// PendingBatch records an extraction batch that has been submitted but whose
+// results have not yet been collected.
+type PendingBatch struct {
+	ID          string `json:"id"`
+	Model       string `json:"model"`
+	SubmittedAt string `json:"submitted_at"`
+	Chapters    []int  `json:"chapters"`
+	// PromptHash and PromptVersion identify the prompt the batch was
+	// submitted with; both are empty for batches recorded before they were.
+	PromptHash    string `json:"prompt_hash,omitempty"`
+	PromptVersion int    `json:"prompt_version,omitempty"`
+	// Prefills is the batch's extractor.Batch.Prefills, needed to rebuild
+	// the replies on collection. Batches recorded before it was added
+	// have none.
+	Prefills map[string]string `json:"prefills,omitempty"`
+}
+
+// pendingBatchPrefix namespaces pending batch entries in the meta table.
+const pendingBatchPrefix = "extract_batch:"
+
+// WritePendingBatch remembers a submitted batch so it can be collected after a restart.
+func (s *Store) WritePendingBatch(b *PendingBatch) error {
+	value, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES (?, ?)", pendingBatchPrefix+b.ID, string(value))
+	return err
+}
+
+// ReadPendingBatches returns all batches that have not been collected, oldest first.
+func (s *Store) ReadPendingBatches() ([]PendingBatch, error) {
+	rows, err := s.DB.Query("SELECT value FROM meta WHERE starts_with(key, ?)", pendingBatchPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []PendingBatch
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		var b PendingBatch
+		if err := json.Unmarshal([]byte(value), &b); err != nil {
+			return nil, fmt.Errorf("decoding pending batch: %w", err)
+		}
+		batches = append(batches, b)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].SubmittedAt < batches[j].SubmittedAt })
+	return batches, rows.Err()
+}
+
+// DeletePendingBatch forgets a batch once its results have been collected.
+func (s *Store) DeletePendingBatch(id string) error {
+	_, err := s.DB.Exec("DELETE FROM meta WHERE key = ?", pendingBatchPrefix+id)
+	return err
+}
+
 // WriteAggregated saves the aggregated location data.
 func (s *Store) WriteAggregated(data *model.AggregatedData) error {
 	tx, err := s.DB.Begin()
@@ -371,7 +967,7 @@ func (s *Store) WriteAggregated(data *model.AggregatedData) error {
 	defer tx.Rollback()
 
 	// Clear previous aggregation. Table names are compile-time constants, not user input.
-	for _, tbl := range []string{"locations", "relationships", "containment"} {
+	for _, tbl := range []string{"locations", "relationships", "containment", "location_descriptions", "rejected_entities", "merge_decisions", "merge_candidates"} {
 		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", tbl)); err != nil {
 			return fmt.Errorf("clearing %s: %w", tbl, err)
 		}
@@ -386,25 +982,55 @@ func (s *Store) WriteAggregated(data *model.AggregatedData) error {
 		seenLoc[loc.ID] = true
 		aliases, _ := json.Marshal(loc.Aliases)
 		indices, _ := json.Marshal(loc.ChapterIndices)
-		if _, err := tx.Exec("INSERT INTO locations (id, name, type, aliases, description, visual_description, first_chapter_idx, mention_count, chapter_indices) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING",
-			loc.ID, loc.Name, loc.Type, string(aliases), loc.Description, loc.VisualDescription, loc.FirstChapterIndex, loc.MentionCount, string(indices)); err != nil {
+		votes, _ := json.Marshal(loc.TypeVotes)
+		if _, err := tx.Exec("INSERT INTO locations (id, name, type, aliases, description, visual_description, first_chapter_idx, mention_count, chapter_indices, agreement, type_votes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING",
+			loc.ID, loc.Name, loc.Type, string(aliases), loc.Description, loc.VisualDescription, loc.FirstChapterIndex, loc.MentionCount, string(indices), loc.Agreement, string(votes)); err != nil {
 			return fmt.Errorf("inserting location %s: %w", loc.ID, err)
 		}
 	}
 
 	for _, rel := range data.Relationships {
-		if _, err := tx.Exec("INSERT INTO relationships (from_loc, to_loc, type, detail, quote, first_chapter_idx) VALUES (?, ?, ?, ?, ?, ?)",
-			rel.From, rel.To, rel.Type, rel.Detail, rel.Quote, rel.FirstChapterIndex); err != nil {
+		quotes, _ := json.Marshal(rel.Quotes)
+		if _, err := tx.Exec("INSERT INTO relationships (from_loc, to_loc, type, detail, quote, first_chapter_idx, quote_verified, quote_chapter_idx, quote_paragraph, agreement, quotes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			rel.From, rel.To, rel.Type, rel.Detail, rel.Quote, rel.FirstChapterIndex, rel.QuoteVerified, rel.QuoteChapterIndex, rel.QuoteParagraph, rel.Agreement, string(quotes)); err != nil {
 			return err
 		}
 	}
 
 	for _, c := range data.Containment {
-		if _, err := tx.Exec("INSERT INTO containment (child, parent) VALUES (?, ?)", c.Child, c.Parent); err != nil {
+		if _, err := tx.Exec("INSERT INTO containment (child, parent, first_chapter_idx) VALUES (?, ?, ?)", c.Child, c.Parent, c.FirstChapterIndex); err != nil {
 			return err
 		}
 	}
 
+	for _, d := range data.Descriptions {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO location_descriptions (location_id, chapter_idx, description, visual_description) VALUES (?, ?, ?, ?)",
+			d.LocationID, d.ChapterIndex, d.Description, d.VisualDescription); err != nil {
+			return fmt.Errorf("inserting description %s@%d: %w", d.LocationID, d.ChapterIndex, err)
+		}
+	}
+
+	for _, r := range data.Rejected {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO rejected_entities (id, name, reason, detail, mention_count, first_chapter_idx) VALUES (?, ?, ?, ?, ?, ?)",
+			r.ID, r.Name, r.Reason, r.Detail, r.MentionCount, r.FirstChapterIndex); err != nil {
+			return fmt.Errorf("inserting rejected entity %s: %w", r.ID, err)
+		}
+	}
+
+	for _, m := range data.Merges {
+		if _, err := tx.Exec("INSERT INTO merge_decisions (a, b, resolved_id, via, reason, detail, merged) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			m.A, m.B, m.ID, m.Via, m.Reason, m.Detail, m.Merged); err != nil {
+			return fmt.Errorf("inserting merge decision %s/%s: %w", m.A, m.B, err)
+		}
+	}
+
+	for _, c := range data.Candidates {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO merge_candidates (a, b, score, name_score, context_score) VALUES (?, ?, ?, ?, ?)",
+			c.A, c.B, c.Score, c.NameScore, c.ContextScore); err != nil {
+			return fmt.Errorf("inserting merge candidate %s/%s: %w", c.A, c.B, err)
+		}
+	}
+
 	if _, err := tx.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('aggregated_at', ?)", data.AggregatedAt); err != nil {
 		return err
 	}
@@ -417,17 +1043,19 @@ func (s *Store) ReadAggregated() (*model.AggregatedData, error) {
 	data := &model.AggregatedData{}
 
 	// Locations
-	rows, err := s.DB.Query("SELECT id, name, type, aliases, description, visual_description, first_chapter_idx, mention_count, chapter_indices FROM locations ORDER BY first_chapter_idx")
+	rows, err := s.DB.Query("SELECT id, name, type, aliases, description, visual_description, first_chapter_idx, mention_count, chapter_indices, agreement, type_votes FROM locations ORDER BY first_chapter_idx")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var loc model.AggregatedLocation
-		var aliases, indices, visualDesc sql.NullString
-		if err := rows.Scan(&loc.ID, &loc.Name, &loc.Type, &aliases, &loc.Description, &visualDesc, &loc.FirstChapterIndex, &loc.MentionCount, &indices); err != nil {
+		var aliases, indices, visualDesc, votes sql.NullString
+		var agreement sql.NullFloat64
+		if err := rows.Scan(&loc.ID, &loc.Name, &loc.Type, &aliases, &loc.Description, &visualDesc, &loc.FirstChapterIndex, &loc.MentionCount, &indices, &agreement, &votes); err != nil {
 			return nil, err
 		}
+		loc.Agreement = agreement.Float64
 		if aliases.Valid {
 			json.Unmarshal([]byte(aliases.String), &loc.Aliases)
 		}
@@ -437,6 +1065,9 @@ func (s *Store) ReadAggregated() (*model.AggregatedData, error) {
 		if indices.Valid {
 			json.Unmarshal([]byte(indices.String), &loc.ChapterIndices)
 		}
+		if votes.Valid {
+			json.Unmarshal([]byte(votes.String), &loc.TypeVotes)
+		}
 		data.Locations = append(data.Locations, loc)
 	}
 	if err := rows.Err(); err != nil {
@@ -444,20 +1075,30 @@ func (s *Store) ReadAggregated() (*model.AggregatedData, error) {
 	}
 
 	// Relationships
-	relRows, err := s.DB.Query("SELECT from_loc, to_loc, type, detail, quote, first_chapter_idx FROM relationships ORDER BY first_chapter_idx")
+	relRows, err := s.DB.Query("SELECT from_loc, to_loc, type, detail, quote, first_chapter_idx, quote_verified, quote_chapter_idx, quote_paragraph, agreement, quotes FROM relationships ORDER BY first_chapter_idx")
 	if err != nil {
 		return nil, err
 	}
 	defer relRows.Close()
 	for relRows.Next() {
 		var rel model.AggregatedRelationship
-		var quote sql.NullString
-		if err := relRows.Scan(&rel.From, &rel.To, &rel.Type, &rel.Detail, &quote, &rel.FirstChapterIndex); err != nil {
+		var quote, quotes sql.NullString
+		var verified sql.NullBool
+		var quoteChapter, quoteParagraph sql.NullInt64
+		var agreement sql.NullFloat64
+		if err := relRows.Scan(&rel.From, &rel.To, &rel.Type, &rel.Detail, &quote, &rel.FirstChapterIndex, &verified, &quoteChapter, &quoteParagraph, &agreement, &quotes); err != nil {
 			return nil, err
 		}
+		if quotes.Valid {
+			json.Unmarshal([]byte(quotes.String), &rel.Quotes)
+		}
+		rel.Agreement = agreement.Float64
 		if quote.Valid {
 			rel.Quote = quote.String
 		}
+		rel.QuoteVerified = verified.Bool
+		rel.QuoteChapterIndex = int(quoteChapter.Int64)
+		rel.QuoteParagraph = int(quoteParagraph.Int64)
 		data.Relationships = append(data.Relationships, rel)
 	}
 	if err := relRows.Err(); err != nil {
@@ -465,14 +1106,14 @@ func (s *Store) ReadAggregated() (*model.AggregatedData, error) {
 	}
 
 	// Containment
-	cRows, err := s.DB.Query("SELECT child, parent FROM containment")
+	cRows, err := s.DB.Query("SELECT child, parent, first_chapter_idx FROM containment ORDER BY first_chapter_idx")
 	if err != nil {
 		return nil, err
 	}
 	defer cRows.Close()
 	for cRows.Next() {
 		var c model.Containment
-		if err := cRows.Scan(&c.Child, &c.Parent); err != nil {
+		if err := cRows.Scan(&c.Child, &c.Parent, &c.FirstChapterIndex); err != nil {
 			return nil, err
 		}
 		data.Containment = append(data.Containment, c)
@@ -481,6 +1122,26 @@ func (s *Store) ReadAggregated() (*model.AggregatedData, error) {
 		return nil, fmt.Errorf("reading containment: %w", err)
 	}
 
+	// Description history
+	dRows, err := s.DB.Query("SELECT location_id, chapter_idx, description, visual_description FROM location_descriptions ORDER BY location_id, chapter_idx")
+	if err != nil {
+		return nil, err
+	}
+	defer dRows.Close()
+	for dRows.Next() {
+		var d model.DescriptionRevision
+		var desc, visualDesc sql.NullString
+		if err := dRows.Scan(&d.LocationID, &d.ChapterIndex, &desc, &visualDesc); err != nil {
+			return nil, err
+		}
+		d.Description = desc.String
+		d.VisualDescription = visualDesc.String
+		data.Descriptions = append(data.Descriptions, d)
+	}
+	if err := dRows.Err(); err != nil {
+		return nil, fmt.Errorf("reading descriptions: %w", err)
+	}
+
 	var aggAt sql.NullString
 	s.DB.QueryRow("SELECT value FROM meta WHERE key = 'aggregated_at'").Scan(&aggAt)
 	data.AggregatedAt = aggAt.String
@@ -488,16 +1149,112 @@ func (s *Store) ReadAggregated() (*model.AggregatedData, error) {
 	return data, nil
 }
 
+// ReadRejected loads the names the last aggregation judged not to be map
+// locations, most mentioned first.
+func (s *Store) ReadRejected() ([]model.RejectedEntity, error) {
+	rows, err := s.DB.Query("SELECT id, name, reason, detail, mention_count, first_chapter_idx FROM rejected_entities ORDER BY mention_count DESC, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rejected []model.RejectedEntity
+	for rows.Next() {
+		var r model.RejectedEntity
+		var detail sql.NullString
+		if err := rows.Scan(&r.ID, &r.Name, &r.Reason, &detail, &r.MentionCount, &r.FirstChapterIndex); err != nil {
+			return nil, err
+		}
+		r.Detail = detail.String
+		rejected = append(rejected, r)
+	}
+	return rejected, rows.Err()
+}
+
+// ReadMergeDecisions loads the alias merges the last aggregation made and
+// refused, merges first.
+func (s *Store) ReadMergeDecisions() ([]model.MergeDecision, error) {
+	rows, err := s.DB.Query("SELECT a, b, resolved_id, via, reason, detail, merged FROM merge_decisions ORDER BY merged DESC, resolved_id, a, b")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var merges []model.MergeDecision
+	for rows.Next() {
+		var m model.MergeDecision
+		var id, detail sql.NullString
+		if err := rows.Scan(&m.A, &m.B, &id, &m.Via, &m.Reason, &detail, &m.Merged); err != nil {
+			return nil, err
+		}
+		m.ID, m.Detail = id.String, detail.String
+		merges = append(merges, m)
+	}
+	return merges, rows.Err()
+}
+
+// ReadMergeCandidates loads the possible duplicates from the last aggregation
+// that haven't been reviewed since, most similar first.
+func (s *Store) ReadMergeCandidates() ([]model.MergeCandidate, error) {
+	rows, err := s.DB.Query(`SELECT c.a, c.b, c.score, c.name_score, c.context_score FROM merge_candidates c
+		LEFT JOIN merge_reviews r ON r.a = least(c.a, c.b) AND r.b = greatest(c.a, c.b)
+		WHERE r.a IS NULL ORDER BY c.score DESC, c.a, c.b`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []model.MergeCandidate
+	for rows.Next() {
+		var c model.MergeCandidate
+		if err := rows.Scan(&c.A, &c.B, &c.Score, &c.NameScore, &c.ContextScore); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// WriteMergeReview records a reviewer's verdict on merging two locations,
+// replacing any earlier verdict on the same pair in either order.
+func (s *Store) WriteMergeReview(r model.MergeReview) error {
+	a, b := r.A, r.B
+	if a > b {
+		a, b = b, a
+	}
+	_, err := s.DB.Exec("INSERT OR REPLACE INTO merge_reviews (a, b, accepted, reviewed_at) VALUES (?, ?, ?, ?)", a, b, r.Accepted, r.ReviewedAt)
+	return err
+}
+
+// ReadMergeReviews loads every merge verdict, oldest first.
+func (s *Store) ReadMergeReviews() ([]model.MergeReview, error) {
+	rows, err := s.DB.Query("SELECT a, b, accepted, reviewed_at FROM merge_reviews ORDER BY reviewed_at, a, b")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []model.MergeReview
+	for rows.Next() {
+		var r model.MergeReview
+		if err := rows.Scan(&r.A, &r.B, &r.Accepted, &r.ReviewedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
 // WriteCoordinate inserts or updates a single location's coordinates.
 func (s *Store) WriteCoordinate(c model.Coordinate) error {
-	_, err := s.DB.Exec("INSERT OR REPLACE INTO coordinates (location_id, x, y, confidence, manual) VALUES (?, ?, ?, ?, ?)",
-		c.LocationID, c.X, c.Y, c.Confidence, c.Manual)
+	_, err := s.DB.Exec("INSERT OR REPLACE INTO coordinates (location_id, x, y, confidence, manual, first_chapter_idx) VALUES (?, ?, ?, ?, ?, ?)",
+		c.LocationID, c.X, c.Y, c.Confidence, c.Manual, c.FirstChapterIndex)
 	return err
 }
 
 // ReadCoordinates loads all coordinates.
 func (s *Store) ReadCoordinates() ([]model.Coordinate, error) {
-	rows, err := s.DB.Query("SELECT location_id, x, y, confidence, manual FROM coordinates")
+	rows, err := s.DB.Query("SELECT location_id, x, y, confidence, manual, first_chapter_idx FROM coordinates")
 	if err != nil {
 		return nil, err
 	}
@@ -506,7 +1263,7 @@ func (s *Store) ReadCoordinates() ([]model.Coordinate, error) {
 	var coords []model.Coordinate
 	for rows.Next() {
 		var c model.Coordinate
-		if err := rows.Scan(&c.LocationID, &c.X, &c.Y, &c.Confidence, &c.Manual); err != nil {
+		if err := rows.Scan(&c.LocationID, &c.X, &c.Y, &c.Confidence, &c.Manual, &c.FirstChapterIndex); err != nil {
 			return nil, err
 		}
 		coords = append(coords, c)
@@ -535,6 +1292,41 @@ func (s *Store) ExtractionCount() int {
 	return n
 }
 
+// PartialExtractionCount returns how many chapters were extracted from a
+// truncated response.
+func (s *Store) PartialExtractionCount() int {
+	var n int
+	s.DB.QueryRow("SELECT COUNT(*) FROM extraction_meta WHERE partial").Scan(&n)
+	return n
+}
+
+// WarningCounts tallies extraction validation warnings by kind and returns
+// how many chapters have at least one.
+func (s *Store) WarningCounts() (byKind map[string]int, chapters int, err error) {
+	rows, err := s.DB.Query("SELECT warnings FROM extraction_meta WHERE warnings IS NOT NULL")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	byKind = make(map[string]int)
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, 0, err
+		}
+		var warnings []model.ExtractionWarning
+		json.Unmarshal([]byte(raw), &warnings)
+		if len(warnings) > 0 {
+			chapters++
+		}
+		for _, w := range warnings {
+			byKind[w.Kind]++
+		}
+	}
+	return byKind, chapters, rows.Err()
+}
+
 // LocationCount returns the number of aggregated locations.
 func (s *Store) LocationCount() int {
 	var n int
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -117,6 +119,9 @@ func TestExtractionRoundTrip(t *testing.T) {
 	if err := s.WriteExtraction(ext); err != nil {
 		t.Fatalf("writing extraction: %v", err)
 	}
+	if got, err := s.ReadExtraction(0); err != nil || got.Locations[0].QuoteMatches != nil || got.Relationships[0].QuoteMatch != nil {
+		t.Errorf("expected unverified quotes to read back without matches: %v", err)
+	}
 
 	if !s.ExtractionExists(0) {
 		t.Error("expected ExtractionExists(0) = true")
@@ -139,6 +144,292 @@ func TestExtractionRoundTrip(t *testing.T) {
 	if len(got.Containment) != 1 {
 		t.Errorf("expected 1 containment, got %d", len(got.Containment))
 	}
+	if got.Partial || s.PartialExtractionCount() != 0 {
+		t.Error("expected a complete extraction")
+	}
+
+	ext.Locations[0].ContextQuotes = []string{"the walls of Liscor"}
+	ext.Locations[0].QuoteMatches = []model.QuoteMatch{{Offset: 42, Paragraph: 3, Score: 1}}
+	ext.Relationships[0].Quote = "Liscor, in southern Izril"
+	ext.Relationships[0].QuoteMatch = &model.QuoteMatch{Offset: -1, Score: 0.25}
+	ext.Partial = true
+	ext.PromptHash = "0123456789ab"
+	ext.Warnings = []model.ExtractionWarning{
+		{Kind: "coerced_type", Detail: `Liscor: type "walled city" coerced to "city"`},
+		{Kind: "dangling_reference", Detail: `Liscor in Izril: "Izril" is not among the chapter's locations`},
+	}
+	if err := s.WriteExtraction(ext); err != nil {
+		t.Fatalf("rewriting extraction: %v", err)
+	}
+	got, err = s.ReadExtraction(0)
+	if err != nil {
+		t.Fatalf("reading extraction: %v", err)
+	}
+	if !got.Partial || s.PartialExtractionCount() != 1 {
+		t.Error("expected the partial flag to round-trip")
+	}
+	if got.PromptHash != "0123456789ab" {
+		t.Errorf("expected prompt hash to round-trip, got %q", got.PromptHash)
+	}
+	if m := got.Locations[0].QuoteMatches; len(m) != 1 || m[0].Offset != 42 || m[0].Paragraph != 3 {
+		t.Errorf("expected location quote matches to round-trip, got %+v", m)
+	}
+	if m := got.Relationships[0].QuoteMatch; m == nil || m.Offset != -1 || m.Verified() {
+		t.Errorf("expected relationship quote match to round-trip, got %+v", m)
+	}
+	if len(got.Warnings) != 2 || got.Warnings[0].Kind != "coerced_type" {
+		t.Errorf("expected warnings to round-trip, got %+v", got.Warnings)
+	}
+	byKind, chapters, err := s.WarningCounts()
+	if err != nil {
+		t.Fatalf("counting warnings: %v", err)
+	}
+	if chapters != 1 || byKind["coerced_type"] != 1 || byKind["dangling_reference"] != 1 {
+		t.Errorf("unexpected warning counts %v across %d chapters", byKind, chapters)
+	}
+}
+
+func TestExtractionVersions(t *testing.T) {
+	s := testStore(t)
+
+	toc := &model.TOC{Chapters: []model.Chapter{{Index: 0, WebTitle: "1.00", Volume: "vol-1", Slug: "1-00"}}}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+
+	first := &model.ChapterExtraction{
+		ChapterIndex: 0, Model: "old-model", ExtractedAt: "2025-01-01T00:00:00Z", PromptVersion: 1,
+		Locations: []model.ExtractedLocation{{Name: "Liscor", Type: "city"}},
+	}
+	if err := s.WriteExtraction(first); err != nil {
+		t.Fatalf("writing extraction: %v", err)
+	}
+	// Rewriting the same run (e.g. after verifying quotes) must not archive.
+	first.Locations[0].Description = "A walled city"
+	if err := s.WriteExtraction(first); err != nil {
+		t.Fatalf("rewriting extraction: %v", err)
+	}
+	if v, err := s.ExtractionVersions(0); err != nil || len(v) != 0 {
+		t.Fatalf("expected no archived versions after an in-place rewrite, got %+v (%v)", v, err)
+	}
+
+	second := &model.ChapterExtraction{
+		ChapterIndex: 0, Model: "new-model", ExtractedAt: "2025-02-01T00:00:00Z", PromptVersion: 2,
+		Locations: []model.ExtractedLocation{{Name: "Pallass", Type: "city"}},
+	}
+	if err := s.WriteExtraction(second); err != nil {
+		t.Fatalf("writing re-extraction: %v", err)
+	}
+	versions, err := s.ExtractionVersions(0)
+	if err != nil {
+		t.Fatalf("listing versions: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Version != 1 || versions[0].Model != "old-model" || versions[0].PromptVersion != 1 {
+		t.Fatalf("expected the first run archived as version 1, got %+v", versions)
+	}
+	archived, err := s.ReadExtractionVersion(0, 1)
+	if err != nil || archived.Model != "old-model" || archived.Locations[0].Description != "A walled city" {
+		t.Errorf("expected version 1 to hold the first run, got %+v (%v)", archived, err)
+	}
+	if _, err := s.ReadExtractionVersion(0, 2); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for a missing version, got %v", err)
+	}
+
+	metas, err := s.ReadExtractionMeta()
+	if err != nil {
+		t.Fatalf("reading extraction meta: %v", err)
+	}
+	if m := metas[0]; m == nil || m.Model != "new-model" || m.PromptVersion != 2 {
+		t.Errorf("expected meta for the current extraction, got %+v", m)
+	}
+
+	restored, err := s.RollbackExtraction(0)
+	if err != nil {
+		t.Fatalf("rolling back: %v", err)
+	}
+	if restored.Model != "old-model" {
+		t.Errorf("expected old-model restored, got %q", restored.Model)
+	}
+	got, err := s.ReadExtraction(0)
+	if err != nil {
+		t.Fatalf("reading extraction: %v", err)
+	}
+	if len(got.Locations) != 1 || got.Locations[0].Name != "Liscor" || got.Locations[0].Description != "A walled city" || got.PromptVersion != 1 {
+		t.Errorf("expected the first run's records back, got %+v", got)
+	}
+	if v, _ := s.ExtractionVersions(0); len(v) != 0 {
+		t.Errorf("expected the restored version removed from the archive, got %+v", v)
+	}
+	if _, err := s.RollbackExtraction(0); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows with no earlier version, got %v", err)
+	}
+}
+
+func TestRollbackRestoresRawExtraction(t *testing.T) {
+	s := testStore(t)
+
+	toc := &model.TOC{Chapters: []model.Chapter{{Index: 0, WebTitle: "1.00", Volume: "vol-1", Slug: "1-00"}, {Index: 1, WebTitle: "1.01", Volume: "vol-1", Slug: "1-01"}}}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+
+	raw := func(idx int, modelName string) *model.RawExtraction {
+		return &model.RawExtraction{ChapterIndex: idx, Model: modelName, Responses: []string{modelName}, CreatedAt: "2025-01-01T00:00:00Z"}
+	}
+	ext := func(idx int, modelName, at string) *model.ChapterExtraction {
+		return &model.ChapterExtraction{ChapterIndex: idx, Model: modelName, ExtractedAt: at}
+	}
+	if err := s.WriteExtractionWithRaw(ext(0, "old-model", "2025-01-01T00:00:00Z"), raw(0, "old-model")); err != nil {
+		t.Fatalf("writing extraction: %v", err)
+	}
+	if err := s.WriteExtractionWithRaw(ext(0, "new-model", "2025-02-01T00:00:00Z"), raw(0, "new-model")); err != nil {
+		t.Fatalf("writing re-extraction: %v", err)
+	}
+	if got, err := s.ReadRawExtraction(0); err != nil || got.Model != "new-model" {
+		t.Fatalf("expected the new reply stored with the new extraction, got %+v (%v)", got, err)
+	}
+
+	if _, err := s.RollbackExtraction(0); err != nil {
+		t.Fatalf("rolling back: %v", err)
+	}
+	if got, err := s.ReadRawExtraction(0); err != nil || got.Model != "old-model" || got.Responses[0] != "old-model" {
+		t.Errorf("expected the old reply restored with the old extraction, got %+v (%v)", got, err)
+	}
+
+	// A version archived without a reply must not leave the newer reply
+	// behind to be re-parsed over the rollback.
+	if err := s.WriteExtraction(ext(1, "old-model", "2025-01-01T00:00:00Z")); err != nil {
+		t.Fatalf("writing extraction: %v", err)
+	}
+	if err := s.WriteExtractionWithRaw(ext(1, "new-model", "2025-02-01T00:00:00Z"), raw(1, "new-model")); err != nil {
+		t.Fatalf("writing re-extraction: %v", err)
+	}
+	if _, err := s.RollbackExtraction(1); err != nil {
+		t.Fatalf("rolling back: %v", err)
+	}
+	if s.RawExtractionExists(1) {
+		t.Error("expected the newer reply removed by the rollback")
+	}
+}
+
+func TestRawExtractionRoundTrip(t *testing.T) {
+	s := testStore(t)
+
+	toc := &model.TOC{Chapters: []model.Chapter{{Index: 4, WebTitle: "1.04", Volume: "vol-1", Slug: "1-04"}}}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	if s.RawExtractionExists(4) {
+		t.Fatal("expected no raw response before writing one")
+	}
+	raw := &model.RawExtraction{
+		ChapterIndex: 4,
+		Model:        "test-model",
+		PromptHash:   "0123456789ab",
+		Responses:    []string{`{"locations":[`, `{"locations":[]}`},
+		StopReasons:  []string{"max_tokens", "end_turn"},
+		InputTokens:  1200,
+		OutputTokens: 300,
+		CreatedAt:    "2025-01-01T00:00:00Z",
+	}
+	if err := s.WriteRawExtraction(raw); err != nil {
+		t.Fatalf("writing raw response: %v", err)
+	}
+	if !s.RawExtractionExists(4) {
+		t.Error("expected RawExtractionExists(4) = true")
+	}
+
+	got, err := s.ReadRawExtraction(4)
+	if err != nil {
+		t.Fatalf("reading raw response: %v", err)
+	}
+	if got.Model != raw.Model || got.PromptHash != raw.PromptHash || got.CreatedAt != raw.CreatedAt {
+		t.Errorf("metadata mismatch: %+v", got)
+	}
+	if len(got.Responses) != 2 || got.Responses[0] != raw.Responses[0] {
+		t.Errorf("expected responses to round-trip verbatim, got %q", got.Responses)
+	}
+	if len(got.StopReasons) != 2 || got.StopReasons[0] != "max_tokens" {
+		t.Errorf("expected stop reasons to round-trip, got %q", got.StopReasons)
+	}
+	if got.InputTokens != 1200 || got.OutputTokens != 300 {
+		t.Errorf("usage mismatch: %+v", got)
+	}
+
+	// A later run for the same chapter replaces the stored response.
+	raw.Responses = []string{`{"locations":[]}`}
+	if err := s.WriteRawExtraction(raw); err != nil {
+		t.Fatalf("rewriting raw response: %v", err)
+	}
+	if got, err := s.ReadRawExtraction(4); err != nil || len(got.Responses) != 1 {
+		t.Errorf("expected the rewrite to replace the responses, got %+v (%v)", got, err)
+	}
+
+	// A consensus run keeps the replies of every sample.
+	raw.Samples = []model.RawExtraction{{Model: "other-model", Responses: []string{`{"locations":[]}`}, StopReasons: []string{"end_turn"}}}
+	if err := s.WriteRawExtraction(raw); err != nil {
+		t.Fatalf("writing sampled raw response: %v", err)
+	}
+	if got, err := s.ReadRawExtraction(4); err != nil || len(got.Samples) != 1 || got.Samples[0].Model != "other-model" {
+		t.Errorf("expected samples to round-trip, got %+v (%v)", got, err)
+	}
+
+	ext := &model.ChapterExtraction{
+		ChapterIndex: 4, Model: "test-model+other-model", ExtractedAt: "2025-01-01T00:00:00Z", Samples: 3,
+		Locations:     []model.ExtractedLocation{{Name: "Liscor", Type: model.LocationCity, Agreement: 2.0 / 3}},
+		Relationships: []model.ExtractedRelationship{{From: "Liscor", To: "Izril", Type: model.RelContainment, Agreement: 1}},
+	}
+	if err := s.WriteExtraction(ext); err != nil {
+		t.Fatalf("writing consensus extraction: %v", err)
+	}
+	gotExt, err := s.ReadExtraction(4)
+	if err != nil {
+		t.Fatalf("reading consensus extraction: %v", err)
+	}
+	if gotExt.Samples != 3 || gotExt.Locations[0].Agreement != 2.0/3 || gotExt.Relationships[0].Agreement != 1 {
+		t.Errorf("expected sample count and agreement scores to round-trip, got %+v", gotExt)
+	}
+}
+
+func TestPendingBatchRoundTrip(t *testing.T) {
+	s := testStore(t)
+
+	b1 := &PendingBatch{ID: "msgbatch_1", Model: "test-model", SubmittedAt: "2025-01-01T00:00:00Z", Chapters: []int{0, 1},
+		Prefills: map[string]string{"chapter-0-0": "{", "chapter-1-0": ""}, PromptHash: "abc123", PromptVersion: 2}
+	b2 := &PendingBatch{ID: "msgbatch_2", Model: "test-model", SubmittedAt: "2025-01-02T00:00:00Z", Chapters: []int{2}}
+	for _, b := range []*PendingBatch{b2, b1} {
+		if err := s.WritePendingBatch(b); err != nil {
+			t.Fatalf("writing pending batch: %v", err)
+		}
+	}
+
+	got, err := s.ReadPendingBatches()
+	if err != nil {
+		t.Fatalf("reading pending batches: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 pending batches, got %d", len(got))
+	}
+	if got[0].ID != "msgbatch_1" || len(got[0].Chapters) != 2 {
+		t.Errorf("expected oldest batch first with 2 chapters, got %+v", got[0])
+	}
+	if p, ok := got[0].Prefills["chapter-1-0"]; len(got[0].Prefills) != 2 || !ok || p != "" {
+		t.Errorf("expected per-request prefills kept, got %v", got[0].Prefills)
+	}
+	if got[0].PromptHash != "abc123" || got[0].PromptVersion != 2 {
+		t.Errorf("expected the submitted prompt kept, got %q v%d", got[0].PromptHash, got[0].PromptVersion)
+	}
+
+	if err := s.DeletePendingBatch("msgbatch_1"); err != nil {
+		t.Fatalf("deleting pending batch: %v", err)
+	}
+	got, err = s.ReadPendingBatches()
+	if err != nil {
+		t.Fatalf("reading pending batches: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "msgbatch_2" {
+		t.Errorf("expected only msgbatch_2 to remain, got %+v", got)
+	}
 }
 
 func TestAggregatedRoundTrip(t *testing.T) {
@@ -147,7 +438,8 @@ func TestAggregatedRoundTrip(t *testing.T) {
 	data := &model.AggregatedData{
 		AggregatedAt: "2025-01-01T00:00:00Z",
 		Locations: []model.AggregatedLocation{
-			{ID: "liscor", Name: "Liscor", Type: "city", Description: "A walled city", MentionCount: 50, FirstChapterIndex: 0},
+			{ID: "liscor", Name: "Liscor", Type: "city", Description: "A walled city", MentionCount: 50, FirstChapterIndex: 0,
+				TypeVotes: []model.TypeVote{{Type: "city", Mentions: 48, Weight: 48, LastChapterIndex: 90}, {Type: "other", Mentions: 2, Weight: 0.5}}},
 		},
 		Relationships: []model.AggregatedRelationship{
 			{From: "liscor", To: "izril", Type: "containment", FirstChapterIndex: 0},
@@ -155,6 +447,18 @@ func TestAggregatedRoundTrip(t *testing.T) {
 		Containment: []model.Containment{
 			{Child: "liscor", Parent: "izril"},
 		},
+		Descriptions: []model.DescriptionRevision{
+			{LocationID: "liscor", ChapterIndex: 0, Description: "A city"},
+			{LocationID: "liscor", ChapterIndex: 3, Description: "A walled city", VisualDescription: "Green stone"},
+		},
+		Rejected: []model.RejectedEntity{
+			{ID: "the kitchen", Name: "The Kitchen", Reason: "uncapitalized", MentionCount: 2, FirstChapterIndex: 1},
+			{ID: "erin", Name: "Erin", Reason: "person_verb", Detail: `"Erin" said in chapter 0`, MentionCount: 9},
+		},
+		Merges: []model.MergeDecision{
+			{A: "pallass", B: "liscor", Via: "the walled city", Reason: "type_conflict"},
+			{ID: "liscor", A: "liscor", B: "the city of liscor", Via: "liscor", Reason: "alias", Merged: true},
+		},
 	}
 
 	if err := s.WriteAggregated(data); err != nil {
@@ -172,18 +476,42 @@ func TestAggregatedRoundTrip(t *testing.T) {
 	if got.Locations[0].Name != "Liscor" {
 		t.Errorf("expected 'Liscor', got %q", got.Locations[0].Name)
 	}
+	if votes := got.Locations[0].TypeVotes; len(votes) != 2 || votes[0].LastChapterIndex != 90 || votes[1].Weight != 0.5 {
+		t.Errorf("expected type votes to round-trip, got %+v", votes)
+	}
 	if len(got.Relationships) != 1 {
 		t.Errorf("expected 1 relationship, got %d", len(got.Relationships))
 	}
 	if len(got.Containment) != 1 {
 		t.Errorf("expected 1 containment, got %d", len(got.Containment))
 	}
+	if len(got.Descriptions) != 2 {
+		t.Fatalf("expected 2 description revisions, got %d", len(got.Descriptions))
+	}
+	if got.Descriptions[1].ChapterIndex != 3 || got.Descriptions[1].VisualDescription != "Green stone" {
+		t.Errorf("description revision mismatch: %+v", got.Descriptions[1])
+	}
+
+	rejected, err := s.ReadRejected()
+	if err != nil {
+		t.Fatalf("reading rejected: %v", err)
+	}
+	if len(rejected) != 2 || rejected[0].ID != "erin" || rejected[0].Detail == "" || rejected[1].Reason != "uncapitalized" {
+		t.Errorf("expected rejected entities most mentioned first, got %+v", rejected)
+	}
+	merges, err := s.ReadMergeDecisions()
+	if err != nil {
+		t.Fatalf("reading merge decisions: %v", err)
+	}
+	if len(merges) != 2 || !merges[0].Merged || merges[0].ID != "liscor" || merges[1].Merged || merges[1].ID != "" {
+		t.Errorf("expected the merge before the refusal, got %+v", merges)
+	}
 }
 
 func TestCoordinateRoundTrip(t *testing.T) {
 	s := testStore(t)
 
-	coord := model.Coordinate{LocationID: "liscor", X: 240, Y: -20, Confidence: "estimated"}
+	coord := model.Coordinate{LocationID: "liscor", X: 240, Y: -20, Confidence: "estimated", FirstChapterIndex: 4}
 	if err := s.WriteCoordinate(coord); err != nil {
 		t.Fatalf("writing coordinate: %v", err)
 	}
@@ -196,11 +524,53 @@ func TestCoordinateRoundTrip(t *testing.T) {
 	if len(coords) != 1 {
 		t.Fatalf("expected 1 coordinate, got %d", len(coords))
 	}
-	if coords[0].LocationID != "liscor" || coords[0].X != 240 || coords[0].Y != -20 {
+	if coords[0].LocationID != "liscor" || coords[0].X != 240 || coords[0].Y != -20 || coords[0].FirstChapterIndex != 4 {
 		t.Errorf("coordinate mismatch: %+v", coords[0])
 	}
 }
 
+func TestUnstampedRowsStayUnrevealed(t *testing.T) {
+	s := testStore(t)
+
+	if _, err := s.DB.Exec("INSERT INTO containment (child, parent) VALUES ('Liscor', 'Izril')"); err != nil {
+		t.Fatalf("inserting containment: %v", err)
+	}
+	// A database from before coordinates were stamped gains the column on
+	// the next migration.
+	if _, err := s.DB.Exec("ALTER TABLE coordinates DROP COLUMN first_chapter_idx"); err != nil {
+		t.Fatalf("dropping column: %v", err)
+	}
+	if _, err := s.DB.Exec("INSERT INTO coordinates (location_id, x, y) VALUES ('liscor', 240, -20)"); err != nil {
+		t.Fatalf("inserting coordinate: %v", err)
+	}
+	if err := s.migrate(); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	if _, err := s.DB.Exec("INSERT INTO coordinates (location_id, x, y) VALUES ('celum', 100, 0)"); err != nil {
+		t.Fatalf("inserting coordinate: %v", err)
+	}
+
+	coords, err := s.ReadCoordinates()
+	if err != nil {
+		t.Fatalf("reading coordinates: %v", err)
+	}
+	if len(coords) != 2 {
+		t.Fatalf("expected 2 coordinates, got %+v", coords)
+	}
+	for _, c := range coords {
+		if c.FirstChapterIndex != model.UnrevealedChapter {
+			t.Errorf("expected unstamped coordinate %s unrevealed, got chapter %d", c.LocationID, c.FirstChapterIndex)
+		}
+	}
+	data, err := s.ReadAggregated()
+	if err != nil {
+		t.Fatalf("reading aggregated: %v", err)
+	}
+	if len(data.Containment) != 1 || data.Containment[0].FirstChapterIndex != model.UnrevealedChapter {
+		t.Errorf("expected unstamped containment unrevealed, got %+v", data.Containment)
+	}
+}
+
 func TestCountMethods(t *testing.T) {
 	s := testStore(t)
 
@@ -221,3 +591,66 @@ func TestCountMethods(t *testing.T) {
 		t.Errorf("expected 1 chapter, got %d", s.ChapterCount())
 	}
 }
+
+func TestCharactersRoundTrip(t *testing.T) {
+	s := testStore(t)
+
+	toc := &model.TOC{
+		Chapters: []model.Chapter{
+			{Index: 0, WebTitle: "1.00", URL: "https://example.com/1-00", Volume: "vol-1", Slug: "1-00"},
+			{Index: 1, WebTitle: "1.01", URL: "https://example.com/1-01", Volume: "vol-1", Slug: "1-01"},
+		},
+	}
+	if err := s.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+
+	ch0 := &model.ChapterCharacters{
+		ChapterIndex: 0,
+		Model:        "test-model",
+		ExtractedAt:  "2025-01-01T00:00:00Z",
+		Sightings: []model.CharacterSighting{
+			{Character: "Erin Solstice", Location: "The Wandering Inn", Role: model.SightingPresent, Quote: "Erin sighed."},
+			{Character: "Relc", Location: "Liscor", Role: model.SightingPresent},
+			{Character: "Erin Solstice", Location: "Liscor", Role: model.SightingTravellingTo},
+		},
+		Events: []model.ExtractedEvent{{Name: "The Goblin Raid", Location: "The Wandering Inn"}},
+	}
+	ch1 := &model.ChapterCharacters{
+		ChapterIndex: 1,
+		Model:        "test-model",
+		ExtractedAt:  "2025-01-01T00:00:00Z",
+		Sightings:    []model.CharacterSighting{{Character: "erin solstice", Location: "Celum", Role: model.SightingPresent}},
+	}
+	for _, ch := range []*model.ChapterCharacters{ch0, ch1, ch0} {
+		if err := s.WriteCharacters(ch); err != nil {
+			t.Fatalf("writing characters: %v", err)
+		}
+	}
+
+	if !s.CharactersExtracted(0) || s.ExtractionExists(0) {
+		t.Error("expected a character extraction without a location extraction for chapter 0")
+	}
+	got, err := s.ReadCharacters(0)
+	if err != nil {
+		t.Fatalf("reading characters: %v", err)
+	}
+	if got.ChapterTitle != "1.00" || len(got.Sightings) != 3 || got.Sightings[2].Role != model.SightingTravellingTo || got.Sightings[0].Quote != "Erin sighed." {
+		t.Errorf("expected rewritten sightings in order, got %+v", got)
+	}
+	if len(got.Events) != 1 || got.Events[0].Location != "The Wandering Inn" {
+		t.Errorf("expected one event, got %+v", got.Events)
+	}
+
+	last, err := s.LastCharacterLocations(0)
+	if err != nil {
+		t.Fatalf("reading last locations: %v", err)
+	}
+	if len(last) != 2 || last[0].Character != "Erin Solstice" || last[0].Location != "Liscor" || last[1].Character != "Relc" {
+		t.Errorf("expected Erin travelling to Liscor and Relc as of chapter 0, got %+v", last)
+	}
+	last, _ = s.LastCharacterLocations(1)
+	if len(last) != 2 || last[0].Location != "Celum" || last[0].ChapterIndex != 1 {
+		t.Errorf("expected Erin in Celum as of chapter 1, got %+v", last)
+	}
+}
@@ -2,8 +2,12 @@ package web
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
 	"strconv"
+
+	"github.com/intelligrit/twi-map/internal/aggregator"
+	"github.com/intelligrit/twi-map/internal/model"
 )
 
 func (s *Server) handleChapters(w http.ResponseWriter, r *http.Request) {
@@ -44,9 +48,16 @@ func (s *Server) handleLocations(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// Descriptions are chosen from revisions at or before this chapter, so
+		// text written about a place later in the story never reaches the reader early.
+		known := descriptionsAsOf(data.Descriptions, through)
+
 		var filtered []any
 		for _, loc := range data.Locations {
 			if loc.FirstChapterIndex <= through {
+				rev := known[loc.ID]
+				loc.Description = rev.Description
+				loc.VisualDescription = rev.VisualDescription
 				filtered = append(filtered, loc)
 			}
 		}
@@ -57,6 +68,29 @@ func (s *Server) handleLocations(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, data.Locations)
 }
 
+// descriptionsAsOf returns, per location ID, the longest description and visual
+// description extracted from any chapter at or before through.
+func descriptionsAsOf(revs []model.DescriptionRevision, through int) map[string]model.DescriptionRevision {
+	best := make(map[string]model.DescriptionRevision)
+	for _, rev := range revs {
+		if rev.ChapterIndex > through {
+			continue
+		}
+		cur := best[rev.LocationID]
+		if len(rev.Description) > len(cur.Description) {
+			cur.Description = rev.Description
+		}
+		if len(rev.VisualDescription) > len(cur.VisualDescription) {
+			cur.VisualDescription = rev.VisualDescription
+		}
+		best[rev.LocationID] = cur
+	}
+	return best
+}
+
+// handleRelationships serves aggregated relationships. quotes=verified
+// blanks quotes that weren't found in their chapter's text; by default they
+// are returned with quote_verified unset so the client can badge them.
 func (s *Server) handleRelationships(w http.ResponseWriter, r *http.Request) {
 	data, err := s.Store.ReadAggregated()
 	if err != nil {
@@ -64,6 +98,57 @@ func (s *Server) handleRelationships(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	verifiedOnly := false
+	switch r.URL.Query().Get("quotes") {
+	case "", "all":
+	case "verified":
+		verifiedOnly = true
+	default:
+		http.Error(w, "invalid 'quotes' parameter (want 'all' or 'verified')", http.StatusBadRequest)
+		return
+	}
+
+	through := math.MaxInt
+	if throughStr := r.URL.Query().Get("through"); throughStr != "" {
+		through, err = strconv.Atoi(throughStr)
+		if err != nil {
+			http.Error(w, "invalid 'through' parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Quotes are chosen from chapters at or before through, so a quote (and
+	// its deep link) from later in the story never reaches the reader early.
+	var filtered []model.AggregatedRelationship
+	for _, rel := range data.Relationships {
+		if rel.FirstChapterIndex > through {
+			continue
+		}
+		if rel.Quotes == nil && rel.Quote != "" {
+			// Aggregated before per-chapter quotes were kept.
+			rel.Quotes = []model.RelationshipQuote{{ChapterIndex: rel.QuoteChapterIndex, Quote: rel.Quote, Verified: rel.QuoteVerified, Paragraph: rel.QuoteParagraph}}
+		}
+		rel.SetQuoteAsOf(through)
+		rel.Quotes = nil
+		if verifiedOnly && !rel.QuoteVerified {
+			rel.Quote = ""
+		}
+		filtered = append(filtered, rel)
+	}
+	if filtered == nil {
+		writeJSON(w, nil)
+		return
+	}
+	writeJSON(w, filtered)
+}
+
+func (s *Server) handleCoordinates(w http.ResponseWriter, r *http.Request) {
+	coords, err := s.Store.ReadCoordinates()
+	if err != nil {
+		writeJSON(w, []any{})
+		return
+	}
+
 	throughStr := r.URL.Query().Get("through")
 	if throughStr != "" {
 		through, err := strconv.Atoi(throughStr)
@@ -73,25 +158,57 @@ func (s *Server) handleRelationships(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var filtered []any
-		for _, rel := range data.Relationships {
-			if rel.FirstChapterIndex <= through {
-				filtered = append(filtered, rel)
+		for _, c := range coords {
+			if c.FirstChapterIndex <= through {
+				filtered = append(filtered, c)
 			}
 		}
 		writeJSON(w, filtered)
 		return
 	}
 
-	writeJSON(w, data.Relationships)
+	writeJSON(w, coords)
 }
 
-func (s *Server) handleCoordinates(w http.ResponseWriter, r *http.Request) {
-	coords, err := s.Store.ReadCoordinates()
+// handleCharacters returns each character's last known location as of the
+// through chapter, or across all extracted chapters if through is absent.
+func (s *Server) handleCharacters(w http.ResponseWriter, r *http.Request) {
+	through := math.MaxInt
+	if throughStr := r.URL.Query().Get("through"); throughStr != "" {
+		var err error
+		through, err = strconv.Atoi(throughStr)
+		if err != nil {
+			http.Error(w, "invalid 'through' parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	chars, err := s.Store.LastCharacterLocations(through)
 	if err != nil {
-		writeJSON(w, []any{})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, coords)
+
+	// Link sightings to map locations the reader has already reached.
+	known := make(map[string]bool)
+	if data, err := s.Store.ReadAggregated(); err == nil {
+		for _, loc := range data.Locations {
+			if loc.FirstChapterIndex <= through {
+				known[loc.ID] = true
+			}
+		}
+	}
+	gaz := s.Gazetteer
+	if gaz == nil {
+		gaz = aggregator.DefaultGazetteer()
+	}
+	for i := range chars {
+		if id := gaz.Key(chars[i].Location); known[id] {
+			chars[i].LocationID = id
+		}
+	}
+
+	writeJSON(w, chars)
 }
 
 func (s *Server) handleContainment(w http.ResponseWriter, r *http.Request) {
@@ -100,6 +217,25 @@ func (s *Server) handleContainment(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	throughStr := r.URL.Query().Get("through")
+	if throughStr != "" {
+		through, err := strconv.Atoi(throughStr)
+		if err != nil {
+			http.Error(w, "invalid 'through' parameter", http.StatusBadRequest)
+			return
+		}
+
+		var filtered []any
+		for _, c := range data.Containment {
+			if c.FirstChapterIndex <= through {
+				filtered = append(filtered, c)
+			}
+		}
+		writeJSON(w, filtered)
+		return
+	}
+
 	writeJSON(w, data.Containment)
 }
 
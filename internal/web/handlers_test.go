@@ -92,6 +92,55 @@ func TestHandleLocationsWithThrough(t *testing.T) {
 	}
 }
 
+func TestHandleLocationsDescriptionAsOfThrough(t *testing.T) {
+	srv := testServer(t)
+
+	data := &model.AggregatedData{
+		AggregatedAt: "2025-01-01",
+		Locations: []model.AggregatedLocation{
+			{ID: "liscor", Name: "Liscor", Type: "city", FirstChapterIndex: 0, MentionCount: 50,
+				Description: "A walled city whose secret was revealed in Volume 9"},
+		},
+		Descriptions: []model.DescriptionRevision{
+			{LocationID: "liscor", ChapterIndex: 0, Description: "A city", VisualDescription: "Green walls"},
+			{LocationID: "liscor", ChapterIndex: 5, Description: "A walled Drake city"},
+			{LocationID: "liscor", ChapterIndex: 500, Description: "A walled city whose secret was revealed in Volume 9"},
+		},
+	}
+	if err := srv.Store.WriteAggregated(data); err != nil {
+		t.Fatalf("writing aggregated: %v", err)
+	}
+
+	tests := []struct {
+		through    string
+		wantDesc   string
+		wantVisual string
+	}{
+		{"0", "A city", "Green walls"},
+		{"10", "A walled Drake city", "Green walls"},
+		{"600", "A walled city whose secret was revealed in Volume 9", "Green walls"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/locations?through="+tt.through, nil)
+		w := httptest.NewRecorder()
+		srv.handleLocations(w, req)
+
+		var locs []model.AggregatedLocation
+		if err := json.NewDecoder(w.Body).Decode(&locs); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if len(locs) != 1 {
+			t.Fatalf("through=%s: expected 1 location, got %d", tt.through, len(locs))
+		}
+		if locs[0].Description != tt.wantDesc {
+			t.Errorf("through=%s: expected description %q, got %q", tt.through, tt.wantDesc, locs[0].Description)
+		}
+		if locs[0].VisualDescription != tt.wantVisual {
+			t.Errorf("through=%s: expected visual description %q, got %q", tt.through, tt.wantVisual, locs[0].VisualDescription)
+		}
+	}
+}
+
 func TestHandleLocationsInvalidThrough(t *testing.T) {
 	srv := testServer(t)
 
@@ -129,6 +178,119 @@ func TestHandleCoordinates(t *testing.T) {
 	}
 }
 
+func TestHandleCoordinatesWithThrough(t *testing.T) {
+	srv := testServer(t)
+
+	for _, c := range []model.Coordinate{
+		{LocationID: "liscor", X: 240, Y: -20, Confidence: "estimated", FirstChapterIndex: 0},
+		{LocationID: "pallass", X: 280, Y: -50, Confidence: "estimated", FirstChapterIndex: 100},
+		{LocationID: "nombernaught", X: 350, Y: -150, Confidence: "estimated", FirstChapterIndex: model.UnrevealedChapter},
+	} {
+		if err := srv.Store.WriteCoordinate(c); err != nil {
+			t.Fatalf("writing coordinate: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/coordinates?through=50", nil)
+	w := httptest.NewRecorder()
+	srv.handleCoordinates(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var coords []model.Coordinate
+	if err := json.NewDecoder(w.Body).Decode(&coords); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(coords) != 1 || coords[0].LocationID != "liscor" {
+		t.Errorf("expected only liscor, got %+v", coords)
+	}
+}
+
+func TestHandleContainmentWithThrough(t *testing.T) {
+	srv := testServer(t)
+
+	data := &model.AggregatedData{
+		AggregatedAt: "2025-01-01",
+		Containment: []model.Containment{
+			{Child: "Liscor", Parent: "Izril", FirstChapterIndex: 0},
+			{Child: "Pallass", Parent: "Izril", FirstChapterIndex: 100},
+		},
+	}
+	if err := srv.Store.WriteAggregated(data); err != nil {
+		t.Fatalf("writing aggregated: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/containment?through=50", nil)
+	w := httptest.NewRecorder()
+	srv.handleContainment(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var cont []model.Containment
+	if err := json.NewDecoder(w.Body).Decode(&cont); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(cont) != 1 || cont[0].Child != "Liscor" {
+		t.Errorf("expected only Liscor containment, got %+v", cont)
+	}
+
+	req = httptest.NewRequest("GET", "/api/containment?through=abc", nil)
+	w = httptest.NewRecorder()
+	srv.handleContainment(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid through, got %d", w.Code)
+	}
+}
+
+func TestHandleRelationshipsQuotes(t *testing.T) {
+	srv := testServer(t)
+
+	data := &model.AggregatedData{
+		AggregatedAt: "2025-01-01",
+		Relationships: []model.AggregatedRelationship{
+			{From: "The Wandering Inn", To: "Liscor", Type: "adjacency", Quote: "outside Liscor",
+				QuoteVerified: true, QuoteChapterIndex: 2, QuoteParagraph: 14},
+			{From: "Liscor", To: "Pallass", Type: "direction", Quote: "invented"},
+		},
+	}
+	if err := srv.Store.WriteAggregated(data); err != nil {
+		t.Fatalf("writing aggregated: %v", err)
+	}
+
+	get := func(query string) (int, []model.AggregatedRelationship) {
+		req := httptest.NewRequest("GET", "/api/relationships"+query, nil)
+		w := httptest.NewRecorder()
+		srv.handleRelationships(w, req)
+		var rels []model.AggregatedRelationship
+		json.NewDecoder(w.Body).Decode(&rels)
+		return w.Code, rels
+	}
+
+	code, rels := get("")
+	if code != http.StatusOK || len(rels) != 2 {
+		t.Fatalf("expected 2 relationships, got %d / %+v", code, rels)
+	}
+	if !rels[0].QuoteVerified || rels[0].QuoteChapterIndex != 2 || rels[0].QuoteParagraph != 14 {
+		t.Errorf("expected verification to round-trip, got %+v", rels[0])
+	}
+	if rels[1].Quote != "invented" || rels[1].QuoteVerified {
+		t.Errorf("expected unverified quote returned unbadged by default, got %+v", rels[1])
+	}
+
+	_, rels = get("?quotes=verified")
+	if rels[0].Quote != "outside Liscor" || rels[1].Quote != "" {
+		t.Errorf("expected only the unverified quote dropped, got %+v", rels)
+	}
+
+	if code, _ := get("?quotes=maybe"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid quotes, got %d", code)
+	}
+}
+
 func TestWriteJSONNil(t *testing.T) {
 	w := httptest.NewRecorder()
 	writeJSON(w, nil)
@@ -140,3 +302,127 @@ func TestWriteJSONNil(t *testing.T) {
 		t.Errorf("expected application/json, got %q", ct)
 	}
 }
+
+func TestHandleCharactersWithThrough(t *testing.T) {
+	srv := testServer(t)
+
+	toc := &model.TOC{
+		Chapters: []model.Chapter{
+			{Index: 0, WebTitle: "1.00", URL: "https://example.com/1-00", Volume: "vol-1", Slug: "1-00"},
+			{Index: 1, WebTitle: "1.01", URL: "https://example.com/1-01", Volume: "vol-1", Slug: "1-01"},
+		},
+	}
+	if err := srv.Store.WriteTOC(toc); err != nil {
+		t.Fatalf("writing TOC: %v", err)
+	}
+	for i, loc := range []string{"Liscor", "Celum"} {
+		ch := &model.ChapterCharacters{
+			ChapterIndex: i,
+			Model:        "test-model",
+			ExtractedAt:  "2025-01-01T00:00:00Z",
+			Sightings:    []model.CharacterSighting{{Character: "Erin Solstice", Location: loc, Role: model.SightingPresent}},
+		}
+		if err := srv.Store.WriteCharacters(ch); err != nil {
+			t.Fatalf("writing characters: %v", err)
+		}
+	}
+	data := &model.AggregatedData{
+		AggregatedAt: "2025-01-01",
+		Locations: []model.AggregatedLocation{
+			{ID: "liscor", Name: "Liscor", Type: "city", FirstChapterIndex: 0},
+			{ID: "celum", Name: "Celum", Type: "city", FirstChapterIndex: 1},
+		},
+	}
+	if err := srv.Store.WriteAggregated(data); err != nil {
+		t.Fatalf("writing aggregated: %v", err)
+	}
+
+	get := func(url string) []model.CharacterLocation {
+		t.Helper()
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		srv.handleCharacters(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var chars []model.CharacterLocation
+		if err := json.NewDecoder(w.Body).Decode(&chars); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		return chars
+	}
+
+	if chars := get("/api/characters?through=0"); len(chars) != 1 || chars[0].Location != "Liscor" || chars[0].LocationID != "liscor" {
+		t.Errorf("expected Erin in Liscor as of chapter 0, got %+v", chars)
+	}
+	if chars := get("/api/characters"); len(chars) != 1 || chars[0].LocationID != "celum" || chars[0].ChapterIndex != 1 {
+		t.Errorf("expected Erin in Celum without a limit, got %+v", chars)
+	}
+
+	req := httptest.NewRequest("GET", "/api/characters?through=abc", nil)
+	w := httptest.NewRecorder()
+	srv.handleCharacters(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid through, got %d", w.Code)
+	}
+}
+
+func TestHandleRelationshipsQuoteThrough(t *testing.T) {
+	srv := testServer(t)
+
+	data := &model.AggregatedData{
+		AggregatedAt: "2025-01-01",
+		Relationships: []model.AggregatedRelationship{
+			{From: "The Wandering Inn", To: "Liscor", Type: "adjacency", FirstChapterIndex: 3,
+				Quote: "the inn by the city", QuoteVerified: true, QuoteChapterIndex: 300, QuoteParagraph: 9,
+				Quotes: []model.RelationshipQuote{
+					{ChapterIndex: 3, Quote: "an inn, near a city"},
+					{ChapterIndex: 300, Quote: "the inn by the city", Verified: true, Paragraph: 9},
+				}},
+			{From: "Liscor", To: "Pallass", Type: "direction", FirstChapterIndex: 4,
+				Quote: "north of Liscor", QuoteVerified: true, QuoteChapterIndex: 200,
+				Quotes: []model.RelationshipQuote{{ChapterIndex: 200, Quote: "north of Liscor", Verified: true}}},
+		},
+	}
+	if err := srv.Store.WriteAggregated(data); err != nil {
+		t.Fatalf("writing aggregated: %v", err)
+	}
+
+	get := func(query string) []model.AggregatedRelationship {
+		req := httptest.NewRequest("GET", "/api/relationships"+query, nil)
+		w := httptest.NewRecorder()
+		srv.handleRelationships(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var rels []model.AggregatedRelationship
+		json.NewDecoder(w.Body).Decode(&rels)
+		return rels
+	}
+
+	rels := get("?through=5")
+	if len(rels) != 2 {
+		t.Fatalf("expected 2 relationships, got %+v", rels)
+	}
+	for _, rel := range rels {
+		if rel.QuoteChapterIndex > 5 || rel.Quotes != nil {
+			t.Errorf("expected no quote from past chapter 5, got %+v", rel)
+		}
+	}
+	if rels[0].Quote != "an inn, near a city" || rels[0].QuoteVerified {
+		t.Errorf("expected the earlier unverified quote, got %+v", rels[0])
+	}
+	if rels[1].Quote != "" {
+		t.Errorf("expected no quote before chapter 200, got %+v", rels[1])
+	}
+
+	rels = get("?through=300")
+	if rels[0].Quote != "the inn by the city" || !rels[0].QuoteVerified || rels[0].QuoteParagraph != 9 {
+		t.Errorf("expected the verified quote once reached, got %+v", rels[0])
+	}
+
+	rels = get("?through=5&quotes=verified")
+	if rels[0].Quote != "" {
+		t.Errorf("expected the unverified early quote dropped, got %+v", rels[0])
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"net/http"
 
+	"github.com/intelligrit/twi-map/internal/aggregator"
 	"github.com/intelligrit/twi-map/internal/store"
 )
 
@@ -16,6 +17,9 @@ var staticFS embed.FS
 type Server struct {
 	Store *store.Store
 	Addr  string
+	// Gazetteer maps names to the location IDs aggregate produced. Nil
+	// means aggregator.DefaultGazetteer.
+	Gazetteer *aggregator.Gazetteer
 }
 
 // ListenAndServe starts the HTTP server.
@@ -28,6 +32,7 @@ func (s *Server) ListenAndServe() error {
 	mux.HandleFunc("/api/relationships", s.handleRelationships)
 	mux.HandleFunc("/api/coordinates", s.handleCoordinates)
 	mux.HandleFunc("/api/containment", s.handleContainment)
+	mux.HandleFunc("/api/characters", s.handleCharacters)
 
 	// Static files
 	staticSub, err := fs.Sub(staticFS, "static")
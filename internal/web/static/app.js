@@ -65,6 +65,35 @@ function escapeHtml(str) {
     .replace(/"/g, '&quot;').replace(/'/g, '&#039;');
 }
 
+// Build the popup for a relationship line. Verified quotes link to their
+// paragraph in the chapter via a text fragment; unverified ones are badged.
+function relationshipPopup(rel) {
+  const chTitle = chapters[rel.first_chapter_index]
+    ? chapters[rel.first_chapter_index].web_title : '';
+  let popup = `<b>${escapeHtml(rel.from)}</b> &rarr; <b>${escapeHtml(rel.to)}</b>`;
+  popup += `<div class="popup-type">${escapeHtml(rel.type)}: ${escapeHtml(rel.detail)}</div>`;
+  if (rel.quote) {
+    popup += `<div class="popup-visual">&ldquo;${escapeHtml(rel.quote)}&rdquo;`;
+    const source = chapters[rel.quote_chapter_index];
+    if (rel.quote_verified && source) {
+      popup += ` <a class="popup-source" href="${escapeHtml(quoteLink(source.url, rel.quote))}" target="_blank" rel="noopener">Ch ${rel.quote_chapter_index + 1} &para;${rel.quote_paragraph + 1}</a>`;
+    } else if (!rel.quote_verified) {
+      popup += ` <span class="popup-unverified" title="Not found in the chapter text">unverified</span>`;
+    }
+    popup += `</div>`;
+  }
+  popup += `<div class="popup-meta">First mentioned: Ch ${rel.first_chapter_index + 1}${chTitle ? ' — ' + escapeHtml(chTitle) : ''}</div>`;
+  return popup;
+}
+
+// Link to a quote in its chapter using a text fragment built from its first
+// few words; browsers that don't support fragments just open the chapter.
+function quoteLink(url, quote) {
+  const words = quote.split(/\s+/).filter(Boolean).slice(0, 8).join(' ');
+  const fragment = encodeURIComponent(words).replace(/-/g, '%2D');
+  return `${url}#:~:text=${fragment}`;
+}
+
 async function init() {
   twiMap = L.map('map', {
     crs: L.CRS.Simple,
@@ -308,8 +337,8 @@ async function loadData() {
     const [locResp, relResp, coordResp, contResp] = await Promise.all([
       fetch('api/locations?through=' + through),
       fetch('api/relationships?through=' + through),
-      fetch('api/coordinates'),
-      fetch('api/containment')
+      fetch('api/coordinates?through=' + through),
+      fetch('api/containment?through=' + through)
     ]);
 
     locations = await locResp.json();
@@ -365,14 +394,7 @@ function renderMap() {
           { color: '#ffffff40', weight: 2, dashArray: '4 4' }
         ).addTo(lineLayer);
 
-        const chTitle = chapters[rel.first_chapter_index]
-          ? chapters[rel.first_chapter_index].web_title : '';
-        let popup = `<b>${escapeHtml(rel.from)}</b> &rarr; <b>${escapeHtml(rel.to)}</b>`;
-        popup += `<div class="popup-type">${escapeHtml(rel.type)}: ${escapeHtml(rel.detail)}</div>`;
-        if (rel.quote) {
-          popup += `<div class="popup-visual">&ldquo;${escapeHtml(rel.quote)}&rdquo;</div>`;
-        }
-        popup += `<div class="popup-meta">First mentioned: Ch ${rel.first_chapter_index + 1}${chTitle ? ' — ' + escapeHtml(chTitle) : ''}</div>`;
+        const popup = relationshipPopup(rel);
         line.bindPopup(popup, { maxWidth: 350 });
       }
     });
@@ -408,14 +430,7 @@ function renderMap() {
         { color: '#ffffff30', weight: 2, dashArray: '2 6' }
       ).addTo(lineLayer);
 
-      const chTitle = chapters[rel.first_chapter_index]
-        ? chapters[rel.first_chapter_index].web_title : '';
-      let popup = `<b>${escapeHtml(rel.from)}</b> &rarr; <b>${escapeHtml(rel.to)}</b>`;
-      popup += `<div class="popup-type">${escapeHtml(rel.type)}: ${escapeHtml(rel.detail)}</div>`;
-      if (rel.quote) {
-        popup += `<div class="popup-visual">&ldquo;${escapeHtml(rel.quote)}&rdquo;</div>`;
-      }
-      popup += `<div class="popup-meta">First mentioned: Ch ${rel.first_chapter_index + 1}${chTitle ? ' — ' + escapeHtml(chTitle) : ''}</div>`;
+      const popup = relationshipPopup(rel);
       line.bindPopup(popup, { maxWidth: 350 });
 
       // Wide invisible hit-area line for easy clicking